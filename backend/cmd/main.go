@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -15,6 +21,7 @@ import (
 	"github.com/shrey258/task_management/internal/handlers"
 	"github.com/shrey258/task_management/internal/middleware"
 	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/storage"
 	ws "github.com/shrey258/task_management/internal/websocket"
 )
 
@@ -37,19 +44,27 @@ func main() {
 	}
 	defer gemini.Close()
 
-	// Create Fiber app with custom config
+	// Create Fiber app with custom config. Request bodies are streamed, so
+	// that attachment uploads can be larger than the body limit of other routes
 	app := fiber.New(fiber.Config{
-		AppName: "Task Management API",
+		AppName:                      "Task Management API",
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
+	// Limit request bodies, except attachment uploads which are capped by
+	// their handler
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, isAttachmentUpload))
+
 	// Add logger middleware
 	app.Use(logger.New())
 
 	// Configure CORS
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: os.Getenv("FRONTEND_URL"),
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET, POST, PUT, DELETE",
+		AllowOrigins:  os.Getenv("FRONTEND_URL"),
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, If-Match",
+		AllowMethods:  "GET, POST, PUT, DELETE",
+		ExposeHeaders: "ETag",
 	}))
 
 	// Initialize WebSocket hub
@@ -64,8 +79,13 @@ func main() {
 		})
 	})
 
+	// Stop on SIGINT or SIGTERM, letting background jobs finish first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var jobs sync.WaitGroup
+
 	// Setup routes
-	setupRoutes(app, hub, gemini)
+	setupRoutes(ctx, &jobs, app, hub, gemini)
 
 	// Get port from environment variable
 	port := os.Getenv("PORT")
@@ -78,47 +98,231 @@ func main() {
 	log.Printf("Environment: %s", os.Getenv("GO_ENV"))
 	log.Printf("MongoDB URI: %s", os.Getenv("MONGODB_URI"))
 
+	go func() {
+		<-ctx.Done()
+		log.Printf("Shutting down")
+		if err := app.Shutdown(); err != nil {
+			log.Printf("Warning: Failed to shut down the server: %v", err)
+		}
+	}()
+
 	// Start server with explicit host and port
 	addr := fmt.Sprintf("0.0.0.0:%s", port)
 	if err := app.Listen(addr); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
+	stop()
+	jobs.Wait()
+}
+
+// attachmentUploadPath matches the attachment upload route.
+var attachmentUploadPath = regexp.MustCompile(`(?i)^/api/tasks/[^/]+/attachments/?$`)
+
+func isAttachmentUpload(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPost && attachmentUploadPath.MatchString(c.Path())
 }
 
-func setupRoutes(app *fiber.App, hub *ws.Hub, gemini *ai.GeminiService) {
+func setupRoutes(ctx context.Context, jobs *sync.WaitGroup, app *fiber.App, hub *ws.Hub, gemini *ai.GeminiService) {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository()
 	taskRepo := repository.NewTaskRepository()
+	projectRepo := repository.NewProjectRepository()
+	commentRepo := repository.NewCommentRepository()
+	activityRepo := repository.NewActivityRepository()
+	viewRepo := repository.NewSavedViewRepository()
+	wipRepo := repository.NewWIPViolationRepository()
+	workLogRepo := repository.NewWorkLogRepository()
+	templateRepo := repository.NewTaskTemplateRepository()
+	attachmentRepo := repository.NewAttachmentRepository()
+	notificationRepo := repository.NewNotificationRepository()
+	leaseRepo := repository.NewLeaseRepository()
+
+	// Ensure indexes
+	indexCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := taskRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create task indexes: %v", err)
+	}
+	if err := projectRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create project indexes: %v", err)
+	}
+	if err := commentRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create comment indexes: %v", err)
+	}
+	if err := activityRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create activity indexes: %v", err)
+	}
+	if err := viewRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create saved view indexes: %v", err)
+	}
+	if err := wipRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create WIP violation indexes: %v", err)
+	}
+	if err := workLogRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create work log indexes: %v", err)
+	}
+	if err := templateRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create task template indexes: %v", err)
+	}
+	if err := attachmentRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create attachment indexes: %v", err)
+	}
+	if err := notificationRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to create notification indexes: %v", err)
+	}
+
+	// Initialize blob storage for attachments
+	blobs, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize blob storage: %v", err)
+	}
 
 	// Initialize handlers
+	notifier := handlers.NewNotifier(notificationRepo, userRepo, hub)
 	authHandler := handlers.NewAuthHandler(userRepo)
-	taskHandler := handlers.NewTaskHandler(taskRepo, hub)
+	taskHandler := handlers.NewTaskHandler(taskRepo, projectRepo, commentRepo, activityRepo, wipRepo, workLogRepo, attachmentRepo, blobs, notifier, hub)
+	commentHandler := handlers.NewCommentHandler(commentRepo, taskRepo, projectRepo, userRepo, notifier, hub)
+	projectHandler := handlers.NewProjectHandler(projectRepo, taskRepo, wipRepo)
+	viewHandler := handlers.NewViewHandler(viewRepo, taskRepo, projectRepo, userRepo)
+	templateHandler := handlers.NewTemplateHandler(templateRepo, projectRepo, taskHandler)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo, userRepo, hub)
 	wsHandler := handlers.NewWebSocketHandler(hub)
-	aiHandler := handlers.NewAIHandler(gemini)
+	aiHandler := handlers.NewAIHandler(gemini, projectRepo)
 	log.Println("Initializing chat handler...")
 	chatHandler, err := handlers.NewChatHandler()
 	if err != nil {
 		log.Printf("Warning: Failed to initialize chat handler: %v", err)
 	}
 
+	// Purge tasks that have been in the trash past their retention period
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		taskHandler.RunTrashPurger(ctx, time.Hour)
+	}()
+
+	// Send due date reminders and overdue notifications
+	reminders := handlers.NewReminderScheduler(taskRepo, leaseRepo, notifier)
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		reminders.Run(ctx, time.Minute)
+	}()
+
 	// Auth routes
 	auth := app.Group("/auth")
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
 
+	// Attachment downloads, authorized by their signed URL
+	app.Get("/files/:id", taskHandler.DownloadAttachment)
+
 	// Protected routes
 	protected := app.Group("/api", middleware.Protected())
 	
 	// User routes
 	protected.Get("/user", authHandler.GetCurrentUser)
+	protected.Put("/user", authHandler.UpdateCurrentUser)
+
+	// Search routes
+	protected.Get("/search", taskHandler.SearchTasks)
+
+	// Board routes
+	protected.Get("/board", taskHandler.GetBoard)
+	protected.Get("/timer", taskHandler.GetTimer)
+	protected.Get("/timesheet", taskHandler.GetTimesheet)
 
 	// Task routes
 	tasks := protected.Group("/tasks")
 	tasks.Post("/", taskHandler.CreateTask)
 	tasks.Get("/", taskHandler.GetTasks)
+	tasks.Post("/bulk", taskHandler.BulkUpdateTasks)
 	tasks.Get("/:id", taskHandler.GetTask)
 	tasks.Put("/:id", taskHandler.UpdateTask)
 	tasks.Delete("/:id", taskHandler.DeleteTask)
+	tasks.Post("/:id/move", taskHandler.MoveTask)
+	tasks.Post("/:id/restore", taskHandler.RestoreTask)
+	tasks.Post("/:id/clone", taskHandler.CloneTask)
+	tasks.Post("/:id/checklist", taskHandler.AddChecklistItem)
+	tasks.Put("/:id/checklist/:itemId", taskHandler.UpdateChecklistItem)
+	tasks.Post("/:id/checklist/:itemId/move", taskHandler.MoveChecklistItem)
+	tasks.Delete("/:id/checklist/:itemId", taskHandler.DeleteChecklistItem)
+	tasks.Post("/:id/shares", taskHandler.ShareTask)
+	tasks.Delete("/:id/shares/:userId", taskHandler.UnshareTask)
+	tasks.Get("/:id/children", taskHandler.GetTaskChildren)
+	tasks.Get("/:id/tree", taskHandler.GetTaskTree)
+	tasks.Get("/:id/dependencies", taskHandler.GetTaskDependencies)
+	tasks.Post("/:id/dependencies", taskHandler.AddTaskDependency)
+	tasks.Delete("/:id/dependencies/:blockerId", taskHandler.RemoveTaskDependency)
+	tasks.Get("/:id/critical-path", taskHandler.GetCriticalPath)
+	tasks.Get("/:id/occurrences", taskHandler.GetTaskOccurrences)
+	tasks.Put("/:id/series", taskHandler.UpdateTaskSeries)
+	tasks.Get("/:id/history", taskHandler.GetTaskHistory)
+	tasks.Post("/:id/timer/start", taskHandler.StartTimer)
+	tasks.Post("/:id/timer/stop", taskHandler.StopTimer)
+	tasks.Get("/:id/worklogs", taskHandler.GetWorkLogs)
+	tasks.Post("/:id/worklogs", taskHandler.AddWorkLog)
+	tasks.Delete("/:id/worklogs/:logId", taskHandler.DeleteWorkLog)
+	tasks.Get("/:id/history/snapshot", taskHandler.GetTaskSnapshot)
+	tasks.Get("/:id/attachments", taskHandler.GetAttachments)
+	tasks.Post("/:id/attachments", taskHandler.UploadAttachment)
+	tasks.Get("/:id/attachments/:attachmentId/url", taskHandler.GetAttachmentURL)
+	tasks.Delete("/:id/attachments/:attachmentId", taskHandler.DeleteAttachment)
+	tasks.Get("/:id/comments", commentHandler.GetComments)
+	tasks.Post("/:id/comments", commentHandler.CreateComment)
+	tasks.Put("/:id/comments/:commentId", commentHandler.UpdateComment)
+	tasks.Delete("/:id/comments/:commentId", commentHandler.DeleteComment)
+
+	// Trash routes
+	trash := protected.Group("/trash")
+	trash.Get("/", taskHandler.GetTrash)
+	trash.Delete("/:id", taskHandler.PurgeTask)
+
+	// Project routes
+	projects := protected.Group("/projects")
+	projects.Post("/", projectHandler.CreateProject)
+	projects.Get("/", projectHandler.GetProjects)
+	projects.Get("/:id", projectHandler.GetProject)
+	projects.Put("/:id", projectHandler.UpdateProject)
+	projects.Delete("/:id", projectHandler.DeleteProject)
+	projects.Post("/:id/members", projectHandler.SetProjectMember)
+	projects.Delete("/:id/members/:userId", projectHandler.RemoveProjectMember)
+	projects.Get("/:id/tasks", projectHandler.GetProjectTasks)
+	projects.Get("/:id/fields", projectHandler.GetCustomFields)
+	projects.Post("/:id/fields", projectHandler.CreateCustomField)
+	projects.Put("/:id/fields/:fieldId", projectHandler.UpdateCustomField)
+	projects.Delete("/:id/fields/:fieldId", projectHandler.DeleteCustomField)
+	projects.Get("/:id/workflow", projectHandler.GetWorkflow)
+	projects.Put("/:id/workflow", projectHandler.UpdateWorkflow)
+	projects.Put("/:id/wip-limits", projectHandler.UpdateWIPLimits)
+	projects.Get("/:id/wip", projectHandler.GetWIP)
+	projects.Get("/:id/wip/violations", projectHandler.GetWIPViolations)
+
+	// Saved view routes
+	views := protected.Group("/views")
+	views.Post("/", viewHandler.CreateView)
+	views.Get("/", viewHandler.GetViews)
+	views.Get("/:id", viewHandler.GetView)
+	views.Put("/:id", viewHandler.UpdateView)
+	views.Delete("/:id", viewHandler.DeleteView)
+	views.Get("/:id/tasks", viewHandler.GetViewTasks)
+
+	// Task template routes
+	templates := protected.Group("/templates")
+	templates.Post("/", templateHandler.CreateTemplate)
+	templates.Get("/", templateHandler.GetTemplates)
+	templates.Get("/:id", templateHandler.GetTemplate)
+	templates.Put("/:id", templateHandler.UpdateTemplate)
+	templates.Delete("/:id", templateHandler.DeleteTemplate)
+	templates.Post("/:id/instantiate", templateHandler.InstantiateTemplate)
+
+	// Notification routes
+	notifications := protected.Group("/notifications")
+	notifications.Get("/", notificationHandler.GetNotifications)
+	notifications.Post("/read-all", notificationHandler.MarkAllNotificationsRead)
+	notifications.Get("/preferences", notificationHandler.GetNotificationPreferences)
+	notifications.Put("/preferences", notificationHandler.UpdateNotificationPreferences)
+	notifications.Post("/:id/read", notificationHandler.MarkNotificationRead)
 
 	// AI routes
 	ai := protected.Group("/ai")
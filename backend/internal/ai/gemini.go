@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
@@ -23,6 +24,12 @@ type TaskSuggestion struct {
 	SubTasks    []string `json:"sub_tasks,omitempty"`
 }
 
+// TaskField is a custom field value of a task, as shown to the model.
+type TaskField struct {
+	Name  string
+	Value string
+}
+
 func NewGeminiService() (*GeminiService, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
@@ -86,11 +93,21 @@ Focus on actionable items and clear priorities. Keep descriptions concise but in
 	return suggestions, nil
 }
 
-func (s *GeminiService) AnalyzeTaskPriority(ctx context.Context, title, description string) (*TaskSuggestion, error) {
+// AnalyzeTaskPriority suggests a priority and tags for a task. fields are
+// the task's custom field values, which often say a lot about its urgency.
+func (s *GeminiService) AnalyzeTaskPriority(ctx context.Context, title, description string, fields []TaskField) (*TaskSuggestion, error) {
+	var details strings.Builder
+	if len(fields) > 0 {
+		details.WriteString("Custom fields:\n")
+		for _, field := range fields {
+			fmt.Fprintf(&details, "- %s: %s\n", field.Name, field.Value)
+		}
+	}
+
 	prompt := fmt.Sprintf(`Analyze the following task and suggest appropriate priority and tags:
 Title: %s
 Description: %s
-
+%s
 Please provide the response in the following JSON format:
 {
   "title": "Original title",
@@ -99,7 +116,7 @@ Please provide the response in the following JSON format:
   "tags": ["tag1", "tag2"]
 }
 
-Consider factors like urgency, impact, and complexity when determining priority.`, title, description)
+Consider factors like urgency, impact, and complexity when determining priority.`, title, description, details.String())
 
 	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
@@ -15,7 +15,8 @@ var (
 	db     *mongo.Database
 )
 
-// Connect establishes a connection to MongoDB
+// Connect establishes a connection to MongoDB. MONGODB_DATABASE names the
+// database to use, task_management by default.
 func Connect() error {
 	uri := os.Getenv("MONGODB_URI")
 	if uri == "" {
@@ -38,7 +39,11 @@ func Connect() error {
 		return err
 	}
 
-	db = client.Database("task_management")
+	name := os.Getenv("MONGODB_DATABASE")
+	if name == "" {
+		name = "task_management"
+	}
+	db = client.Database(name)
 	log.Println("Connected to MongoDB!")
 	return nil
 }
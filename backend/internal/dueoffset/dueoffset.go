@@ -0,0 +1,98 @@
+// Package dueoffset parses due dates given relative to another date, such as
+// "+3 business days", "-1 week" or "2d". An offset is an optional sign, a
+// count and a unit: business days (also "bd" or "weekdays"), days ("d"),
+// weeks ("w") or months ("mo"). Business days skip Saturdays and Sundays.
+package dueoffset
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Unit string
+
+const (
+	BusinessDays Unit = "business_days"
+	Days         Unit = "days"
+	Weeks        Unit = "weeks"
+	Months       Unit = "months"
+)
+
+// maxCount bounds offsets to roughly ten years of days.
+const maxCount = 3660
+
+var pattern = regexp.MustCompile(`^([+-]?)\s*(\d+)\s*([a-z ]+)$`)
+
+var units = map[string]Unit{
+	"business day":  BusinessDays,
+	"business days": BusinessDays,
+	"bd":            BusinessDays,
+	"weekday":       BusinessDays,
+	"weekdays":      BusinessDays,
+	"day":           Days,
+	"days":          Days,
+	"d":             Days,
+	"week":          Weeks,
+	"weeks":         Weeks,
+	"w":             Weeks,
+	"month":         Months,
+	"months":        Months,
+	"mo":            Months,
+}
+
+// Offset moves a date by N units, backwards when N is negative.
+type Offset struct {
+	N    int
+	Unit Unit
+}
+
+// Parse reads an offset such as "+3 business days".
+func Parse(s string) (Offset, error) {
+	match := pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if match == nil {
+		return Offset{}, fmt.Errorf("invalid offset %q, want e.g. +3 business days", s)
+	}
+	unit, ok := units[strings.Join(strings.Fields(match[3]), " ")]
+	if !ok {
+		return Offset{}, fmt.Errorf("unknown unit in offset %q; use business days, days, weeks or months", s)
+	}
+	n, err := strconv.Atoi(match[2])
+	if err != nil || n > maxCount {
+		return Offset{}, fmt.Errorf("offset %q is too large", s)
+	}
+	if match[1] == "-" {
+		n = -n
+	}
+	return Offset{N: n, Unit: unit}, nil
+}
+
+// Apply returns t moved by the offset, keeping its time of day.
+func (o Offset) Apply(t time.Time) time.Time {
+	switch o.Unit {
+	case Days:
+		return t.AddDate(0, 0, o.N)
+	case Weeks:
+		return t.AddDate(0, 0, 7*o.N)
+	case Months:
+		return t.AddDate(0, o.N, 0)
+	case BusinessDays:
+		step, remaining := 1, o.N
+		if remaining < 0 {
+			step, remaining = -1, -remaining
+		}
+		for remaining > 0 {
+			t = t.AddDate(0, 0, step)
+			if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+				remaining--
+			}
+		}
+	}
+	return t
+}
+
+func (o Offset) String() string {
+	return fmt.Sprintf("%+d %s", o.N, strings.ReplaceAll(string(o.Unit), "_", " "))
+}
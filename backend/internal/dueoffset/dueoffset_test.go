@@ -0,0 +1,105 @@
+package dueoffset
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Offset
+		wantErr bool
+	}{
+		{in: "+3 business days", want: Offset{N: 3, Unit: BusinessDays}},
+		{in: "1 business day", want: Offset{N: 1, Unit: BusinessDays}},
+		{in: "  +2   Business   Days ", want: Offset{N: 2, Unit: BusinessDays}},
+		{in: "5bd", want: Offset{N: 5, Unit: BusinessDays}},
+		{in: "10 weekdays", want: Offset{N: 10, Unit: BusinessDays}},
+		{in: "2d", want: Offset{N: 2, Unit: Days}},
+		{in: "- 4 days", want: Offset{N: -4, Unit: Days}},
+		{in: "0 days", want: Offset{N: 0, Unit: Days}},
+		{in: "-1 week", want: Offset{N: -1, Unit: Weeks}},
+		{in: "3W", want: Offset{N: 3, Unit: Weeks}},
+		{in: "1mo", want: Offset{N: 1, Unit: Months}},
+		{in: "+6 months", want: Offset{N: 6, Unit: Months}},
+		{in: "3660 days", want: Offset{N: 3660, Unit: Days}},
+		{in: "", wantErr: true},
+		{in: "days", wantErr: true},
+		{in: "+3", wantErr: true},
+		{in: "three days", wantErr: true},
+		{in: "3.5 days", wantErr: true},
+		{in: "+-3 days", wantErr: true},
+		{in: "3 fortnights", wantErr: true},
+		{in: "3 m", wantErr: true},
+		{in: "3661 days", wantErr: true},
+		{in: "99999999999999999999 days", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := Parse(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want an error", tc.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tc.in, err)
+			}
+			if got != tc.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	// 5 January 2024 is a Friday.
+	friday := time.Date(2024, time.January, 5, 17, 30, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	monday := friday.AddDate(0, 0, 3)
+
+	tests := []struct {
+		name   string
+		offset Offset
+		from   time.Time
+		want   time.Time
+	}{
+		{name: "days", offset: Offset{N: 2, Unit: Days}, from: friday, want: friday.AddDate(0, 0, 2)},
+		{name: "weeks back", offset: Offset{N: -2, Unit: Weeks}, from: friday, want: friday.AddDate(0, 0, -14)},
+		{name: "months", offset: Offset{N: 1, Unit: Months}, from: friday, want: friday.AddDate(0, 1, 0)},
+		{name: "business day over a weekend", offset: Offset{N: 1, Unit: BusinessDays}, from: friday, want: monday},
+		{name: "business day from a saturday", offset: Offset{N: 1, Unit: BusinessDays}, from: saturday, want: monday},
+		{name: "business day back over a weekend", offset: Offset{N: -1, Unit: BusinessDays}, from: monday, want: friday},
+		{name: "business week", offset: Offset{N: 5, Unit: BusinessDays}, from: monday, want: monday.AddDate(0, 0, 7)},
+		{name: "business days across weekends", offset: Offset{N: 7, Unit: BusinessDays}, from: friday, want: friday.AddDate(0, 0, 11)},
+		{name: "no business days", offset: Offset{N: 0, Unit: BusinessDays}, from: saturday, want: saturday},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.offset.Apply(tc.from); !got.Equal(tc.want) {
+				t.Errorf("%v applied to %v = %v, want %v", tc.offset, tc.from, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		offset Offset
+		want   string
+	}{
+		{Offset{N: 3, Unit: BusinessDays}, "+3 business days"},
+		{Offset{N: -1, Unit: Weeks}, "-1 weeks"},
+		{Offset{N: 0, Unit: Days}, "+0 days"},
+	}
+	for _, tc := range tests {
+		if got := tc.offset.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+		if parsed, err := Parse(tc.offset.String()); err != nil || parsed != tc.offset {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v", tc.offset.String(), parsed, err, tc.offset)
+		}
+	}
+}
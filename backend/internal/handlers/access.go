@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taskKeyPattern matches project task keys such as "OPS-142".
+var taskKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}-[0-9]+$`)
+
+// taskAccess decides what a user may do with a task. A task is readable by
+// its creator, its assignee, users it is shared with and members of its
+// project; it is editable by its creator, editor shares and project members
+// above the viewer role.
+type taskAccess struct {
+	taskRepo    repository.TaskStore
+	projectRepo repository.ProjectStore
+}
+
+// restrictToVisible limits filter to the tasks userID may read.
+func (a *taskAccess) restrictToVisible(ctx context.Context, userID primitive.ObjectID, filter *models.TaskFilter) error {
+	projects, err := a.projectRepo.FindByMember(ctx, userID, true)
+	if err != nil {
+		return err
+	}
+
+	filter.VisibleTo = &userID
+	filter.VisibleProjects = nil
+	for _, project := range projects {
+		filter.VisibleProjects = append(filter.VisibleProjects, project.ID)
+	}
+	return nil
+}
+
+func (a *taskAccess) project(ctx context.Context, task *models.Task) (*models.Project, error) {
+	if task.ProjectID == nil {
+		return nil, nil
+	}
+	return a.projectRepo.FindByID(ctx, *task.ProjectID)
+}
+
+func (a *taskAccess) canView(ctx context.Context, task *models.Task, userID primitive.ObjectID) (bool, error) {
+	if task.CanView(userID) {
+		return true, nil
+	}
+	project, err := a.project(ctx, task)
+	if err != nil || project == nil {
+		return false, err
+	}
+	return project.CanView(userID), nil
+}
+
+func (a *taskAccess) canEdit(ctx context.Context, task *models.Task, userID primitive.ObjectID) (bool, error) {
+	if task.CanEdit(userID) {
+		return true, nil
+	}
+	project, err := a.project(ctx, task)
+	if err != nil || project == nil {
+		return false, err
+	}
+	return project.CanEditTasks(userID), nil
+}
+
+// summaries summarises the tasks userID may read, in order, and leaves out
+// the rest, so that links to other tasks do not reveal tasks the user cannot
+// open.
+func (a *taskAccess) summaries(ctx context.Context, userID primitive.ObjectID, tasks []*models.Task) ([]models.TaskSummary, error) {
+	summaries := make([]models.TaskSummary, 0, len(tasks))
+	for _, task := range tasks {
+		allowed, err := a.canView(ctx, task, userID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			summaries = append(summaries, task.Summary())
+		}
+	}
+	return summaries, nil
+}
+
+// editable returns the tasks userID may edit, in order, looking each
+// project up once.
+func (a *taskAccess) editable(ctx context.Context, userID primitive.ObjectID, tasks []*models.Task) ([]*models.Task, error) {
+	projects := make(map[primitive.ObjectID]*models.Project)
+	editable := make([]*models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.CanEdit(userID) {
+			editable = append(editable, task)
+			continue
+		}
+		if task.ProjectID == nil {
+			continue
+		}
+		project, ok := projects[*task.ProjectID]
+		if !ok {
+			var err error
+			if project, err = a.projectRepo.FindByID(ctx, *task.ProjectID); err != nil {
+				return nil, err
+			}
+			projects[*task.ProjectID] = project
+		}
+		if project != nil && project.CanEditTasks(userID) {
+			editable = append(editable, task)
+		}
+	}
+	return editable, nil
+}
+
+// audience returns everyone who should hear about changes to the task.
+func (a *taskAccess) audience(ctx context.Context, task *models.Task) []primitive.ObjectID {
+	audience := task.Audience()
+	project, err := a.project(ctx, task)
+	if err != nil || project == nil {
+		return audience
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(audience))
+	for _, id := range audience {
+		seen[id] = true
+	}
+	for _, id := range project.MemberIDs() {
+		if !seen[id] {
+			seen[id] = true
+			audience = append(audience, id)
+		}
+	}
+	return audience
+}
+
+// resolve finds a task by ObjectID or by current or previous project key.
+func (a *taskAccess) resolve(ctx context.Context, ref string) (*models.Task, *fiber.Error) {
+	var (
+		task *models.Task
+		err  error
+	)
+	if id, parseErr := primitive.ObjectIDFromHex(ref); parseErr == nil {
+		task, err = a.taskRepo.FindByID(ctx, id)
+	} else if taskKeyPattern.MatchString(ref) {
+		task, err = a.taskRepo.FindByKey(ctx, ref)
+	} else {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid task id")
+	}
+
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch task")
+	}
+	if task == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "task not found")
+	}
+	return task, nil
+}
+
+// load resolves ref on behalf of userID. Tasks the user may not read, or may
+// not edit when requireEdit is set, are reported as not found so that task
+// IDs cannot be probed.
+func (a *taskAccess) load(ctx context.Context, ref string, userID primitive.ObjectID, requireEdit bool) (*models.Task, *fiber.Error) {
+	task, ferr := a.resolve(ctx, ref)
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	check := a.canView
+	if requireEdit {
+		check = a.canEdit
+	}
+	allowed, err := check(ctx, task, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch task")
+	}
+	if !allowed {
+		return nil, fiber.NewError(fiber.StatusNotFound, "task not found")
+	}
+	return task, nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTasksTheCallerCannotSeeAreNotFound(t *testing.T) {
+	h := newTestTaskHandler(t)
+	app := testApp()
+	app.Get("/tasks", h.GetTasks)
+	app.Get("/tasks/:id", h.GetTask)
+	app.Put("/tasks/:id", h.UpdateTask)
+	app.Delete("/tasks/:id", h.DeleteTask)
+
+	owner, viewer, editor, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	task := createTask(t, h, &models.Task{Title: "private", Status: models.StatusTodo, CreatedBy: owner, SharedWith: []models.TaskShare{
+		{UserID: viewer, Role: models.ShareRoleViewer},
+		{UserID: editor, Role: models.ShareRoleEditor},
+	}})
+	missing := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name   string
+		caller primitive.ObjectID
+		method string
+		body   interface{}
+		want   int
+	}{
+		{"stranger reading", stranger, "GET", nil, fiber.StatusNotFound},
+		{"stranger updating", stranger, "PUT", fiber.Map{"title": "mine"}, fiber.StatusNotFound},
+		{"stranger deleting", stranger, "DELETE", nil, fiber.StatusNotFound},
+		{"viewer reading", viewer, "GET", nil, fiber.StatusOK},
+		{"viewer updating", viewer, "PUT", fiber.Map{"title": "mine"}, fiber.StatusNotFound},
+		{"viewer deleting", viewer, "DELETE", nil, fiber.StatusNotFound},
+		{"editor updating", editor, "PUT", fiber.Map{"title": "edited"}, fiber.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, body := send(t, app, tc.caller, tc.method, "/tasks/"+task.ID.Hex(), tc.body)
+			if code != tc.want {
+				t.Fatalf("%s = %d %s, want %d", tc.method, code, body, tc.want)
+			}
+			if code != fiber.StatusNotFound {
+				return
+			}
+			// The answer must not tell the task apart from one that does
+			// not exist.
+			missingCode, missingBody := send(t, app, tc.caller, tc.method, "/tasks/"+missing, tc.body)
+			if code != missingCode || body != missingBody {
+				t.Errorf("%s of a hidden task = %d %s, of a missing one = %d %s", tc.method, code, body, missingCode, missingBody)
+			}
+		})
+	}
+
+	if got := findTask(t, h, task.ID); got == nil || got.Title != "edited" {
+		t.Errorf("task after the requests = %+v, want it kept with the editor's title", got)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		caller primitive.ObjectID
+		want   int
+	}{
+		{"owner", owner, 1},
+		{"viewer", viewer, 1},
+		{"stranger", stranger, 0},
+	} {
+		code, body := send(t, app, tc.caller, "GET", "/tasks", nil)
+		var tasks []*models.Task
+		if code != fiber.StatusOK || json.Unmarshal([]byte(body), &tasks) != nil {
+			t.Fatalf("list = %d %s, want 200", code, body)
+		}
+		if len(tasks) != tc.want {
+			t.Errorf("%s lists %d tasks, want %d", tc.name, len(tasks), tc.want)
+		}
+	}
+}
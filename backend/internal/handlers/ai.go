@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/shrey258/task_management/internal/ai"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AIHandler struct {
-	gemini *ai.GeminiService
+	gemini      *ai.GeminiService
+	projectRepo repository.ProjectStore
 }
 
-func NewAIHandler(gemini *ai.GeminiService) *AIHandler {
+func NewAIHandler(gemini *ai.GeminiService, projectRepo repository.ProjectStore) *AIHandler {
 	return &AIHandler{
-		gemini: gemini,
+		gemini:      gemini,
+		projectRepo: projectRepo,
 	}
 }
 
@@ -19,9 +26,13 @@ type GenerateTaskSuggestionsRequest struct {
 	Description string `json:"description"`
 }
 
+// AnalyzeTaskRequest describes the task to analyze. CustomFields holds
+// values for the custom fields of the project, by field key or ID.
 type AnalyzeTaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	ProjectID    string                 `json:"project_id,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 func (h *AIHandler) GenerateTaskSuggestions(c *fiber.Ctx) error {
@@ -64,7 +75,14 @@ func (h *AIHandler) AnalyzeTask(c *fiber.Ctx) error {
 		})
 	}
 
-	suggestion, err := h.gemini.AnalyzeTaskPriority(c.Context(), req.Title, req.Description)
+	fields, ferr := h.customFields(c, &req)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	suggestion, err := h.gemini.AnalyzeTaskPriority(c.Context(), req.Title, req.Description, fields)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to analyze task",
@@ -73,3 +91,50 @@ func (h *AIHandler) AnalyzeTask(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(suggestion)
 }
+
+// customFields checks the custom field values of req against the project's
+// fields and labels them with the fields' names for the prompt.
+func (h *AIHandler) customFields(c *fiber.Ctx, req *AnalyzeTaskRequest) ([]ai.TaskField, *fiber.Error) {
+	if len(req.CustomFields) == 0 {
+		return nil, nil
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	projectID, err := primitive.ObjectIDFromHex(req.ProjectID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "custom fields need a valid project_id")
+	}
+	project, err := h.projectRepo.FindByID(c.Context(), projectID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil || !project.CanView(userID) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+
+	for ref := range req.CustomFields {
+		if project.FindCustomField(ref, true) == nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown custom field %q", ref))
+		}
+	}
+
+	// Fields are listed in the order the project defines them.
+	var fields []ai.TaskField
+	for _, field := range project.CustomFields {
+		value, ok := req.CustomFields[field.Key]
+		if !ok {
+			value, ok = req.CustomFields[field.ID.Hex()]
+		}
+		if !ok || value == nil {
+			continue
+		}
+		normalized, err := field.NormalizeValue(value)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		fields = append(fields, ai.TaskField{Name: field.Name, Value: models.FormatCustomValue(normalized)})
+	}
+	return fields, nil
+}
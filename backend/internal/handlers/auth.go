@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,10 +12,10 @@ import (
 )
 
 type AuthHandler struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserStore
 }
 
-func NewAuthHandler(userRepo *repository.UserRepository) *AuthHandler {
+func NewAuthHandler(userRepo repository.UserStore) *AuthHandler {
 	return &AuthHandler{
 		userRepo: userRepo,
 	}
@@ -190,3 +191,56 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(user.ToResponse())
 }
+
+// UpdateCurrentUser changes the caller's name or timezone.
+func (h *AuthHandler) UpdateCurrentUser(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var update models.UserUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "name cannot be empty",
+			})
+		}
+		update.Name = &name
+	}
+	if update.Timezone != nil && *update.Timezone != "" {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unknown timezone",
+			})
+		}
+	}
+
+	if err := h.userRepo.Update(c.Context(), userID, &update); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update user",
+		})
+	}
+
+	user, err := h.userRepo.FindByID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to find user",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(user.ToResponse())
+}
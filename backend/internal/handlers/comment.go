@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxCommentLength = 10000
+
+var (
+	// Mentions inside Markdown code are left alone, so code blocks and
+	// inline code are removed before scanning.
+	codeBlockPattern = regexp.MustCompile("(?s)```.*?(```|$)")
+	codeSpanPattern  = regexp.MustCompile("`[^`\n]*`")
+
+	// mentionPattern matches @"Full Name", @email and @name. The mention
+	// must start a word, so plain email addresses are not read as mentions.
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@(?:"([^"\n]+)"|([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})|([\p{L}\p{N}_][\p{L}\p{N}_.-]*))`)
+)
+
+type CommentHandler struct {
+	commentRepo repository.CommentStore
+	userRepo    repository.UserStore
+	notifier    *Notifier
+	access      *taskAccess
+	hub         *websocket.Hub
+}
+
+func NewCommentHandler(commentRepo repository.CommentStore, taskRepo repository.TaskStore, projectRepo repository.ProjectStore, userRepo repository.UserStore, notifier *Notifier, hub *websocket.Hub) *CommentHandler {
+	return &CommentHandler{
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+		notifier:    notifier,
+		access:      &taskAccess{taskRepo: taskRepo, projectRepo: projectRepo},
+		hub:         hub,
+	}
+}
+
+type CommentRequest struct {
+	// Body is Markdown; it is stored as written and rendered by clients.
+	Body string `json:"body"`
+}
+
+// CommentMention is delivered to a user mentioned in a comment, in place of
+// a notification event for the stored Notification.
+type CommentMention struct {
+	Task         models.TaskSummary   `json:"task"`
+	Comment      *models.Comment      `json:"comment"`
+	Notification *models.Notification `json:"notification"`
+}
+
+// GetComments lists a task's comments, oldest first.
+func (h *CommentHandler) GetComments(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	comments, err := h.commentRepo.FindByTask(c.Context(), task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch comments",
+		})
+	}
+	if comments == nil {
+		comments = []*models.Comment{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(comments)
+}
+
+// CreateComment adds a comment to a task. Anyone who can see the task may
+// comment on it.
+func (h *CommentHandler) CreateComment(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	body, ferr := parseCommentBody(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	mentions, err := h.resolveMentions(c.Context(), task, body)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to resolve mentions",
+		})
+	}
+
+	comment := &models.Comment{
+		TaskID:   task.ID,
+		AuthorID: userID,
+		Body:     body,
+		Mentions: mentions,
+	}
+	if err := h.commentRepo.Create(c.Context(), comment); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create comment",
+		})
+	}
+
+	h.broadcastComment(task, comment, websocket.Message{
+		Type:    "comment_created",
+		Payload: comment,
+	})
+	h.notifyMentions(c.Context(), task, comment, userID, mentions)
+
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
+
+// UpdateComment replaces the body of a comment. Only its author may edit it;
+// the previous body is kept in the comment's edit history.
+func (h *CommentHandler) UpdateComment(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	body, ferr := parseCommentBody(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	task, comment, ferr := h.loadOwnComment(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if body == comment.Body {
+		return c.Status(fiber.StatusOK).JSON(comment)
+	}
+
+	mentions, err := h.resolveMentions(c.Context(), task, body)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to resolve mentions",
+		})
+	}
+
+	update := &models.CommentUpdate{
+		Body:     body,
+		Mentions: mentions,
+		Previous: models.CommentEdit{Body: comment.Body, ReplacedAt: time.Now()},
+	}
+	if err := h.commentRepo.Update(c.Context(), comment.ID, update); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update comment",
+		})
+	}
+
+	updated, err := h.commentRepo.FindByID(c.Context(), comment.ID)
+	if err != nil || updated == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch updated comment",
+		})
+	}
+
+	// Only users mentioned for the first time are notified again.
+	var added []primitive.ObjectID
+	for _, id := range mentions {
+		if !containsObjectID(comment.Mentions, id) {
+			added = append(added, id)
+		}
+	}
+
+	h.broadcastComment(task, updated, websocket.Message{
+		Type:    "comment_updated",
+		Payload: updated,
+	})
+	h.notifyMentions(c.Context(), task, updated, userID, added)
+
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// DeleteComment removes a comment. Only its author may delete it.
+func (h *CommentHandler) DeleteComment(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, comment, ferr := h.loadOwnComment(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.commentRepo.Delete(c.Context(), comment.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete comment",
+		})
+	}
+
+	h.broadcastComment(task, comment, websocket.Message{
+		Type: "comment_deleted",
+		Payload: fiber.Map{
+			"task_id":    task.ID,
+			"comment_id": comment.ID,
+		},
+	})
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// loadOwnComment resolves the :id and :commentId parameters to a comment on
+// a task userID can see, written by userID.
+func (h *CommentHandler) loadOwnComment(c *fiber.Ctx, userID primitive.ObjectID) (*models.Task, *models.Comment, *fiber.Error) {
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return nil, nil, ferr
+	}
+
+	commentID, err := primitive.ObjectIDFromHex(c.Params("commentId"))
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "invalid comment id")
+	}
+	comment, err := h.commentRepo.FindByID(c.Context(), commentID)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch comment")
+	}
+	if comment == nil || comment.TaskID != task.ID {
+		return nil, nil, fiber.NewError(fiber.StatusNotFound, "comment not found")
+	}
+	if comment.AuthorID != userID {
+		return nil, nil, fiber.NewError(fiber.StatusForbidden, "only the author can change a comment")
+	}
+
+	return task, comment, nil
+}
+
+// resolveMentions returns the users mentioned in body who can see the task.
+// Names that match more than one user are ambiguous and ignored.
+func (h *CommentHandler) resolveMentions(ctx context.Context, task *models.Task, body string) ([]primitive.ObjectID, error) {
+	var mentions []primitive.ObjectID
+	for _, mention := range parseMentions(body) {
+		var candidates []*models.User
+		if strings.Contains(mention, "@") {
+			user, err := h.userRepo.FindByEmail(ctx, mention)
+			if err != nil {
+				return nil, err
+			}
+			if user != nil {
+				candidates = append(candidates, user)
+			}
+		} else {
+			users, err := h.userRepo.FindByName(ctx, mention)
+			if err != nil {
+				return nil, err
+			}
+			candidates = users
+		}
+		if len(candidates) != 1 || containsObjectID(mentions, candidates[0].ID) {
+			continue
+		}
+
+		visible, err := h.access.canView(ctx, task, candidates[0].ID)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			mentions = append(mentions, candidates[0].ID)
+		}
+	}
+	return mentions, nil
+}
+
+// broadcastComment delivers a comment event to the task's creator, its
+// assignee and the users mentioned in the comment.
+func (h *CommentHandler) broadcastComment(task *models.Task, comment *models.Comment, message websocket.Message) {
+	recipients := []primitive.ObjectID{task.CreatedBy}
+	if task.AssignedTo != nil && !containsObjectID(recipients, *task.AssignedTo) {
+		recipients = append(recipients, *task.AssignedTo)
+	}
+	for _, id := range comment.Mentions {
+		if !containsObjectID(recipients, id) {
+			recipients = append(recipients, id)
+		}
+	}
+
+	for _, userID := range recipients {
+		h.hub.BroadcastToUser(userID, message)
+	}
+}
+
+// notifyMentions stores a notification for each user mentioned in a
+// comment, delivered live as a single comment_mention event.
+func (h *CommentHandler) notifyMentions(ctx context.Context, task *models.Task, comment *models.Comment, authorID primitive.ObjectID, mentioned []primitive.ObjectID) {
+	for _, userID := range mentioned {
+		if userID == authorID {
+			continue
+		}
+		notification := &models.Notification{
+			UserID:  userID,
+			Type:    models.NotificationMentioned,
+			TaskID:  &task.ID,
+			ActorID: &authorID,
+			Message: fmt.Sprintf("You were mentioned in a comment on %q", task.Title),
+			Key:     fmt.Sprintf("%s:%s:%s", models.NotificationMentioned, comment.ID.Hex(), userID.Hex()),
+		}
+		event := websocket.Message{
+			Type:    "comment_mention",
+			Payload: CommentMention{Task: task.Summary(), Comment: comment, Notification: notification},
+		}
+		if _, err := h.notifier.notifyWith(ctx, notification, event); err != nil {
+			log.Printf("Warning: Failed to notify user %s of a mention: %v", userID.Hex(), err)
+		}
+	}
+}
+
+// parseMentions lists the distinct emails and names mentioned in a Markdown
+// body, in order of appearance.
+func parseMentions(body string) []string {
+	body = codeBlockPattern.ReplaceAllString(body, " ")
+	body = codeSpanPattern.ReplaceAllString(body, " ")
+
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		mention := match[1] + match[2] + strings.TrimRight(match[3], ".-")
+		mention = strings.TrimSpace(mention)
+		key := strings.ToLower(mention)
+		if mention == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		mentions = append(mentions, mention)
+	}
+	return mentions
+}
+
+func parseCommentBody(c *fiber.Ctx) (string, *fiber.Error) {
+	var req CommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "body is required")
+	}
+	if len(body) > maxCommentLength {
+		return "", fiber.NewError(fiber.StatusBadRequest, "body must be at most 10000 characters")
+	}
+	return body, nil
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// currentUserID returns the authenticated caller's ID. middleware.Protected
+// stores it as a hex string while the WebSocket upgrade stores an ObjectID,
+// so both forms are accepted.
+func currentUserID(c *fiber.Ctx) (primitive.ObjectID, bool) {
+	switch v := c.Locals("user_id").(type) {
+	case primitive.ObjectID:
+		return v, !v.IsZero()
+	case string:
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return primitive.NilObjectID, false
+		}
+		return id, true
+	default:
+		return primitive.NilObjectID, false
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taskETag is the entity tag of a task: its version, quoted.
+func taskETag(task *models.Task) string {
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+func setTaskETag(c *fiber.Ctx, task *models.Task) {
+	c.Set(fiber.HeaderETag, taskETag(task))
+}
+
+// ifMatch reports whether the request's If-Match header, if any, names the
+// task's current version. Weak tags are compared by their value.
+func ifMatch(c *fiber.Ctx, task *models.Task) bool {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := taskETag(task)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == current || `"`+tag+`"` == current {
+			return true
+		}
+	}
+	return false
+}
+
+// respondStale answers a write that lost a race or named an outdated
+// version, sending the task as it is now stored so the client can merge and
+// retry. Requests that used If-Match get 412, others 409.
+func (h *TaskHandler) respondStale(c *fiber.Ctx, taskID primitive.ObjectID) error {
+	status := fiber.StatusConflict
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		status = fiber.StatusPreconditionFailed
+	}
+
+	current, err := h.taskRepo.FindByID(c.Context(), taskID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch task",
+		})
+	}
+	if current == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "task not found",
+		})
+	}
+
+	setTaskETag(c, current)
+	return c.Status(status).JSON(fiber.Map{
+		"error":   "task has been changed since it was loaded",
+		"current": current,
+	})
+}
+
+// respondUpdateFailed reports an error from updateTask.
+func (h *TaskHandler) respondUpdateFailed(c *fiber.Ctx, taskID primitive.ObjectID, err error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return h.respondStale(c, taskID)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "failed to update task",
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStaleTaskWrites(t *testing.T) {
+	h := newTestTaskHandler(t)
+	store := &racingStore{TaskStore: h.taskRepo}
+	useTaskStore(h, store)
+	app := testApp()
+	app.Put("/tasks/:id", h.UpdateTask)
+	app.Delete("/tasks/:id", h.DeleteTask)
+
+	owner := primitive.NewObjectID()
+	tests := []struct {
+		name   string
+		method string
+		body   interface{}
+		// ifMatch is the If-Match header to send, with "current" standing
+		// for the task's version when the request is made.
+		ifMatch string
+		race    bool
+		want    int
+	}{
+		{"update naming an old version", "PUT", fiber.Map{"title": "mine"}, `"1"`, false, fiber.StatusPreconditionFailed},
+		{"update with If-Match losing a race", "PUT", fiber.Map{"title": "mine"}, "current", true, fiber.StatusPreconditionFailed},
+		{"update losing a race", "PUT", fiber.Map{"title": "mine"}, "", true, fiber.StatusConflict},
+		{"delete naming an old version", "DELETE", nil, `"1"`, false, fiber.StatusPreconditionFailed},
+		{"delete with If-Match losing a race", "DELETE", nil, "current", true, fiber.StatusPreconditionFailed},
+		{"delete losing a race", "DELETE", nil, "", true, fiber.StatusConflict},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			task := createTask(t, h, &models.Task{Title: "shared", Status: models.StatusTodo, CreatedBy: owner})
+			// Move the task past version 1.
+			title := "renamed"
+			if err := h.taskRepo.Update(context.Background(), task.ID, &models.TaskUpdate{Title: &title}); err != nil {
+				t.Fatal(err)
+			}
+
+			var headers []string
+			switch tc.ifMatch {
+			case "":
+			case "current":
+				headers = []string{fiber.HeaderIfMatch, taskETag(findTask(t, h, task.ID))}
+			default:
+				headers = []string{fiber.HeaderIfMatch, tc.ifMatch}
+			}
+			if tc.race {
+				store.race = task.ID
+			}
+			code, body := send(t, app, owner, tc.method, "/tasks/"+task.ID.Hex(), tc.body, headers...)
+			if code != tc.want {
+				t.Fatalf("%s = %d %s, want %d", tc.method, code, body, tc.want)
+			}
+			if !strings.Contains(body, `"current"`) {
+				t.Errorf("response %s does not include the current task", body)
+			}
+
+			got := findTask(t, h, task.ID)
+			if got == nil {
+				t.Fatal("task was deleted")
+			}
+			if got.Title == "mine" {
+				t.Error("task was updated")
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+type NotificationHandler struct {
+	notificationRepo repository.NotificationStore
+	userRepo         repository.UserStore
+	hub              *websocket.Hub
+}
+
+func NewNotificationHandler(notificationRepo repository.NotificationStore, userRepo repository.UserStore, hub *websocket.Hub) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		hub:              hub,
+	}
+}
+
+// NotificationsRead is sent to a user's other connections when they read
+// notifications, so every open client can update its badge. IDs is empty
+// when all of them were read.
+type NotificationsRead struct {
+	IDs         []primitive.ObjectID `json:"ids,omitempty"`
+	UnreadCount int64                `json:"unread_count"`
+}
+
+// GetNotifications pages through the caller's notifications, newest first,
+// along with how many are unread. ?unread=true leaves out those already
+// read. Pass the returned next_cursor as ?cursor= to fetch the following
+// page.
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	limit := defaultNotificationLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxNotificationLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "limit must be between 1 and 200",
+			})
+		}
+		limit = n
+	}
+	var cursor *primitive.ObjectID
+	if raw := c.Query("cursor"); raw != "" {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid cursor",
+			})
+		}
+		cursor = &id
+	}
+
+	notifications, err := h.notificationRepo.FindByUser(c.Context(), userID, c.QueryBool("unread"), cursor, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch notifications",
+		})
+	}
+	if notifications == nil {
+		notifications = []*models.Notification{}
+	}
+	unread, err := h.notificationRepo.CountUnread(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch notifications",
+		})
+	}
+
+	response := fiber.Map{"notifications": notifications, "unread_count": unread}
+	if len(notifications) == limit {
+		response["next_cursor"] = notifications[len(notifications)-1].ID.Hex()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// MarkNotificationRead marks one of the caller's notifications read.
+func (h *NotificationHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid notification id",
+		})
+	}
+
+	if err := h.notificationRepo.MarkRead(c.Context(), userID, id, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "notification not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update notification",
+		})
+	}
+
+	return h.respondRead(c, userID, []primitive.ObjectID{id})
+}
+
+// MarkAllNotificationsRead marks every notification of the caller read.
+func (h *NotificationHandler) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	if _, err := h.notificationRepo.MarkAllRead(c.Context(), userID, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update notifications",
+		})
+	}
+
+	return h.respondRead(c, userID, nil)
+}
+
+// respondRead tells the caller's clients which notifications were read and
+// responds with the new unread count.
+func (h *NotificationHandler) respondRead(c *fiber.Ctx, userID primitive.ObjectID, ids []primitive.ObjectID) error {
+	unread, err := h.notificationRepo.CountUnread(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch notifications",
+		})
+	}
+
+	read := NotificationsRead{IDs: ids, UnreadCount: unread}
+	h.hub.BroadcastToUser(userID, websocket.Message{
+		Type:    "notifications_read",
+		Payload: read,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(read)
+}
+
+// GetNotificationPreferences returns whether the caller receives each type
+// of notification.
+func (h *NotificationHandler) GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	user, err := h.userRepo.FindByID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to find user",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(notificationPreferences(user))
+}
+
+// UpdateNotificationPreferences turns types of notification on or off for
+// the caller. The body maps types to whether they are wanted; types left
+// out keep their setting.
+func (h *NotificationHandler) UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req map[models.NotificationType]bool
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	for kind := range req {
+		if !models.ValidNotificationType(kind) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unknown notification type " + string(kind),
+			})
+		}
+	}
+
+	user, err := h.userRepo.FindByID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to find user",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	muted := []models.NotificationType{}
+	for _, kind := range models.NotificationTypes {
+		wanted, set := req[kind]
+		if !set {
+			wanted = user.WantsNotification(kind)
+		}
+		if !wanted {
+			muted = append(muted, kind)
+		}
+	}
+	if err := h.userRepo.Update(c.Context(), userID, &models.UserUpdate{MutedNotifications: &muted}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update preferences",
+		})
+	}
+	user.MutedNotifications = muted
+
+	return c.Status(fiber.StatusOK).JSON(notificationPreferences(user))
+}
+
+func notificationPreferences(user *models.User) map[models.NotificationType]bool {
+	preferences := make(map[models.NotificationType]bool, len(models.NotificationTypes))
+	for _, kind := range models.NotificationTypes {
+		preferences[kind] = user.WantsNotification(kind)
+	}
+	return preferences
+}
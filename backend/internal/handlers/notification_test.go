@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMutedNotificationsAreNotDelivered(t *testing.T) {
+	h := newTestTaskHandler(t)
+	notifications := NewNotificationHandler(h.notifier.notificationRepo, h.notifier.userRepo, h.hub)
+	app := testApp()
+	app.Put("/tasks/:id", h.UpdateTask)
+	app.Get("/notifications", notifications.GetNotifications)
+	app.Put("/notifications/preferences", notifications.UpdateNotificationPreferences)
+
+	owner := primitive.NewObjectID()
+	assignee := &models.User{Email: "assignee@example.com", Name: "Assignee"}
+	if err := h.notifier.userRepo.Create(context.Background(), assignee); err != nil {
+		t.Fatal(err)
+	}
+	task := createTask(t, h, &models.Task{Title: "report", Status: models.StatusTodo, CreatedBy: owner})
+
+	if code, body := send(t, app, assignee.ID, "PUT", "/notifications/preferences", fiber.Map{"paged": false}); code != fiber.StatusBadRequest {
+		t.Errorf("muting an unknown type = %d %s, want 400", code, body)
+	}
+	code, body := send(t, app, assignee.ID, "PUT", "/notifications/preferences", fiber.Map{string(models.NotificationAssigned): false})
+	var preferences map[models.NotificationType]bool
+	if code != fiber.StatusOK || json.Unmarshal([]byte(body), &preferences) != nil {
+		t.Fatalf("mute = %d %s, want 200", code, body)
+	}
+	if preferences[models.NotificationAssigned] || !preferences[models.NotificationStatusChanged] {
+		t.Errorf("preferences = %v, want only assignments muted", preferences)
+	}
+
+	// The assignment is muted; the status change that follows is not.
+	if code, body := send(t, app, owner, "PUT", "/tasks/"+task.ID.Hex(), fiber.Map{"assigned_to": assignee.ID.Hex()}); code != fiber.StatusOK {
+		t.Fatalf("assign = %d %s, want 200", code, body)
+	}
+	if code, body := send(t, app, owner, "PUT", "/tasks/"+task.ID.Hex(), fiber.Map{"status": models.StatusInProgress}); code != fiber.StatusOK {
+		t.Fatalf("move = %d %s, want 200", code, body)
+	}
+
+	code, body = send(t, app, assignee.ID, "GET", "/notifications", nil)
+	var inbox struct {
+		Notifications []*models.Notification `json:"notifications"`
+		UnreadCount   int64                  `json:"unread_count"`
+	}
+	if code != fiber.StatusOK || json.Unmarshal([]byte(body), &inbox) != nil {
+		t.Fatalf("notifications = %d %s, want 200", code, body)
+	}
+	if len(inbox.Notifications) != 1 || inbox.Notifications[0].Type != models.NotificationStatusChanged || inbox.UnreadCount != 1 {
+		t.Errorf("notifications = %s, want only the status change", body)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Notifier stores notifications and pushes them to their recipients over
+// the WebSocket hub, so users who were offline can catch up on what they
+// missed. Recipients who muted a notification's type get nothing, and
+// nobody is notified of their own actions.
+type Notifier struct {
+	notificationRepo repository.NotificationStore
+	userRepo         repository.UserStore
+	hub              *websocket.Hub
+}
+
+func NewNotifier(notificationRepo repository.NotificationStore, userRepo repository.UserStore, hub *websocket.Hub) *Notifier {
+	return &Notifier{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		hub:              hub,
+	}
+}
+
+// Notify stores a notification and pushes it to its recipient, and reports
+// whether it was sent. A notification whose Key was already used is not
+// sent again.
+func (n *Notifier) Notify(ctx context.Context, notification *models.Notification) (bool, error) {
+	return n.notifyWith(ctx, notification, websocket.Message{
+		Type:    "notification",
+		Payload: notification,
+	})
+}
+
+// notifyWith is Notify with event pushed in place of the notification
+// event, for events that carry the notification along with more context.
+// The notification is stored before event is sent.
+func (n *Notifier) notifyWith(ctx context.Context, notification *models.Notification, event websocket.Message) (bool, error) {
+	if notification.ActorID != nil && *notification.ActorID == notification.UserID {
+		return false, nil
+	}
+	user, err := n.userRepo.FindByID(ctx, notification.UserID)
+	if err != nil {
+		return false, err
+	}
+	if user != nil && !user.WantsNotification(notification.Type) {
+		return false, nil
+	}
+
+	if err := n.notificationRepo.Create(ctx, notification); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	n.hub.BroadcastToUser(notification.UserID, event)
+	return true, nil
+}
+
+// notifyTaskChange notifies the people involved in a task about a change to
+// it: a new assignee that the task is theirs, and the creator and assignee
+// that its status changed. Like the history, it runs after the change is
+// stored, so failures are logged rather than reported.
+func (n *Notifier) notifyTaskChange(ctx context.Context, actorID primitive.ObjectID, before, after *models.Task) {
+	if after == nil {
+		return
+	}
+	var actor *primitive.ObjectID
+	if !actorID.IsZero() {
+		actor = &actorID
+	}
+	send := func(userID primitive.ObjectID, kind models.NotificationType, message string) {
+		notification := &models.Notification{
+			UserID:  userID,
+			Type:    kind,
+			TaskID:  &after.ID,
+			ActorID: actor,
+			Message: message,
+		}
+		if _, err := n.Notify(ctx, notification); err != nil {
+			log.Printf("Warning: Failed to notify user %s about task %s: %v", userID.Hex(), after.ID.Hex(), err)
+		}
+	}
+
+	if after.AssignedTo != nil && (before == nil || before.AssignedTo == nil || *before.AssignedTo != *after.AssignedTo) {
+		send(*after.AssignedTo, models.NotificationAssigned, fmt.Sprintf("You were assigned %q", after.Title))
+	}
+	if before != nil && before.Status != after.Status {
+		message := fmt.Sprintf("%q moved from %s to %s", after.Title, before.Status, after.Status)
+		for _, userID := range taskParticipants(after) {
+			send(userID, models.NotificationStatusChanged, message)
+		}
+	}
+}
+
+// taskParticipants returns a task's creator and, if someone else, its
+// assignee.
+func taskParticipants(task *models.Task) []primitive.ObjectID {
+	participants := []primitive.ObjectID{task.CreatedBy}
+	if task.AssignedTo != nil && *task.AssignedTo != task.CreatedBy {
+		participants = append(participants, *task.AssignedTo)
+	}
+	return participants
+}
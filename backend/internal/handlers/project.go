@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ProjectHandler struct {
+	projectRepo repository.ProjectStore
+	taskRepo    repository.TaskStore
+	wipRepo     repository.WIPViolationStore
+}
+
+func NewProjectHandler(projectRepo repository.ProjectStore, taskRepo repository.TaskStore, wipRepo repository.WIPViolationStore) *ProjectHandler {
+	return &ProjectHandler{
+		projectRepo: projectRepo,
+		taskRepo:    taskRepo,
+		wipRepo:     wipRepo,
+	}
+}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name"`
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+type ProjectMemberRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (h *ProjectHandler) CreateProject(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req CreateProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Key = strings.ToUpper(strings.TrimSpace(req.Key))
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required",
+		})
+	}
+	if !models.ValidProjectKey(req.Key) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "key must be 2-10 uppercase letters or digits starting with a letter",
+		})
+	}
+
+	project := &models.Project{
+		Name:        req.Name,
+		Key:         req.Key,
+		Description: req.Description,
+		Members:     []models.ProjectMember{{UserID: userID, Role: models.ProjectRoleOwner}},
+		CreatedBy:   userID,
+	}
+
+	if err := h.projectRepo.Create(c.Context(), project); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "project key already in use",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create project",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(project)
+}
+
+// GetProjects lists the projects the caller is a member of. Archived projects
+// are included only with ?archived=true.
+func (h *ProjectHandler) GetProjects(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	projects, err := h.projectRepo.FindByMember(c.Context(), userID, c.QueryBool("archived"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch projects",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(projects)
+}
+
+func (h *ProjectHandler) GetProject(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(project)
+}
+
+func (h *ProjectHandler) UpdateProject(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var update models.ProjectUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name cannot be empty",
+		})
+	}
+
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.projectRepo.Update(c.Context(), project.ID, &update); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update project",
+		})
+	}
+
+	return h.respondWithProject(c, project.ID)
+}
+
+// DeleteProject removes an empty project. Only owners may delete a project,
+// and projects that still hold tasks, including tasks in the trash, must be
+// archived instead.
+func (h *ProjectHandler) DeleteProject(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if role, _ := project.MemberRole(userID); role != models.ProjectRoleOwner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only project owners can delete a project",
+		})
+	}
+
+	tasks, err := h.taskRepo.Find(c.Context(), models.TaskFilter{ProjectID: &project.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch project tasks",
+		})
+	}
+	if len(tasks) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "project still has tasks; archive it instead",
+		})
+	}
+	// Tasks in the trash could otherwise be restored into a missing project.
+	trashed, err := h.taskRepo.Find(c.Context(), models.TaskFilter{ProjectID: &project.ID, Trashed: true})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch project tasks",
+		})
+	}
+	if len(trashed) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "project still has tasks in the trash",
+		})
+	}
+
+	if err := h.projectRepo.Delete(c.Context(), project.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete project",
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// SetProjectMember adds a member or changes their role. Only owners may
+// grant the owner role.
+func (h *ProjectHandler) SetProjectMember(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req ProjectMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	memberID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id",
+		})
+	}
+	role := models.ProjectRole(req.Role)
+	if role == "" {
+		role = models.ProjectRoleMember
+	}
+	if !models.ValidProjectRole(role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "role must be owner, admin, member or viewer",
+		})
+	}
+
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	callerRole, _ := project.MemberRole(userID)
+	currentRole, _ := project.MemberRole(memberID)
+	if (role == models.ProjectRoleOwner || currentRole == models.ProjectRoleOwner) && callerRole != models.ProjectRoleOwner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only project owners can manage owners",
+		})
+	}
+
+	members := []models.ProjectMember{}
+	for _, member := range project.Members {
+		if member.UserID != memberID {
+			members = append(members, member)
+		}
+	}
+	members = append(members, models.ProjectMember{UserID: memberID, Role: role})
+	if !hasOwner(members) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "a project must keep at least one owner",
+		})
+	}
+
+	if err := h.projectRepo.Update(c.Context(), project.ID, &models.ProjectUpdate{Members: &members, ExpectedVersion: &project.Version}); err != nil {
+		return projectUpdateFailed(c, err)
+	}
+
+	return h.respondWithProject(c, project.ID)
+}
+
+func (h *ProjectHandler) RemoveProjectMember(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	memberID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user id",
+		})
+	}
+
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	callerRole, _ := project.MemberRole(userID)
+	if role, _ := project.MemberRole(memberID); role == models.ProjectRoleOwner && callerRole != models.ProjectRoleOwner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only project owners can manage owners",
+		})
+	}
+
+	members := []models.ProjectMember{}
+	for _, member := range project.Members {
+		if member.UserID != memberID {
+			members = append(members, member)
+		}
+	}
+	if !hasOwner(members) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "a project must keep at least one owner",
+		})
+	}
+
+	if err := h.projectRepo.Update(c.Context(), project.ID, &models.ProjectUpdate{Members: &members, ExpectedVersion: &project.Version}); err != nil {
+		return projectUpdateFailed(c, err)
+	}
+
+	return h.respondWithProject(c, project.ID)
+}
+
+// GetProjectTasks lists the tasks in a project. It accepts the same filter
+// query parameters as GET /api/tasks.
+func (h *ProjectHandler) GetProjectTasks(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	filter, ferr := parseTaskFilter(c, userID, h.projectRepo)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	filter.ProjectID = &project.ID
+	if ferr := resolveCustomFields(c.Context(), &filter, nil, userID, h.projectRepo); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	tasks, err := h.taskRepo.Find(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tasks)
+}
+
+// loadProject resolves the :id parameter to a project the caller belongs to.
+// Projects the caller is not a member of are reported as not found.
+func (h *ProjectHandler) loadProject(c *fiber.Ctx, userID primitive.ObjectID) (*models.Project, *fiber.Error) {
+	projectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid project id")
+	}
+
+	project, err := h.projectRepo.FindByID(c.Context(), projectID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil || !project.CanView(userID) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+	return project, nil
+}
+
+// loadManagedProject is loadProject restricted to owners and admins.
+func (h *ProjectHandler) loadManagedProject(c *fiber.Ctx, userID primitive.ObjectID) (*models.Project, *fiber.Error) {
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return nil, ferr
+	}
+	if !project.CanManage(userID) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "only project owners and admins can change a project")
+	}
+	return project, nil
+}
+
+func (h *ProjectHandler) respondWithProject(c *fiber.Ctx, projectID primitive.ObjectID) error {
+	project, err := h.projectRepo.FindByID(c.Context(), projectID)
+	if err != nil || project == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch updated project",
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(project)
+}
+
+// projectUpdateFailed answers a failed project update. Updates that replace
+// members, fields, the workflow or WIP limits are conditional on the version
+// they were read at, and another change in between is a conflict the client
+// can retry.
+func projectUpdateFailed(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "project was modified concurrently, try again",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "failed to update project",
+	})
+}
+
+func hasOwner(members []models.ProjectMember) bool {
+	for _, member := range members {
+		if member.Role == models.ProjectRoleOwner {
+			return true
+		}
+	}
+	return false
+}
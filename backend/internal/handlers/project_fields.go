@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CustomFieldRequest defines a custom field. Key defaults to one derived
+// from Name. A field's type cannot change once it is created; PUT replaces
+// the rest of the definition, and archived=false brings back an archived
+// field.
+type CustomFieldRequest struct {
+	Name     string                 `json:"name"`
+	Key      string                 `json:"key"`
+	Type     models.CustomFieldType `json:"type"`
+	Options  []string               `json:"options"`
+	Required bool                   `json:"required"`
+	Min      *float64               `json:"min"`
+	Max      *float64               `json:"max"`
+	Archived *bool                  `json:"archived"`
+}
+
+// GetCustomFields lists the custom fields of a project. Archived fields are
+// included only with ?archived=true.
+func (h *ProjectHandler) GetCustomFields(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	includeArchived := c.QueryBool("archived")
+	fields := []models.CustomField{}
+	for _, field := range project.CustomFields {
+		if includeArchived || !field.Archived {
+			fields = append(fields, field)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fields)
+}
+
+// CreateCustomField adds a custom field to a project. Only owners and
+// admins may define fields.
+func (h *ProjectHandler) CreateCustomField(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req CustomFieldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	field := models.CustomField{ID: primitive.NewObjectID(), Type: req.Type}
+	applyCustomFieldRequest(&field, &req)
+	if ferr := validateCustomField(project, &field); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	fields := append(append([]models.CustomField{}, project.CustomFields...), field)
+	if err := h.projectRepo.Update(c.Context(), project.ID, &models.ProjectUpdate{CustomFields: &fields, ExpectedVersion: &project.Version}); err != nil {
+		return projectUpdateFailed(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(field)
+}
+
+// UpdateCustomField replaces the definition of a custom field. Tasks keep
+// the values they have, even ones a changed definition would no longer
+// accept.
+func (h *ProjectHandler) UpdateCustomField(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req CustomFieldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	project, field, ferr := h.loadCustomField(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if req.Type != "" && req.Type != field.Type {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "the type of a custom field cannot be changed",
+		})
+	}
+
+	updated := *field
+	applyCustomFieldRequest(&updated, &req)
+	if req.Archived != nil && !*req.Archived {
+		updated.Archived, updated.ArchivedAt = false, nil
+	}
+	if ferr := validateCustomField(project, &updated); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.saveCustomField(c, project, updated); err != nil {
+		return projectUpdateFailed(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// DeleteCustomField archives a custom field. Its values stay on the tasks
+// that have them, and it can still be filtered and sorted by, but it no
+// longer accepts new values.
+func (h *ProjectHandler) DeleteCustomField(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, field, ferr := h.loadCustomField(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if !field.Archived {
+		archived := *field
+		now := time.Now()
+		archived.Archived, archived.ArchivedAt = true, &now
+		if err := h.saveCustomField(c, project, archived); err != nil {
+			return projectUpdateFailed(c, err)
+		}
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// loadCustomField resolves the :fieldId parameter, a field key or ID, in a
+// project the caller manages.
+func (h *ProjectHandler) loadCustomField(c *fiber.Ctx, userID primitive.ObjectID) (*models.Project, *models.CustomField, *fiber.Error) {
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return nil, nil, ferr
+	}
+	field := project.FindCustomField(c.Params("fieldId"), true)
+	if field == nil {
+		return nil, nil, fiber.NewError(fiber.StatusNotFound, "custom field not found")
+	}
+	return project, field, nil
+}
+
+// saveCustomField stores field in place of the project's field with the
+// same ID.
+func (h *ProjectHandler) saveCustomField(c *fiber.Ctx, project *models.Project, field models.CustomField) error {
+	fields := make([]models.CustomField, 0, len(project.CustomFields))
+	for _, existing := range project.CustomFields {
+		if existing.ID == field.ID {
+			existing = field
+		}
+		fields = append(fields, existing)
+	}
+	return h.projectRepo.Update(c.Context(), project.ID, &models.ProjectUpdate{CustomFields: &fields, ExpectedVersion: &project.Version})
+}
+
+func applyCustomFieldRequest(field *models.CustomField, req *CustomFieldRequest) {
+	field.Name = strings.TrimSpace(req.Name)
+	field.Key = strings.TrimSpace(req.Key)
+	if field.Key == "" {
+		field.Key = models.CustomFieldKey(field.Name)
+	}
+	field.Options = req.Options
+	field.Required = req.Required
+	field.Min, field.Max = req.Min, req.Max
+}
+
+// validateCustomField checks a field's definition and that no other field
+// of the project, archived or not, uses its key.
+func validateCustomField(project *models.Project, field *models.CustomField) *fiber.Error {
+	if err := field.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	for _, existing := range project.CustomFields {
+		if existing.ID != field.ID && existing.Key == field.Key {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("the project already has a field with key %q", field.Key))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultViolationWindow = 30 * 24 * time.Hour
+	defaultViolationLimit  = 50
+	maxViolationLimit      = 200
+)
+
+// WIPUsage is a WIP limit with the number of tasks currently counting
+// towards it. Per-assignee limits report each assignee's count in
+// Assignees and the largest of them in Count.
+type WIPUsage struct {
+	models.WIPLimit
+	Count     int            `json:"count"`
+	Assignees map[string]int `json:"assignees,omitempty"`
+	Exceeded  bool           `json:"exceeded"`
+}
+
+// WIPLimitReport sums up a limit's violations over the reporting window.
+type WIPLimitReport struct {
+	LimitID  primitive.ObjectID `json:"limit_id"`
+	Limit    string             `json:"limit"`
+	Total    int                `json:"total"`
+	Rejected int                `json:"rejected"`
+}
+
+// UpdateWIPLimits replaces the WIP limits of a project. Only owners and
+// admins may change them. Limits keep their IDs when sent back with them.
+func (h *ProjectHandler) UpdateWIPLimits(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var limits []models.WIPLimit
+	if err := c.BodyParser(&limits); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	known := make(map[primitive.ObjectID]bool, len(project.WIPLimits))
+	for _, limit := range project.WIPLimits {
+		known[limit.ID] = true
+	}
+	workflow := project.TaskWorkflow()
+	seen := make(map[string]bool, len(limits))
+	for i := range limits {
+		limit := &limits[i]
+		if err := limit.Validate(workflow); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("limit %d: %v", i+1, err),
+			})
+		}
+		group := fmt.Sprintf("%s/%s/%t", limit.Status, limit.Tag, limit.PerAssignee)
+		if seen[group] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("limit %d: duplicates another limit on the same tasks", i+1),
+			})
+		}
+		seen[group] = true
+		if !known[limit.ID] {
+			limit.ID = primitive.NewObjectID()
+		}
+		known[limit.ID] = false
+	}
+
+	if err := h.projectRepo.Update(c.Context(), project.ID, &models.ProjectUpdate{WIPLimits: &limits, ExpectedVersion: &project.Version}); err != nil {
+		return projectUpdateFailed(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(limits)
+}
+
+// GetWIP reports each WIP limit of a project with the number of tasks
+// currently counting towards it.
+func (h *ProjectHandler) GetWIP(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	usage := make([]WIPUsage, 0, len(project.WIPLimits))
+	for i := range project.WIPLimits {
+		limit := &project.WIPLimits[i]
+		filter := models.TaskFilter{ProjectID: &project.ID, Status: []models.TaskStatus{limit.Status}}
+		if limit.Tag != "" {
+			filter.Tags = []string{limit.Tag}
+		}
+		tasks, err := h.taskRepo.Find(c.Context(), filter)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch tasks",
+			})
+		}
+
+		entry := WIPUsage{WIPLimit: *limit}
+		if limit.PerAssignee {
+			entry.Assignees = make(map[string]int)
+			for _, task := range tasks {
+				if task.AssignedTo == nil {
+					continue
+				}
+				assignee := task.AssignedTo.Hex()
+				entry.Assignees[assignee]++
+				if entry.Assignees[assignee] > entry.Count {
+					entry.Count = entry.Assignees[assignee]
+				}
+			}
+		} else {
+			entry.Count = len(tasks)
+		}
+		entry.Exceeded = entry.Count > limit.Max
+		usage = append(usage, entry)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"limits": usage,
+	})
+}
+
+// GetWIPViolations reports how often the WIP limits of a project were
+// exceeded since ?since= (a date or RFC 3339 timestamp, 30 days ago by
+// default), in total and per limit, along with the most recent violations.
+func (h *ProjectHandler) GetWIPViolations(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	since := time.Now().Add(-defaultViolationWindow)
+	if raw := c.Query("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", raw); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "since must be YYYY-MM-DD or an RFC 3339 timestamp",
+				})
+			}
+		}
+		since = t
+	}
+	limit := defaultViolationLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxViolationLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "limit must be between 1 and 200",
+			})
+		}
+		limit = n
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	violations, err := h.wipRepo.FindByProject(c.Context(), project.ID, since, 0)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch WIP violations",
+		})
+	}
+
+	// Limits that have since been removed are still reported, described by
+	// what the violations recorded about them.
+	rejected := 0
+	reports := []*WIPLimitReport{}
+	byLimit := make(map[primitive.ObjectID]*WIPLimitReport)
+	for _, limit := range project.WIPLimits {
+		report := &WIPLimitReport{LimitID: limit.ID, Limit: limit.String()}
+		byLimit[limit.ID] = report
+		reports = append(reports, report)
+	}
+	for _, violation := range violations {
+		report, ok := byLimit[violation.LimitID]
+		if !ok {
+			removed := models.WIPLimit{Status: violation.Status, Tag: violation.Tag, PerAssignee: violation.AssigneeID != nil, Max: violation.Max}
+			report = &WIPLimitReport{LimitID: violation.LimitID, Limit: removed.String() + " (removed)"}
+			byLimit[violation.LimitID] = report
+			reports = append(reports, report)
+		}
+		report.Total++
+		if violation.Rejected {
+			report.Rejected++
+			rejected++
+		}
+	}
+
+	recent := violations
+	if len(recent) > limit {
+		recent = recent[:limit]
+	}
+	if recent == nil {
+		recent = []*models.WIPViolation{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"since":      since,
+		"total":      len(violations),
+		"rejected":   rejected,
+		"limits":     reports,
+		"violations": recent,
+	})
+}
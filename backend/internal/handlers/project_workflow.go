@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetWorkflow returns the workflow of a project: the one it defines, or the
+// default todo, in_progress, completed workflow.
+func (h *ProjectHandler) GetWorkflow(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	project, ferr := h.loadProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(project.TaskWorkflow())
+}
+
+// UpdateWorkflow replaces the workflow of a project. Only owners and admins
+// may change it. A status cannot be removed while tasks, including those in
+// the trash, are still in it, or while it has a WIP limit. Tasks in a status
+// whose category changes are moved to the new category.
+func (h *ProjectHandler) UpdateWorkflow(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var workflow models.Workflow
+	if err := c.BodyParser(&workflow); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	project, ferr := h.loadManagedProject(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	isField := func(key string) bool {
+		return project.FindCustomField(key, false) != nil
+	}
+	if err := workflow.Validate(isField); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var removed, recategorized []models.TaskStatus
+	for _, status := range project.TaskWorkflow().Statuses {
+		next := workflow.Status(status.Key)
+		switch {
+		case next == nil:
+			removed = append(removed, status.Key)
+		case next.Category != status.Category:
+			recategorized = append(recategorized, status.Key)
+		}
+	}
+
+	// WIP limits are part of the project, so the versioned update below
+	// catches limits added since it was loaded. Tasks are not: one moved
+	// into a removed status between the check and the update keeps it, and
+	// like any task in a status its workflow no longer has, may then move
+	// to any status.
+	if len(removed) > 0 {
+		for _, limit := range project.WIPLimits {
+			if workflow.Status(limit.Status) == nil {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": fmt.Sprintf("status %q has a WIP limit; remove the limit first", limit.Status),
+				})
+			}
+		}
+		tasks, err := h.tasksInStatuses(c.Context(), project.ID, removed)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch tasks",
+			})
+		}
+		if len(tasks) > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("status %q is still used by %d task(s); move them to another status first", tasks[0].Status, countStatus(tasks, tasks[0].Status)),
+			})
+		}
+	}
+
+	if err := h.projectRepo.Update(c.Context(), project.ID, &models.ProjectUpdate{Workflow: &workflow, ExpectedVersion: &project.Version}); err != nil {
+		return projectUpdateFailed(c, err)
+	}
+
+	if len(recategorized) > 0 {
+		tasks, err := h.tasksInStatuses(c.Context(), project.ID, recategorized)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch tasks",
+			})
+		}
+		for _, task := range tasks {
+			category := workflow.Status(task.Status).Category
+			if err := h.taskRepo.Update(c.Context(), task.ID, &models.TaskUpdate{StatusCategory: &category}); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "failed to update tasks",
+				})
+			}
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(workflow)
+}
+
+// tasksInStatuses returns the project's tasks, in the trash or not, that are
+// in one of the statuses.
+func (h *ProjectHandler) tasksInStatuses(ctx context.Context, projectID primitive.ObjectID, statuses []models.TaskStatus) ([]*models.Task, error) {
+	var tasks []*models.Task
+	for _, trashed := range []bool{false, true} {
+		found, err := h.taskRepo.Find(ctx, models.TaskFilter{ProjectID: &projectID, Status: statuses, Trashed: trashed})
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, found...)
+	}
+	return tasks, nil
+}
+
+func countStatus(tasks []*models.Task, status models.TaskStatus) int {
+	count := 0
+	for _, task := range tasks {
+		if task.Status == status {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reminderLease names the lease that picks the server sending reminders.
+const reminderLease = "reminders"
+
+// defaultReminderOffsets apply when REMINDER_OFFSETS is unset.
+var defaultReminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+// ReminderScheduler notifies the creator and assignee of a task as its due
+// date approaches, at each of the configured offsets before it, and once
+// more when it becomes overdue. Done tasks and tasks in the trash are left
+// alone.
+//
+// Any number of servers may run a scheduler. Only the one holding the
+// reminders lease does the work, and the lease records the time up to which
+// reminders have been sent, so a server that takes over, or restarts, picks
+// up where the last one stopped. Each reminder is stored as a notification
+// under a key unique to the task, its due date and the offset, so a window
+// that is scanned twice still notifies every user once.
+type ReminderScheduler struct {
+	taskRepo  repository.TaskStore
+	leaseRepo repository.LeaseStore
+	notifier  *Notifier
+
+	// offsets are how long before the due date reminders go out, longest
+	// first.
+	offsets []time.Duration
+	// holder identifies this server to the lease.
+	holder string
+}
+
+// reminder is a notification due for a task. Offset is zero for overdue
+// notifications.
+type reminder struct {
+	task   *models.Task
+	kind   models.NotificationType
+	offset time.Duration
+}
+
+func NewReminderScheduler(taskRepo repository.TaskStore, leaseRepo repository.LeaseStore, notifier *Notifier) *ReminderScheduler {
+	return &ReminderScheduler{
+		taskRepo:  taskRepo,
+		leaseRepo: leaseRepo,
+		notifier:  notifier,
+		offsets:   reminderOffsetsFromEnv(),
+		holder:    leaseHolder(),
+	}
+}
+
+// reminderOffsetsFromEnv reads REMINDER_OFFSETS, a comma-separated list of
+// durations such as "1d,1h,15m".
+func reminderOffsetsFromEnv() []time.Duration {
+	raw := os.Getenv("REMINDER_OFFSETS")
+	if raw == "" {
+		return defaultReminderOffsets
+	}
+	offsets, err := parseReminderOffsets(raw)
+	if err != nil {
+		log.Printf("Warning: Invalid REMINDER_OFFSETS %q (%v), using 1d,1h", raw, err)
+		return defaultReminderOffsets
+	}
+	return offsets
+}
+
+// parseReminderOffsets parses a comma-separated list of Go durations, which
+// may also be given in whole days ("2d"), and returns them longest first.
+func parseReminderOffsets(raw string) ([]time.Duration, error) {
+	seen := make(map[time.Duration]bool)
+	var offsets []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		var offset time.Duration
+		if days, ok := strings.CutSuffix(part, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset %q", part)
+			}
+			offset = time.Duration(n) * 24 * time.Hour
+		} else {
+			var err error
+			if offset, err = time.ParseDuration(part); err != nil {
+				return nil, fmt.Errorf("invalid offset %q", part)
+			}
+		}
+		if offset <= 0 {
+			return nil, fmt.Errorf("offset %q must be positive", part)
+		}
+		if !seen[offset] {
+			seen[offset] = true
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	return offsets, nil
+}
+
+// leaseHolder names this server: its host name, process ID and a random
+// suffix, so restarts do not inherit a lease they did not renew.
+func leaseHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Run sends the reminders that have come due, checking every interval,
+// until ctx is cancelled. It then releases the lease, so that another server
+// can take over without waiting for it to expire.
+func (s *ReminderScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Losing the lease mid-run is expected while servers come and go.
+		if n, err := s.sendDue(ctx, 2*interval); err != nil && !errors.Is(err, repository.ErrLeaseLost) && ctx.Err() == nil {
+			log.Printf("Warning: Failed to send reminders: %v", err)
+		} else if n > 0 {
+			log.Printf("Sent %d reminder(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// release gives up the lease on shutdown.
+func (s *ReminderScheduler) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.leaseRepo.Release(ctx, reminderLease, s.holder); err != nil {
+		log.Printf("Warning: Failed to release the reminder lease: %v", err)
+	}
+}
+
+// sendDue sends the reminders that came due since the last run, if this
+// server holds or can take the lease, which it keeps for ttl. It returns how
+// many notifications were sent. The lease's cursor only moves on once every
+// reminder up to it has been sent, so a failed run is retried in full.
+func (s *ReminderScheduler) sendDue(ctx context.Context, ttl time.Duration) (int, error) {
+	lease, err := s.leaseRepo.Acquire(ctx, reminderLease, s.holder, ttl)
+	if err != nil || lease == nil {
+		return 0, err
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	if lease.Cursor == nil {
+		// The first run starts the clock rather than reminding about
+		// everything that was ever due.
+		return 0, s.leaseRepo.Advance(ctx, reminderLease, s.holder, now)
+	}
+	since := *lease.Cursor
+	if !since.Before(now) {
+		return 0, nil
+	}
+
+	reminders, err := s.findReminders(ctx, since, now)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, r := range reminders {
+		n, err := s.notify(ctx, r)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, s.leaseRepo.Advance(ctx, reminderLease, s.holder, now)
+}
+
+// findReminders returns the reminders that came due in (since, until],
+// ordered by due date. A task that is overdue gets only the overdue
+// notification, and a task with several reminders due at once, after
+// downtime, gets only the one for the shortest offset.
+func (s *ReminderScheduler) findReminders(ctx context.Context, since, until time.Time) ([]*reminder, error) {
+	// DueBefore is exclusive; due dates are stored to the millisecond.
+	find := func(from, to time.Time) ([]*models.Task, error) {
+		before := to.Add(time.Millisecond)
+		return s.taskRepo.Find(ctx, models.TaskFilter{
+			DueAfter:        &from,
+			DueBefore:       &before,
+			ExcludeCategory: []models.StatusCategory{models.CategoryDone},
+		})
+	}
+
+	byTask := make(map[primitive.ObjectID]*reminder)
+	overdue, err := find(since, until)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range overdue {
+		byTask[task.ID] = &reminder{task: task, kind: models.NotificationOverdue}
+	}
+	for _, offset := range s.offsets {
+		tasks, err := find(since.Add(offset), until.Add(offset))
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			if !task.DueDate.After(until) {
+				continue
+			}
+			// Offsets run longest first, so a later match is shorter.
+			byTask[task.ID] = &reminder{task: task, kind: models.NotificationDueSoon, offset: offset}
+		}
+	}
+
+	reminders := make([]*reminder, 0, len(byTask))
+	for _, r := range byTask {
+		if !r.task.Done() {
+			reminders = append(reminders, r)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].task.DueDate.Equal(reminders[j].task.DueDate) {
+			return reminders[i].task.DueDate.Before(reminders[j].task.DueDate)
+		}
+		return reminders[i].task.ID.Hex() < reminders[j].task.ID.Hex()
+	})
+	return reminders, nil
+}
+
+// notify sends a reminder to the task's creator and assignee, skipping
+// those who already have it, and returns how many were notified.
+func (s *ReminderScheduler) notify(ctx context.Context, r *reminder) (int, error) {
+	message := fmt.Sprintf("%q is overdue", r.task.Title)
+	if r.kind == models.NotificationDueSoon {
+		message = fmt.Sprintf("%q is due in %s", r.task.Title, formatOffset(r.offset))
+	}
+	dueDate := r.task.DueDate
+
+	sent := 0
+	for _, userID := range taskParticipants(r.task) {
+		notification := &models.Notification{
+			UserID:  userID,
+			Type:    r.kind,
+			TaskID:  &r.task.ID,
+			Message: message,
+			DueDate: &dueDate,
+			Key:     fmt.Sprintf("%s:%s:%d:%d:%s", r.kind, r.task.ID.Hex(), dueDate.UnixMilli(), int64(r.offset/time.Second), userID.Hex()),
+		}
+		ok, err := s.notifier.Notify(ctx, notification)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// formatOffset spells out an offset in the largest unit that divides it,
+// such as "1 day" or "90 minutes".
+func formatOffset(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+	for _, unit := range units {
+		if d%unit.size == 0 {
+			n := int64(d / unit.size)
+			if n == 1 {
+				return "1 " + unit.name
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+	return d.String()
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+)
+
+func TestReminderSchedulerReleasesLeaseOnShutdown(t *testing.T) {
+	hub := websocket.NewHub()
+	leases := repository.NewMemoryLeaseRepository()
+	notifier := NewNotifier(repository.NewMemoryNotificationRepository(), repository.NewMemoryUserRepository(), hub)
+	scheduler := NewReminderScheduler(repository.NewMemoryTaskRepository(), leases, notifier)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	// The first run takes the lease and starts its cursor.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lease, err := leases.Acquire(context.Background(), reminderLease, "other", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lease == nil {
+			break
+		}
+		// Taken before the scheduler got to it; hand it back.
+		if err := leases.Release(context.Background(), reminderLease, "other"); err != nil {
+			t.Fatal(err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the scheduler never took the lease")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+
+	lease, err := leases.Acquire(context.Background(), reminderLease, "other", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease == nil {
+		t.Fatal("the lease was not released on shutdown")
+	}
+	if lease.Cursor == nil {
+		t.Error("the released lease lost its cursor")
+	}
+}
@@ -1,24 +1,63 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/shrey258/task_management/internal/models"
 	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/storage"
 	"github.com/shrey258/task_management/internal/websocket"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type TaskHandler struct {
-	taskRepo *repository.TaskRepository
-	hub      *websocket.Hub
+	taskRepo       repository.TaskStore
+	projectRepo    repository.ProjectStore
+	commentRepo    repository.CommentStore
+	activityRepo   repository.ActivityStore
+	wipRepo        repository.WIPViolationStore
+	workLogRepo    repository.WorkLogStore
+	attachmentRepo repository.AttachmentStore
+	blobs          storage.BlobStore
+	notifier       *Notifier
+	access         *taskAccess
+	hub            *websocket.Hub
+
+	// blockStart also refuses moving a task to in_progress while it has
+	// unfinished blockers, not only completing it.
+	blockStart bool
+	// trashRetention is how long deleted tasks stay in the trash before
+	// they are purged.
+	trashRetention time.Duration
+	// maxAttachment is the size limit of uploads, in bytes.
+	maxAttachment int64
+	// urlSecret signs attachment download URLs.
+	urlSecret []byte
 }
 
-func NewTaskHandler(taskRepo *repository.TaskRepository, hub *websocket.Hub) *TaskHandler {
+func NewTaskHandler(taskRepo repository.TaskStore, projectRepo repository.ProjectStore, commentRepo repository.CommentStore, activityRepo repository.ActivityStore, wipRepo repository.WIPViolationStore, workLogRepo repository.WorkLogStore, attachmentRepo repository.AttachmentStore, blobs storage.BlobStore, notifier *Notifier, hub *websocket.Hub) *TaskHandler {
 	return &TaskHandler{
-		taskRepo: taskRepo,
-		hub:      hub,
+		taskRepo:       taskRepo,
+		projectRepo:    projectRepo,
+		commentRepo:    commentRepo,
+		activityRepo:   activityRepo,
+		wipRepo:        wipRepo,
+		workLogRepo:    workLogRepo,
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		notifier:       notifier,
+		access:         &taskAccess{taskRepo: taskRepo, projectRepo: projectRepo},
+		hub:            hub,
+		blockStart:     os.Getenv("DEPENDENCIES_BLOCK_START") == "true",
+		trashRetention: trashRetentionFromEnv(),
+		maxAttachment:  attachmentMaxBytesFromEnv(),
+		urlSecret:      attachmentSecretFromEnv(),
 	}
 }
 
@@ -29,6 +68,14 @@ type CreateTaskRequest struct {
 	DueDate     time.Time `json:"due_date"`
 	AssignedTo  string    `json:"assigned_to,omitempty"`
 	Tags        []string  `json:"tags"`
+	ProjectID   string    `json:"project_id,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty"`
+	Recurrence  string    `json:"recurrence,omitempty"`
+	// Estimate is the expected effort in seconds.
+	Estimate int64 `json:"estimate,omitempty"`
+	// CustomFields holds values for the project's custom fields, by field
+	// key or ID.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
@@ -39,13 +86,19 @@ func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 		})
 	}
 
-	userID, ok := c.Locals("user_id").(primitive.ObjectID)
+	userID, ok := currentUserID(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "unauthorized",
 		})
 	}
 
+	if req.Estimate < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "estimate cannot be negative",
+		})
+	}
+
 	var assignedToID *primitive.ObjectID
 	if req.AssignedTo != "" {
 		id, err := primitive.ObjectIDFromHex(req.AssignedTo)
@@ -61,33 +114,108 @@ func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 		Title:       req.Title,
 		Description: req.Description,
 		Priority:    models.TaskPriority(req.Priority),
-		Status:      models.StatusTodo,
 		DueDate:     req.DueDate,
 		CreatedBy:   userID,
 		AssignedTo:  assignedToID,
 		Tags:        req.Tags,
+		Estimate:    req.Estimate,
+	}
+
+	if req.Recurrence != "" {
+		rec, ferr := newRecurrence(req.Recurrence, req.DueDate)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		task.Recurrence = rec
+	}
+
+	// Subtasks land in their parent's project unless told otherwise
+	if req.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid parent_id",
+			})
+		}
+		parent, ferr := h.validateParent(c.Context(), nil, parentID, userID)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		task.ParentID = &parent.ID
+		if req.ProjectID == "" && parent.ProjectID != nil {
+			req.ProjectID = parent.ProjectID.Hex()
+		}
+	}
+
+	if req.ProjectID != "" {
+		projectID, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid project_id",
+			})
+		}
+		values, ferr := h.customFieldValues(c.Context(), &projectID, req.CustomFields, true)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		task.CustomFields = values
+		key, ferr := h.allocateTaskKey(c, projectID, userID)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		task.ProjectID = &projectID
+		task.Key = key
+	} else if len(req.CustomFields) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "custom fields can only be set on tasks in a project",
+		})
+	}
+
+	if ferr := h.startWorkflow(c.Context(), task); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	// The ID is chosen up front so WIP violations can refer to the task.
+	task.ID = primitive.NewObjectID()
+	warnings, ferr := h.checkWIP(c.Context(), userID, nil, task, nil)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
 	}
 
-	if err := h.taskRepo.Create(c.Context(), task); err != nil {
+	if err := h.createTask(c, task); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to create task",
 		})
 	}
 
 	// Notify relevant users about the new task
-	h.hub.BroadcastToAll(websocket.Message{
+	h.broadcastTask(c, task, websocket.Message{
 		Type:    "task_created",
 		Payload: task,
 	})
 
+	setTaskETag(c, task)
+	setWIPWarnings(c, warnings)
 	return c.Status(fiber.StatusCreated).JSON(task)
 }
 
 func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
-	taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid task id",
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
 		})
 	}
 
@@ -98,100 +226,476 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.taskRepo.Update(c.Context(), taskID, &update); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to update task",
+	if update.Estimate != nil && *update.Estimate < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "estimate cannot be negative",
+		})
+	}
+
+	existing, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
 		})
 	}
+	if !ifMatch(c, existing) {
+		return h.respondStale(c, existing.ID)
+	}
+
+	if update.ParentID != nil && !update.ParentID.IsZero() {
+		if _, ferr := h.validateParent(c.Context(), existing, *update.ParentID, userID); ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+	}
+
+	// An empty project_id takes the task out of its project, which only
+	// needs the right to edit the project's tasks.
+	detach := update.ProjectID != nil && update.ProjectID.IsZero()
+	if detach && existing.ProjectID == nil {
+		update.ProjectID, detach = nil, false
+	}
+	if detach {
+		project, err := h.projectRepo.FindByID(c.Context(), *existing.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch project",
+			})
+		}
+		if project != nil && !project.CanEditTasks(userID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "you cannot remove tasks from this project",
+			})
+		}
+	}
+	moved := update.ProjectID != nil && !detach && (existing.ProjectID == nil || *existing.ProjectID != *update.ProjectID)
+
+	// Values are checked against the fields of the project the task ends up
+	// in.
+	if update.CustomFields != nil {
+		projectID := existing.ProjectID
+		if update.ProjectID != nil {
+			projectID = update.ProjectID
+		}
+		if detach {
+			projectID = nil
+		}
+		values, ferr := h.customFieldValues(c.Context(), projectID, update.CustomFields, false)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		update.CustomFields = values
+	}
 
-	// Fetch updated task
-	task, err := h.taskRepo.FindByID(c.Context(), taskID)
+	// The workflow decides which statuses the task may move to. Blocked
+	// tasks cannot be finished (or, if configured, started) until every
+	// task blocking them is done.
+	if ferr := h.applyWorkflow(c.Context(), existing, &update); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	blockers, err := h.statusBlockers(c.Context(), existing, &update)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to fetch updated task",
+			"error": "failed to check dependencies",
+		})
+	}
+	if len(blockers) > 0 {
+		return h.respondBlocked(c, userID, blockers)
+	}
+
+	// Moving a task to another project gives it a key in that project, and
+	// taking it out leaves it without one; the old key is kept so existing
+	// references still resolve.
+	if moved || detach {
+		var key string
+		if moved {
+			if key, ferr = h.allocateTaskKey(c, *update.ProjectID, userID); ferr != nil {
+				return c.Status(ferr.Code).JSON(fiber.Map{
+					"error": ferr.Message,
+				})
+			}
+		}
+		previousKeys := append([]string{}, existing.PreviousKeys...)
+		if existing.Key != "" {
+			previousKeys = append(previousKeys, existing.Key)
+		}
+		update.Key = &key
+		update.PreviousKeys = &previousKeys
+	}
+
+	warnings, ferr := h.checkWIP(c.Context(), userID, existing, previewTask(existing, &update), nil)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
 		})
 	}
 
-	// Notify about task update
-	h.hub.BroadcastToAll(websocket.Message{
+	task, err := h.updateTask(c, existing, &update)
+	if err != nil {
+		return h.respondUpdateFailed(c, existing.ID, err)
+	}
+
+	// Completing an occurrence of a recurring task schedules the next one
+	if !existing.Done() && task.Done() {
+		if _, ferr := h.scheduleNextOccurrence(c, task); ferr != nil {
+			log.Printf("Warning: Failed to schedule next occurrence of task %s: %s", task.ID.Hex(), ferr.Message)
+		}
+	}
+
+	// Notify about task update; the payload carries the new version
+	h.broadcastTask(c, task, websocket.Message{
 		Type:    "task_updated",
 		Payload: task,
 	})
 
+	setTaskETag(c, task)
+	setWIPWarnings(c, warnings)
 	return c.Status(fiber.StatusOK).JSON(task)
 }
 
+// GetTasks lists the tasks the caller can see. Without paging parameters it
+// returns every match as an array, as it always has; with ?limit=, ?cursor=
+// or ?count= it returns one page as {"tasks", "next_cursor", "total"}. See
+// parseTaskListing for sorting and field selection.
 func (h *TaskHandler) GetTasks(c *fiber.Ctx) error {
-	var filter models.TaskFilter
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
 
-	// Parse query parameters
-	if status := c.Query("status"); status != "" {
-		taskStatus := models.TaskStatus(status)
-		filter.Status = &taskStatus
+	listing, ferr := parseTaskListing(c, defaultTaskSort)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
 	}
-	if priority := c.Query("priority"); priority != "" {
-		taskPriority := models.TaskPriority(priority)
-		filter.Priority = &taskPriority
+
+	filter, ferr := parseTaskFilter(c, userID, h.projectRepo)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
 	}
-	if assignedTo := c.Query("assigned_to"); assignedTo != "" {
-		id, err := primitive.ObjectIDFromHex(assignedTo)
-		if err == nil {
-			filter.AssignedTo = &id
-		}
+	if ferr := resolveCustomFields(c.Context(), &filter, listing, userID, h.projectRepo); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
 	}
 
-	tasks, err := h.taskRepo.Find(c.Context(), filter)
+	result, err := h.taskRepo.FindPage(c.Context(), filter, listing.page)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to fetch tasks",
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(tasks)
+	var items interface{} = result.Tasks
+	if result.Tasks == nil {
+		items = []*models.Task{}
+	}
+	if len(listing.fields) > 0 {
+		items, err = projectTasks(result.Tasks, listing.fields)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch tasks",
+			})
+		}
+	}
+
+	if !listing.paged {
+		return c.Status(fiber.StatusOK).JSON(items)
+	}
+
+	response := fiber.Map{"tasks": items, "next_cursor": nil}
+	if result.Next != nil {
+		next, err := encodeTaskCursor(result.Next, listing.page)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch tasks",
+			})
+		}
+		response["next_cursor"] = next
+	}
+	if result.Total != nil {
+		response["total"] = *result.Total
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
 }
 
+// GetTask returns a single task. The :id parameter accepts either the task's
+// ObjectID or its project key, including keys from before a project move.
+// Tasks with subtasks include a rollup of their progress, and tasks with an
+// estimate or logged time the time spent.
 func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
-	taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid task id",
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
 		})
 	}
 
-	task, err := h.taskRepo.FindByID(c.Context(), taskID)
-	if err != nil {
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.attachRollup(c.Context(), task, userID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to fetch task",
+			"error": "failed to fetch subtasks",
 		})
 	}
-	if task == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "task not found",
+	if err := h.attachTime(c.Context(), task); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch work logs",
 		})
 	}
 
+	setTaskETag(c, task)
 	return c.Status(fiber.StatusOK).JSON(task)
 }
 
 func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
-	taskID, err := primitive.ObjectIDFromHex(c.Params("id"))
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid task id",
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
 		})
 	}
+	if !ifMatch(c, task) {
+		return h.respondStale(c, task.ID)
+	}
 
-	if err := h.taskRepo.Delete(c.Context(), taskID); err != nil {
+	// Subtasks are never orphaned silently: the caller chooses to cascade
+	// the delete or to move them up a level.
+	if ferr := h.deleteSubtasks(c, task, userID, c.Query("children")); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.removeTask(c, task, nil); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return h.respondStale(c, task.ID)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to delete task",
 		})
 	}
 
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// removeTask moves a task to the trash and notifies everyone who could see
+// it. Its comments and dependencies are kept until it is purged.
+func (h *TaskHandler) removeTask(c *fiber.Ctx, task *models.Task, with *primitive.ObjectID) error {
+	if err := h.trashTask(c, task, with); err != nil {
+		return err
+	}
+
 	// Notify about task deletion
-	h.hub.BroadcastToAll(websocket.Message{
+	h.broadcastTask(c, task, websocket.Message{
 		Type:    "task_deleted",
-		Payload: taskID,
+		Payload: fiber.Map{"id": task.ID, "reason": "trashed"},
 	})
+	return nil
+}
 
-	return c.Status(fiber.StatusNoContent).Send(nil)
+type ShareTaskRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// ShareTask grants a user viewer or editor access to a task. Only the task's
+// creator may change who it is shared with.
+func (h *TaskHandler) ShareTask(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req ShareTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	shareWith, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user_id",
+		})
+	}
+	role := models.ShareRole(req.Role)
+	if role == "" {
+		role = models.ShareRoleViewer
+	}
+	if role != models.ShareRoleViewer && role != models.ShareRoleEditor {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "role must be viewer or editor",
+		})
+	}
+
+	task, ferr := h.loadOwnedTask(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	shares := []models.TaskShare{{UserID: shareWith, Role: role}}
+	for _, share := range task.SharedWith {
+		if share.UserID != shareWith {
+			shares = append(shares, share)
+		}
+	}
+
+	return h.updateShares(c, task, shares)
+}
+
+// UnshareTask revokes a user's shared access to a task.
+func (h *TaskHandler) UnshareTask(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	revoke, err := primitive.ObjectIDFromHex(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user id",
+		})
+	}
+
+	task, ferr := h.loadOwnedTask(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	shares := []models.TaskShare{}
+	for _, share := range task.SharedWith {
+		if share.UserID != revoke {
+			shares = append(shares, share)
+		}
+	}
+
+	return h.updateShares(c, task, shares)
+}
+
+func (h *TaskHandler) updateShares(c *fiber.Ctx, task *models.Task, shares []models.TaskShare) error {
+	// Users losing access still need to hear about it, so the audience is
+	// taken before the update as well as after.
+	previousAudience := h.access.audience(c.Context(), task)
+
+	updated, err := h.updateTask(c, task, &models.TaskUpdate{SharedWith: &shares})
+	if err != nil {
+		return h.respondUpdateFailed(c, task.ID, err)
+	}
+
+	h.broadcastTask(c, updated, websocket.Message{
+		Type:    "task_updated",
+		Payload: updated,
+	})
+	for _, userID := range previousAudience {
+		if visible, err := h.access.canView(c.Context(), updated, userID); err == nil && !visible {
+			h.hub.BroadcastToUser(userID, websocket.Message{
+				Type:    "task_deleted",
+				Payload: fiber.Map{"id": updated.ID, "reason": "access_revoked"},
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// loadOwnedTask resolves the :id parameter to a task created by userID.
+func (h *TaskHandler) loadOwnedTask(c *fiber.Ctx, userID primitive.ObjectID) (*models.Task, *fiber.Error) {
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return nil, ferr
+	}
+	if task.CreatedBy != userID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "task not found")
+	}
+	return task, nil
+}
+
+// allocateTaskKey checks that userID may add tasks to the project and
+// reserves the next key in it, e.g. "OPS-142".
+func (h *TaskHandler) allocateTaskKey(c *fiber.Ctx, projectID, userID primitive.ObjectID) (string, *fiber.Error) {
+	project, ferr := h.taskKeyProject(c.Context(), projectID, userID)
+	if ferr != nil {
+		return "", ferr
+	}
+	return h.nextTaskKey(c.Context(), project)
+}
+
+// taskKeyProject loads the project userID wants to add a task to, checking
+// that they may.
+func (h *TaskHandler) taskKeyProject(ctx context.Context, projectID, userID primitive.ObjectID) (*models.Project, *fiber.Error) {
+	project, err := h.projectRepo.FindByID(ctx, projectID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil || !project.CanView(userID) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+	if !project.CanEditTasks(userID) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "you cannot add tasks to this project")
+	}
+	return project, nil
+}
+
+// nextTaskKey allocates the next task key in a project that is not
+// archived, without regard to who is asking.
+func (h *TaskHandler) nextTaskKey(ctx context.Context, project *models.Project) (string, *fiber.Error) {
+	if project.Archived {
+		return "", fiber.NewError(fiber.StatusConflict, "project is archived")
+	}
+
+	number, err := h.projectRepo.NextTaskNumber(ctx, project.ID)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusInternalServerError, "failed to allocate task key")
+	}
+	return fmt.Sprintf("%s-%d", project.Key, number), nil
+}
+
+// broadcastTask delivers a message about a task to everyone who can see it.
+func (h *TaskHandler) broadcastTask(c *fiber.Ctx, task *models.Task, message websocket.Message) {
+	h.broadcastTaskCtx(c.Context(), task, message)
+}
+
+// broadcastTaskCtx is broadcastTask outside of a request.
+func (h *TaskHandler) broadcastTaskCtx(ctx context.Context, task *models.Task, message websocket.Message) {
+	for _, userID := range h.access.audience(ctx, task) {
+		h.hub.BroadcastToUser(userID, message)
+	}
 }
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/storage"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultAttachmentMaxBytes applies when ATTACHMENT_MAX_BYTES is unset.
+	defaultAttachmentMaxBytes = 10 << 20
+	// attachmentFormOverhead is how much of an upload request, besides the
+	// file itself, is read: multipart framing and other form fields.
+	attachmentFormOverhead = 1 << 20
+	// attachmentURLTTL is how long a download URL stays valid.
+	attachmentURLTTL = 15 * time.Minute
+	// blobCollectBatch bounds the attachments collectBlobs removes per
+	// query.
+	blobCollectBatch = 100
+	// maxFilenameLength bounds stored filenames, in bytes.
+	maxFilenameLength = 255
+)
+
+// attachmentTypes are the content types attachments may have, as detected
+// by http.DetectContentType.
+var attachmentTypes = map[string]bool{
+	"image/png":          true,
+	"image/jpeg":         true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"application/pdf":    true,
+	"text/plain":         true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+}
+
+// AttachmentURL is a signed link to download an attachment without a
+// bearer token.
+type AttachmentURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// attachmentMaxBytesFromEnv reads ATTACHMENT_MAX_BYTES, the largest upload
+// accepted.
+func attachmentMaxBytesFromEnv() int64 {
+	if raw := os.Getenv("ATTACHMENT_MAX_BYTES"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Warning: Invalid ATTACHMENT_MAX_BYTES %q, using %d", raw, defaultAttachmentMaxBytes)
+	}
+	return defaultAttachmentMaxBytes
+}
+
+// attachmentSecretFromEnv returns the key download URLs are signed with:
+// ATTACHMENT_URL_SECRET, or JWT_SECRET when that is unset. Without either,
+// URLs are signed with a random key and stop working on restart.
+func attachmentSecretFromEnv() []byte {
+	for _, name := range []string{"ATTACHMENT_URL_SECRET", "JWT_SECRET"} {
+		if secret := os.Getenv(name); secret != "" {
+			return []byte(secret)
+		}
+	}
+	log.Printf("Warning: ATTACHMENT_URL_SECRET not set, attachment URLs will not survive a restart")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate attachment URL secret: %v", err)
+	}
+	return secret
+}
+
+// UploadAttachment stores the file in the multipart field "file" as an
+// attachment of the task. The content type is detected from the content and
+// must be one of attachmentTypes.
+func (h *TaskHandler) UploadAttachment(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	upload, err := h.receiveUpload(c)
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("file cannot be larger than %d bytes", h.maxAttachment),
+		})
+	case errors.Is(err, errNoUpload):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file is required",
+		})
+	case err != nil:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to read file",
+		})
+	}
+	defer upload.Close()
+	if upload.size == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file is empty",
+		})
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(upload.file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to read file",
+		})
+	}
+	sniff = sniff[:n]
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(sniff))
+	if !attachmentTypes[contentType] {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": fmt.Sprintf("files of type %s are not allowed", contentType),
+		})
+	}
+
+	attachment := &models.Attachment{
+		ID:          primitive.NewObjectID(),
+		TaskID:      task.ID,
+		UploadedBy:  userID,
+		Filename:    attachmentFilename(upload.filename),
+		ContentType: contentType,
+		Size:        upload.size,
+	}
+	attachment.BlobKey = "tasks/" + task.ID.Hex() + "/" + attachment.ID.Hex()
+
+	digest := sha256.New()
+	content := io.TeeReader(io.MultiReader(bytes.NewReader(sniff), upload.file), digest)
+	if err := h.blobs.Put(c.Context(), attachment.BlobKey, content, attachment.Size, contentType); err != nil {
+		log.Printf("Warning: Failed to store attachment blob %s: %v", attachment.BlobKey, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to store attachment",
+		})
+	}
+	attachment.SHA256 = hex.EncodeToString(digest.Sum(nil))
+
+	if err := h.attachmentRepo.Create(c.Context(), attachment); err != nil {
+		if err := h.blobs.Delete(c.Context(), attachment.BlobKey); err != nil {
+			log.Printf("Warning: Failed to remove orphaned attachment blob %s: %v", attachment.BlobKey, err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to store attachment",
+		})
+	}
+
+	h.broadcastTask(c, task, websocket.Message{
+		Type:    "attachment_added",
+		Payload: attachment,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(attachment)
+}
+
+var (
+	errNoUpload       = errors.New("no file in the request")
+	errUploadTooLarge = errors.New("file too large")
+)
+
+// upload is a file received by receiveUpload, spooled to a temporary file.
+type upload struct {
+	file     *os.File
+	filename string
+	size     int64
+}
+
+// Close removes the temporary file.
+func (u *upload) Close() {
+	u.file.Close()
+	os.Remove(u.file.Name())
+}
+
+// receiveUpload reads the multipart field "file" into a temporary file,
+// rewound for reading. The server streams request bodies and the global
+// body limit skips this route, so this is where uploads are capped: no
+// more than maxAttachment bytes of file, plus attachmentFormOverhead of
+// framing and other fields, are read from the client.
+func (h *TaskHandler) receiveUpload(c *fiber.Ctx) (*upload, error) {
+	limit := h.maxAttachment + attachmentFormOverhead
+	if int64(c.Request().Header.ContentLength()) > limit {
+		c.Context().SetConnectionClose()
+		return nil, errUploadTooLarge
+	}
+	boundary := string(c.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return nil, errNoUpload
+	}
+	var stream io.Reader = c.Context().RequestBodyStream()
+	if stream == nil {
+		stream = bytes.NewReader(c.Body())
+	}
+	body := &io.LimitedReader{R: stream, N: limit}
+
+	u, err := receiveFilePart(multipart.NewReader(body, boundary), h.maxAttachment)
+	if err != nil {
+		// The rest of the body is left unread.
+		c.Context().SetConnectionClose()
+		return nil, err
+	}
+	// Drain the closing boundary so the connection can be reused.
+	if _, err := io.Copy(io.Discard, body); err != nil || body.N == 0 {
+		c.Context().SetConnectionClose()
+	}
+	return u, nil
+}
+
+// receiveFilePart spools the first part of form named "file" that carries
+// a filename, reading at most limit bytes of it.
+func receiveFilePart(form *multipart.Reader, limit int64) (*upload, error) {
+	for {
+		part, err := form.NextPart()
+		if err == io.EOF {
+			return nil, errNoUpload
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			continue
+		}
+
+		file, err := os.CreateTemp("", "attachment-*")
+		if err != nil {
+			return nil, err
+		}
+		u := &upload{file: file, filename: part.FileName()}
+		u.size, err = io.Copy(file, io.LimitReader(part, limit+1))
+		if err == nil && u.size > limit {
+			err = errUploadTooLarge
+		}
+		if err == nil {
+			_, err = file.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			u.Close()
+			return nil, err
+		}
+		return u, nil
+	}
+}
+
+// GetAttachments lists a task's attachments, oldest first.
+func (h *TaskHandler) GetAttachments(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	attachments, err := h.attachmentRepo.FindByTask(c.Context(), task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch attachments",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(attachments)
+}
+
+// GetAttachmentURL issues a signed URL to download an attachment. Anyone
+// holding the URL can download the file until it expires, so it is only
+// handed to users who can see the task.
+func (h *TaskHandler) GetAttachmentURL(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	attachment, ferr := h.loadAttachment(c, task)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	expiresAt := time.Now().Add(attachmentURLTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return c.Status(fiber.StatusOK).JSON(AttachmentURL{
+		URL:       fmt.Sprintf("%s/files/%s?expires=%s&signature=%s", c.BaseURL(), attachment.ID.Hex(), expires, h.signAttachment(attachment.ID, expires)),
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// DownloadAttachment serves an attachment to the holder of a URL issued by
+// GetAttachmentURL. It sits outside the authenticated routes; the signature
+// is the authorization.
+func (h *TaskHandler) DownloadAttachment(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "attachment not found",
+		})
+	}
+
+	expires := c.Query("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(c.Query("signature")), []byte(h.signAttachment(id, expires))) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "invalid signature",
+		})
+	}
+	if time.Now().Unix() > unix {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "link has expired",
+		})
+	}
+
+	attachment, err := h.attachmentRepo.FindByID(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch attachment",
+		})
+	}
+	// Attachments of deleted tasks cannot be downloaded while the task is in
+	// the trash.
+	var task *models.Task
+	if attachment != nil {
+		if task, err = h.taskRepo.FindByID(c.Context(), attachment.TaskID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch attachment",
+			})
+		}
+	}
+	if task == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "attachment not found",
+		})
+	}
+
+	blob, err := h.blobs.Get(c.Context(), attachment.BlobKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "attachment not found",
+			})
+		}
+		log.Printf("Warning: Failed to read attachment blob %s: %v", attachment.BlobKey, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to read attachment",
+		})
+	}
+
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=0")
+	return c.Status(fiber.StatusOK).SendStream(blob, int(attachment.Size))
+}
+
+// DeleteAttachment removes an attachment and its blob.
+func (h *TaskHandler) DeleteAttachment(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	attachment, ferr := h.loadAttachment(c, task)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	// The attachment disappears at once; if its blob cannot be removed now,
+	// collectBlobs retries later.
+	if err := h.attachmentRepo.MarkDeleted(c.Context(), attachment.ID, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete attachment",
+		})
+	}
+	if err := h.removeBlob(c.Context(), attachment); err != nil {
+		log.Printf("Warning: Failed to remove attachment blob %s: %v", attachment.BlobKey, err)
+	}
+
+	h.broadcastTask(c, task, websocket.Message{
+		Type:    "attachment_deleted",
+		Payload: fiber.Map{"id": attachment.ID, "task_id": task.ID},
+	})
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// collectBlobs removes the blobs of deleted attachments, and then the
+// attachments themselves. Failures are logged and retried on the next run.
+func (h *TaskHandler) collectBlobs(ctx context.Context) {
+	for {
+		deleted, err := h.attachmentRepo.FindDeleted(ctx, blobCollectBatch)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch deleted attachments: %v", err)
+			return
+		}
+		for _, attachment := range deleted {
+			if err := h.removeBlob(ctx, attachment); err != nil {
+				log.Printf("Warning: Failed to remove attachment blob %s: %v", attachment.BlobKey, err)
+				return
+			}
+		}
+		if len(deleted) < blobCollectBatch {
+			return
+		}
+	}
+}
+
+// removeBlob deletes the blob of a deleted attachment, then its record.
+func (h *TaskHandler) removeBlob(ctx context.Context, attachment *models.Attachment) error {
+	if err := h.blobs.Delete(ctx, attachment.BlobKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return h.attachmentRepo.Delete(ctx, attachment.ID)
+}
+
+// loadAttachment resolves the :attachmentId parameter to an attachment of
+// task.
+func (h *TaskHandler) loadAttachment(c *fiber.Ctx, task *models.Task) (*models.Attachment, *fiber.Error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("attachmentId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid attachment id")
+	}
+	attachment, err := h.attachmentRepo.FindByID(c.Context(), id)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch attachment")
+	}
+	if attachment == nil || attachment.TaskID != task.ID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "attachment not found")
+	}
+	return attachment, nil
+}
+
+// signAttachment signs a download of the attachment valid until expires, a
+// Unix time.
+func (h *TaskHandler) signAttachment(id primitive.ObjectID, expires string) string {
+	mac := hmac.New(sha256.New, h.urlSecret)
+	mac.Write([]byte(id.Hex() + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// attachmentFilename strips the directories and control characters from a
+// client-supplied filename.
+func attachmentFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
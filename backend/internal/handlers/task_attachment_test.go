@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/middleware"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/storage"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDownloadAttachmentSignature(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &TaskHandler{
+		taskRepo:       repository.NewMemoryTaskRepository(),
+		attachmentRepo: repository.NewMemoryAttachmentRepository(),
+		blobs:          blobs,
+		urlSecret:      []byte("test secret"),
+	}
+
+	owner := primitive.NewObjectID()
+	task := &models.Task{Title: "report", CreatedBy: owner}
+	if err := h.taskRepo.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	attachment := &models.Attachment{
+		ID:          primitive.NewObjectID(),
+		TaskID:      task.ID,
+		UploadedBy:  owner,
+		Filename:    "notes.txt",
+		ContentType: "text/plain",
+		Size:        5,
+		BlobKey:     "tasks/" + task.ID.Hex() + "/notes",
+	}
+	if err := h.blobs.Put(ctx, attachment.BlobKey, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.attachmentRepo.Create(ctx, attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/files/:id", h.DownloadAttachment)
+	download := func(t *testing.T, id, expires, signature string) (int, string) {
+		t.Helper()
+		target := "/files/" + id + "?expires=" + expires + "&signature=" + signature
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	unix := func(at time.Time) string {
+		return strconv.FormatInt(at.Unix(), 10)
+	}
+	// tamper changes the first character of a signature.
+	tamper := func(signature string) string {
+		if signature[0] == 'A' {
+			return "B" + signature[1:]
+		}
+		return "A" + signature[1:]
+	}
+
+	id := attachment.ID.Hex()
+	future := unix(time.Now().Add(attachmentURLTTL))
+	past := unix(time.Now().Add(-time.Second))
+	other := primitive.NewObjectID()
+
+	tests := []struct {
+		name      string
+		id        string
+		expires   string
+		signature string
+		code      int
+		body      string
+	}{
+		{name: "valid", id: id, expires: future, signature: h.signAttachment(attachment.ID, future), code: fiber.StatusOK, body: "hello"},
+		{name: "expired", id: id, expires: past, signature: h.signAttachment(attachment.ID, past), code: fiber.StatusForbidden, body: "link has expired"},
+		{name: "expiry extended", id: id, expires: unix(time.Now().Add(time.Hour)), signature: h.signAttachment(attachment.ID, future), code: fiber.StatusForbidden, body: "invalid signature"},
+		{name: "signed for another attachment", id: id, expires: future, signature: h.signAttachment(other, future), code: fiber.StatusForbidden, body: "invalid signature"},
+		{name: "tampered signature", id: id, expires: future, signature: tamper(h.signAttachment(attachment.ID, future)), code: fiber.StatusForbidden, body: "invalid signature"},
+		{name: "no signature", id: id, expires: future, code: fiber.StatusForbidden, body: "invalid signature"},
+		{name: "no expiry", id: id, signature: h.signAttachment(attachment.ID, ""), code: fiber.StatusForbidden, body: "invalid signature"},
+		{name: "unknown attachment", id: other.Hex(), expires: future, signature: h.signAttachment(other, future), code: fiber.StatusNotFound, body: "attachment not found"},
+		{name: "malformed id", id: "nope", expires: future, signature: h.signAttachment(attachment.ID, future), code: fiber.StatusNotFound, body: "attachment not found"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, body := download(t, tc.id, tc.expires, tc.signature)
+			if code != tc.code || !strings.Contains(body, tc.body) {
+				t.Errorf("download = %d %s, want %d containing %q", code, body, tc.code, tc.body)
+			}
+		})
+	}
+
+	t.Run("key rotated", func(t *testing.T) {
+		signature := h.signAttachment(attachment.ID, future)
+		rotated := *h
+		rotated.urlSecret = []byte("another secret")
+		if rotated.signAttachment(attachment.ID, future) == signature {
+			t.Fatal("signatures do not depend on the secret")
+		}
+	})
+
+	t.Run("task in trash", func(t *testing.T) {
+		if err := h.taskRepo.Delete(ctx, task.ID, models.TaskDeletion{By: owner}); err != nil {
+			t.Fatal(err)
+		}
+		code, body := download(t, id, future, h.signAttachment(attachment.ID, future))
+		if code != fiber.StatusNotFound {
+			t.Errorf("download = %d %s, want 404", code, body)
+		}
+	})
+}
+
+func TestAttachmentFilename(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\me\photo.png`, "photo.png"},
+		{"dir/", "dir"},
+		{"  spaced name.txt ", "spaced name.txt"},
+		{"quo\"te\r\n.txt", "quote.txt"},
+		{"", "attachment"},
+		{"/", "attachment"},
+		{"..", "attachment"},
+		{strings.Repeat("é", 200), strings.Repeat("é", 127)},
+	}
+	for _, tc := range tests {
+		if got := attachmentFilename(tc.in); got != tc.want {
+			t.Errorf("attachmentFilename(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestUploadAttachmentLimits(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskRepo := repository.NewMemoryTaskRepository()
+	projectRepo := repository.NewMemoryProjectRepository()
+	h := &TaskHandler{
+		taskRepo:       taskRepo,
+		projectRepo:    projectRepo,
+		attachmentRepo: repository.NewMemoryAttachmentRepository(),
+		blobs:          blobs,
+		access:         &taskAccess{taskRepo: taskRepo, projectRepo: projectRepo},
+		hub:            websocket.NewHub(),
+		maxAttachment:  32 << 10,
+	}
+
+	owner := primitive.NewObjectID()
+	task := &models.Task{Title: "report", CreatedBy: owner}
+	if err := h.taskRepo.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	// Configured as in main: bodies are streamed, and only the upload route
+	// skips the global limit.
+	const bodyLimit = 12 << 10
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+	app.Use(middleware.BodyLimit(bodyLimit, func(c *fiber.Ctx) bool {
+		return strings.HasSuffix(c.Path(), "/attachments")
+	}))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", owner)
+		return c.Next()
+	})
+	app.Post("/tasks/:id/attachments", h.UploadAttachment)
+	app.Post("/echo", func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	})
+
+	post := func(t *testing.T, target, contentType string, body []byte) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest("POST", target, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+	form := func(t *testing.T, field string, content []byte) (string, []byte) {
+		t.Helper()
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		if err := w.WriteField("note", "before the file"); err != nil {
+			t.Fatal(err)
+		}
+		part, err := w.CreateFormFile(field, "notes.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+		w.Close()
+		return w.FormDataContentType(), buf.Bytes()
+	}
+	text := func(n int) []byte {
+		return bytes.Repeat([]byte("a"), n)
+	}
+
+	uploads := []struct {
+		name    string
+		field   string
+		content []byte
+		code    int
+		body    string
+	}{
+		{name: "over the global limit", field: "file", content: text(20 << 10), code: fiber.StatusCreated, body: `"size":20480`},
+		{name: "at the limit", field: "file", content: text(32 << 10), code: fiber.StatusCreated, body: `"size":32768`},
+		{name: "over the limit", field: "file", content: text(32<<10 + 1), code: fiber.StatusRequestEntityTooLarge, body: "file cannot be larger than 32768 bytes"},
+		{name: "empty", field: "file", code: fiber.StatusBadRequest, body: "file is empty"},
+		{name: "no file field", field: "other", content: text(10), code: fiber.StatusBadRequest, body: "file is required"},
+	}
+	for _, tc := range uploads {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType, body := form(t, tc.field, tc.content)
+			code, out := post(t, "/tasks/"+task.ID.Hex()+"/attachments", contentType, body)
+			if code != tc.code || !strings.Contains(out, tc.body) {
+				t.Errorf("upload = %d %s, want %d containing %q", code, out, tc.code, tc.body)
+			}
+		})
+	}
+
+	t.Run("not multipart", func(t *testing.T) {
+		code, out := post(t, "/tasks/"+task.ID.Hex()+"/attachments", "application/json", []byte("{}"))
+		if code != fiber.StatusBadRequest {
+			t.Errorf("upload = %d %s, want 400", code, out)
+		}
+	})
+
+	t.Run("other routes keep the global limit", func(t *testing.T) {
+		if code, out := post(t, "/echo", "application/json", text(bodyLimit)); code != fiber.StatusOK || out != strconv.Itoa(bodyLimit) {
+			t.Errorf("body at the limit = %d %s, want 200 %d", code, out, bodyLimit)
+		}
+		if code, out := post(t, "/echo", "application/json", text(bodyLimit+1)); code != fiber.StatusRequestEntityTooLarge {
+			t.Errorf("body over the limit = %d %s, want 413", code, out)
+		}
+	})
+}
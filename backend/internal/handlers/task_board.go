@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/rank"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MoveTaskRequest places a task on the board. After and Before name, by ID
+// or key, the tasks it should end up directly below and above; either may
+// be left out, and with neither the task goes to the end of the column.
+// Status moves the task to another column, subject to the workflow.
+type MoveTaskRequest struct {
+	Status models.TaskStatus `json:"status"`
+	After  string            `json:"after"`
+	Before string            `json:"before"`
+}
+
+// BoardColumn is one status of a board with its tasks in rank order.
+type BoardColumn struct {
+	Status   models.TaskStatus     `json:"status"`
+	Name     string                `json:"name"`
+	Category models.StatusCategory `json:"category"`
+	Tasks    []*models.Task        `json:"tasks"`
+}
+
+// taskMove is the payload of a task_moved message. Reranked holds the new
+// ranks of other tasks in the column when the move rebalanced it, limited
+// to the tasks each recipient can see.
+type taskMove struct {
+	ID             primitive.ObjectID    `json:"id"`
+	Status         models.TaskStatus     `json:"status"`
+	StatusCategory models.StatusCategory `json:"status_category"`
+	Rank           string                `json:"rank"`
+	Version        int64                 `json:"version"`
+	Reranked       map[string]string     `json:"reranked,omitempty"`
+}
+
+// GetBoard returns the tasks the caller can see as board columns, one per
+// status of the workflow, in rank order. It takes the filters of GET
+// /api/tasks; with ?project_id= the columns follow that project's workflow,
+// otherwise the default one. Tasks in statuses the workflow does not have
+// get columns of their own after the others.
+func (h *TaskHandler) GetBoard(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	filter, ferr := parseTaskFilter(c, userID, h.projectRepo)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if ferr := resolveCustomFields(c.Context(), &filter, nil, userID, h.projectRepo); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var project *models.Project
+	if filter.ProjectID != nil {
+		var err error
+		if project, err = h.projectRepo.FindByID(c.Context(), *filter.ProjectID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch project",
+			})
+		}
+		if project == nil || !project.CanView(userID) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "project not found",
+			})
+		}
+	}
+
+	if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+	tasks, err := h.taskRepo.Find(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+	models.SortByRank(tasks)
+
+	columns := []*BoardColumn{}
+	index := make(map[models.TaskStatus]*BoardColumn)
+	for _, status := range project.TaskWorkflow().Statuses {
+		column := &BoardColumn{Status: status.Key, Name: status.Name, Category: status.Category, Tasks: []*models.Task{}}
+		index[status.Key] = column
+		columns = append(columns, column)
+	}
+	for _, task := range tasks {
+		column, ok := index[task.Status]
+		if !ok {
+			column = &BoardColumn{Status: task.Status, Name: string(task.Status), Category: task.Category(), Tasks: []*models.Task{}}
+			index[task.Status] = column
+			columns = append(columns, column)
+		}
+		column.Tasks = append(column.Tasks, task)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"columns": columns,
+	})
+}
+
+// MoveTask reorders a task within its board column or moves it to another
+// one. The task gets a rank between its new neighbours; when there is no
+// room left between them, or one of them has never been placed, the whole
+// column is ranked afresh in its current order. Everyone who can see the
+// task gets a single task_moved message.
+func (h *TaskHandler) MoveTask(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req MoveTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	existing, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if !ifMatch(c, existing) {
+		return h.respondStale(c, existing.ID)
+	}
+
+	update := &models.TaskUpdate{}
+	status := existing.Status
+	if req.Status != "" && req.Status != existing.Status {
+		status = req.Status
+		update.Status = &status
+		if ferr := h.applyWorkflow(c.Context(), existing, update); ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		blockers, err := h.statusBlockers(c.Context(), existing, update)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to check dependencies",
+			})
+		}
+		if len(blockers) > 0 {
+			return h.respondBlocked(c, userID, blockers)
+		}
+	}
+
+	warnings, ferr := h.checkWIP(c.Context(), userID, existing, previewTask(existing, update), nil)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	column, err := h.boardColumn(c.Context(), existing, status, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+	position, ferr := movePosition(column, req.After, req.Before)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	// A rank between the neighbours only rewrites this task. Otherwise the
+	// column, with the task in its new place, is ranked again.
+	var prev, next string
+	if position > 0 {
+		prev = column[position-1].Rank
+	}
+	if position < len(column) {
+		next = column[position].Rank
+	}
+	newRank, err := rank.Between(prev, next)
+	rebalance := err != nil || len(newRank) > rank.MaxLength ||
+		(position > 0 && prev == "") || (position < len(column) && next == "")
+	reranked := make(map[primitive.ObjectID]string)
+	if rebalance {
+		ordered := make([]*models.Task, 0, len(column)+1)
+		ordered = append(ordered, column[:position]...)
+		ordered = append(ordered, existing)
+		ordered = append(ordered, column[position:]...)
+		for i, spread := range rank.Spread(len(ordered)) {
+			if ordered[i].ID == existing.ID {
+				newRank = spread
+			} else if ordered[i].Rank != spread {
+				reranked[ordered[i].ID] = spread
+			}
+		}
+	}
+	update.Rank = &newRank
+
+	task, err := h.updateTask(c, existing, update)
+	if err != nil {
+		return h.respondUpdateFailed(c, existing.ID, err)
+	}
+
+	var moved []*models.Task
+	for _, other := range column {
+		r, ok := reranked[other.ID]
+		if !ok {
+			continue
+		}
+		if err := h.taskRepo.Update(c.Context(), other.ID, &models.TaskUpdate{Rank: &r}); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to rebalance the board column",
+			})
+		}
+		other.Rank = r
+		moved = append(moved, other)
+	}
+
+	// Completing an occurrence of a recurring task schedules the next one
+	if !existing.Done() && task.Done() {
+		if _, ferr := h.scheduleNextOccurrence(c, task); ferr != nil {
+			log.Printf("Warning: Failed to schedule next occurrence of task %s: %s", task.ID.Hex(), ferr.Message)
+		}
+	}
+
+	h.broadcastMove(c.Context(), task, moved)
+
+	setTaskETag(c, task)
+	setWIPWarnings(c, warnings)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
+
+// boardColumn returns the other tasks in the column a task is moving to, in
+// rank order: the tasks of its project with that status, or for a personal
+// task every task with that status the caller can see. Only tasks the caller
+// may edit are included, since a move can rewrite their ranks.
+func (h *TaskHandler) boardColumn(ctx context.Context, task *models.Task, status models.TaskStatus, userID primitive.ObjectID) ([]*models.Task, error) {
+	filter := models.TaskFilter{Status: []models.TaskStatus{status}}
+	if task.ProjectID != nil {
+		filter.ProjectID = task.ProjectID
+	} else if err := h.access.restrictToVisible(ctx, userID, &filter); err != nil {
+		return nil, err
+	}
+	found, err := h.taskRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	others := make([]*models.Task, 0, len(found))
+	for _, other := range found {
+		if other.ID != task.ID {
+			others = append(others, other)
+		}
+	}
+	column, err := h.access.editable(ctx, userID, others)
+	if err != nil {
+		return nil, err
+	}
+	models.SortByRank(column)
+	return column, nil
+}
+
+// movePosition returns where in column a task goes to sit directly below
+// after and above before. Given both, they must be next to each other; a
+// board that is out of date gets a conflict rather than a surprising order.
+func movePosition(column []*models.Task, after, before string) (int, *fiber.Error) {
+	find := func(ref, name string) (int, *fiber.Error) {
+		for i, task := range column {
+			if task.ID.Hex() == ref || (task.Key != "" && task.Key == ref) {
+				return i, nil
+			}
+		}
+		return 0, fiber.NewError(fiber.StatusBadRequest, name+" is not a task in the target column")
+	}
+
+	switch {
+	case after != "" && before != "":
+		a, ferr := find(after, "after")
+		if ferr != nil {
+			return 0, ferr
+		}
+		b, ferr := find(before, "before")
+		if ferr != nil {
+			return 0, ferr
+		}
+		if b != a+1 {
+			return 0, fiber.NewError(fiber.StatusConflict, "after and before are not next to each other in the column")
+		}
+		return b, nil
+	case after != "":
+		a, ferr := find(after, "after")
+		if ferr != nil {
+			return 0, ferr
+		}
+		return a + 1, nil
+	case before != "":
+		return find(before, "before")
+	}
+	return len(column), nil
+}
+
+// broadcastMove tells everyone who can see a moved task where it went, along
+// with the new ranks of the rebalanced tasks they can see.
+func (h *TaskHandler) broadcastMove(ctx context.Context, task *models.Task, reranked []*models.Task) {
+	for _, userID := range h.access.audience(ctx, task) {
+		payload := taskMove{
+			ID:             task.ID,
+			Status:         task.Status,
+			StatusCategory: task.Category(),
+			Rank:           task.Rank,
+			Version:        task.Version,
+		}
+		for _, other := range reranked {
+			visible, err := h.access.canView(ctx, other, userID)
+			if err != nil {
+				log.Printf("Warning: Failed to check access to task %s: %v", other.ID.Hex(), err)
+				continue
+			}
+			if visible {
+				if payload.Reranked == nil {
+					payload.Reranked = make(map[string]string)
+				}
+				payload.Reranked[other.ID.Hex()] = other.Rank
+			}
+		}
+		h.hub.BroadcastToUser(userID, websocket.Message{
+			Type:    "task_moved",
+			Payload: payload,
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMoveTaskLeavesTasksTheCallerCannotEdit(t *testing.T) {
+	h := newTestTaskHandler(t)
+	app := testApp()
+	app.Post("/tasks/:id/move", h.MoveTask)
+
+	caller, owner := primitive.NewObjectID(), primitive.NewObjectID()
+	viewerShare := []models.TaskShare{{UserID: caller, Role: models.ShareRoleViewer}}
+	editorShare := []models.TaskShare{{UserID: caller, Role: models.ShareRoleEditor}}
+
+	// None of the tasks have been placed, so the move ranks the column
+	// afresh.
+	shared := createTask(t, h, &models.Task{Title: "shared to view", Status: models.StatusTodo, CreatedBy: owner, SharedWith: viewerShare})
+	editable := createTask(t, h, &models.Task{Title: "shared to edit", Status: models.StatusTodo, CreatedBy: owner, SharedWith: editorShare})
+	first := createTask(t, h, &models.Task{Title: "first", Status: models.StatusTodo, CreatedBy: caller})
+	moving := createTask(t, h, &models.Task{Title: "moving", Status: models.StatusTodo, CreatedBy: caller})
+
+	code, body := send(t, app, caller, "POST", "/tasks/"+moving.ID.Hex()+"/move", MoveTaskRequest{After: first.ID.Hex()})
+	if code != fiber.StatusOK {
+		t.Fatalf("move = %d %s, want 200", code, body)
+	}
+
+	if got := findTask(t, h, shared.ID); got.Version != shared.Version || got.Rank != "" {
+		t.Errorf("view-only task rewritten: version %d rank %q, want %d and no rank", got.Version, got.Rank, shared.Version)
+	}
+	for _, task := range []*models.Task{editable, first, moving} {
+		if got := findTask(t, h, task.ID); got.Rank == "" {
+			t.Errorf("%s was not ranked", task.Title)
+		}
+	}
+	if findTask(t, h, first.ID).Rank >= findTask(t, h, moving.ID).Rank {
+		t.Error("moving is not ranked after first")
+	}
+
+	// A view-only task is not in the caller's column to move next to.
+	code, body = send(t, app, caller, "POST", "/tasks/"+moving.ID.Hex()+"/move", MoveTaskRequest{After: shared.ID.Hex()})
+	if code != fiber.StatusBadRequest || !strings.Contains(body, "not a task in the target column") {
+		t.Errorf("move after a view-only task = %d %s, want 400", code, body)
+	}
+}
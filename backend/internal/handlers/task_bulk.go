@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxBulkTasks bounds the number of tasks one bulk operation may touch.
+const maxBulkTasks = 500
+
+const (
+	bulkSetStatus   = "set_status"
+	bulkSetPriority = "set_priority"
+	bulkAssign      = "assign"
+	bulkAddTags     = "add_tags"
+	bulkRemoveTags  = "remove_tags"
+	bulkMoveProject = "move_project"
+	bulkDelete      = "delete"
+)
+
+// BulkTaskRequest applies one operation to several tasks, selected either by
+// IDs (task IDs or keys) or by a Filter. The operation's argument is Status,
+// Priority, AssignedTo ("me" or a user ID), Tags or ProjectID. Deleting tasks
+// that have subtasks requires Children to be "cascade".
+type BulkTaskRequest struct {
+	IDs        []string            `json:"ids"`
+	Filter     *models.TaskFilter  `json:"filter"`
+	Operation  string              `json:"operation"`
+	Status     models.TaskStatus   `json:"status"`
+	Priority   models.TaskPriority `json:"priority"`
+	AssignedTo string              `json:"assigned_to"`
+	Tags       []string            `json:"tags"`
+	ProjectID  string              `json:"project_id"`
+	Children   string              `json:"children"`
+}
+
+// BulkTaskResult is the outcome of a bulk operation for one task. Code is
+// the status a single request for the task would have returned.
+type BulkTaskResult struct {
+	ID       string       `json:"id"`
+	OK       bool         `json:"ok"`
+	Code     int          `json:"code"`
+	Error    string       `json:"error,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+	Task     *models.Task `json:"task,omitempty"`
+}
+
+// bulkItem is a task that passed validation, with the change to make to it.
+// A nil update on a delete operation trashes the task and its subtasks.
+// keyProject is the project the task moves to; its key there is allocated
+// along with the change, so a rolled back transaction gives it back.
+type bulkItem struct {
+	result     *BulkTaskResult
+	task       *models.Task
+	update     *models.TaskUpdate
+	subtasks   []*models.Task
+	keyProject *models.Project
+}
+
+func (r *BulkTaskResult) fail(code int, message string) {
+	r.OK, r.Code, r.Error, r.Warnings, r.Task = false, code, message, nil, nil
+}
+
+// BulkUpdateTasks applies one operation to many tasks. Every task is checked
+// on its own, and the ones that pass are changed together in a single
+// transaction when the database supports it, so that either all of them
+// change or none do; otherwise each is changed separately. The response lists
+// the result for every task, and each affected user receives one
+// "tasks_bulk" WebSocket message instead of one message per task.
+func (h *TaskHandler) BulkUpdateTasks(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req BulkTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	prepare, ferr := h.bulkOperation(c, &req, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	results, tasks, ferr := h.bulkTargets(c, &req, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	// WIP limits count the tasks earlier items move into a group as well.
+	var items []*bulkItem
+	tally := wipTally{}
+	for i, task := range tasks {
+		if task == nil {
+			continue
+		}
+		item := &bulkItem{result: results[i], task: task}
+		if ferr := prepare(item); ferr != nil {
+			item.result.fail(ferr.Code, ferr.Message)
+			continue
+		}
+		if item.update != nil {
+			warnings, ferr := h.checkWIP(c.Context(), userID, task, previewTask(task, item.update), tally)
+			if ferr != nil {
+				item.result.fail(ferr.Code, ferr.Message)
+				continue
+			}
+			item.result.Warnings = warnings
+		}
+		item.result.OK, item.result.Code, item.result.Task = true, fiber.StatusOK, task
+		if item.update != nil || req.Operation == bulkDelete {
+			items = append(items, item)
+		}
+	}
+
+	transaction := true
+	err := h.taskRepo.WithTransaction(c.Context(), func(ctx context.Context) error {
+		return h.applyBulk(ctx, userID, items, true)
+	})
+	if errors.Is(err, repository.ErrTransactionsUnsupported) {
+		transaction = false
+		err = h.applyBulk(c.Context(), userID, items, false)
+	}
+	if err != nil {
+		code, message := fiber.StatusInternalServerError, "failed to update tasks"
+		if errors.Is(err, repository.ErrVersionConflict) {
+			code, message = fiber.StatusPreconditionFailed, "a task was modified concurrently"
+		}
+		for _, item := range items {
+			item.result.fail(code, "no task was changed: "+message)
+		}
+	}
+
+	// Completing an occurrence of a recurring task schedules the next one
+	if req.Operation == bulkSetStatus {
+		for _, item := range items {
+			if !item.result.OK || item.task.Done() || !item.result.Task.Done() {
+				continue
+			}
+			if _, ferr := h.scheduleNextOccurrence(c, item.result.Task); ferr != nil {
+				log.Printf("Warning: Failed to schedule next occurrence of task %s: %s", item.task.ID.Hex(), ferr.Message)
+			}
+		}
+	}
+
+	h.broadcastBulk(c, req.Operation, items)
+	h.notifyBulk(c.Context(), userID, items)
+
+	succeeded := 0
+	for _, result := range results {
+		if result.OK {
+			succeeded++
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"results":     results,
+		"succeeded":   succeeded,
+		"failed":      len(results) - succeeded,
+		"transaction": transaction,
+	})
+}
+
+// bulkOperation validates the operation of req and returns the function that
+// prepares the change for each task.
+func (h *TaskHandler) bulkOperation(c *fiber.Ctx, req *BulkTaskRequest, userID primitive.ObjectID) (func(item *bulkItem) *fiber.Error, *fiber.Error) {
+	switch req.Operation {
+	case bulkSetStatus:
+		if !models.ValidTaskStatus(req.Status) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid status")
+		}
+		return func(item *bulkItem) *fiber.Error {
+			if item.task.Status == req.Status {
+				return nil
+			}
+			update := &models.TaskUpdate{Status: &req.Status}
+			if ferr := h.applyWorkflow(c.Context(), item.task, update); ferr != nil {
+				return ferr
+			}
+			blockers, err := h.statusBlockers(c.Context(), item.task, update)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to check dependencies")
+			}
+			if len(blockers) > 0 {
+				return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("task is blocked by %d unfinished task(s)", len(blockers)))
+			}
+			item.update = update
+			return nil
+		}, nil
+
+	case bulkSetPriority:
+		if !models.ValidTaskPriority(req.Priority) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "priority must be one of low, medium, high")
+		}
+		return func(item *bulkItem) *fiber.Error {
+			if item.task.Priority != req.Priority {
+				item.update = &models.TaskUpdate{Priority: &req.Priority}
+			}
+			return nil
+		}, nil
+
+	case bulkAssign:
+		assignee, ok := userRef(req.AssignedTo, userID)
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "assigned_to must be me or a user id")
+		}
+		return func(item *bulkItem) *fiber.Error {
+			if item.task.AssignedTo == nil || *item.task.AssignedTo != assignee {
+				item.update = &models.TaskUpdate{AssignedTo: &assignee}
+			}
+			return nil
+		}, nil
+
+	case bulkAddTags, bulkRemoveTags:
+		if len(req.Tags) == 0 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "tags are required")
+		}
+		return func(item *bulkItem) *fiber.Error {
+			var tags []string
+			if req.Operation == bulkAddTags {
+				tags = append(tags, item.task.Tags...)
+				for _, tag := range req.Tags {
+					if !containsString(tags, tag) {
+						tags = append(tags, tag)
+					}
+				}
+			} else {
+				tags = []string{}
+				for _, tag := range item.task.Tags {
+					if !containsString(req.Tags, tag) {
+						tags = append(tags, tag)
+					}
+				}
+			}
+			if len(tags) != len(item.task.Tags) {
+				item.update = &models.TaskUpdate{Tags: &tags}
+			}
+			return nil
+		}, nil
+
+	case bulkMoveProject:
+		projectID, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid project id")
+		}
+		project, projectErr := h.taskKeyProject(c.Context(), projectID, userID)
+		return func(item *bulkItem) *fiber.Error {
+			task := item.task
+			if task.ProjectID != nil && *task.ProjectID == projectID {
+				return nil
+			}
+			if projectErr != nil {
+				return projectErr
+			}
+			// As in UpdateTask, the task gets a key in its new project and
+			// keeps the old one.
+			previousKeys := append([]string{}, task.PreviousKeys...)
+			if task.Key != "" {
+				previousKeys = append(previousKeys, task.Key)
+			}
+			update := &models.TaskUpdate{ProjectID: &projectID, PreviousKeys: &previousKeys}
+			if ferr := h.applyWorkflow(c.Context(), task, update); ferr != nil {
+				return ferr
+			}
+			item.update, item.keyProject = update, project
+			return nil
+		}, nil
+
+	case bulkDelete:
+		if req.Children != "" && req.Children != "cascade" {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "children must be cascade")
+		}
+		return func(item *bulkItem) *fiber.Error {
+			descendants, err := h.descendants(c.Context(), item.task.ID, models.TaskFilter{})
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch subtasks")
+			}
+			if len(descendants) > 0 && req.Children != "cascade" {
+				return fiber.NewError(fiber.StatusConflict, "task has subtasks; set children to cascade to delete them too")
+			}
+			for _, descendant := range descendants {
+				allowed, err := h.access.canEdit(c.Context(), descendant, userID)
+				if err != nil {
+					return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch subtasks")
+				}
+				if !allowed {
+					return fiber.NewError(fiber.StatusForbidden, "task has subtasks you cannot delete")
+				}
+			}
+			item.subtasks = descendants
+			return nil
+		}, nil
+	}
+
+	return nil, fiber.NewError(fiber.StatusBadRequest, "operation must be one of set_status, set_priority, assign, add_tags, remove_tags, move_project, delete")
+}
+
+// bulkTargets loads the tasks req selects, with one result per task. Tasks
+// that cannot be changed already have a failed result and a nil entry in
+// tasks.
+func (h *TaskHandler) bulkTargets(c *fiber.Ctx, req *BulkTaskRequest, userID primitive.ObjectID) ([]*BulkTaskResult, []*models.Task, *fiber.Error) {
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "select tasks with either ids or filter")
+	}
+
+	var (
+		results []*BulkTaskResult
+		tasks   []*models.Task
+	)
+	seen := make(map[primitive.ObjectID]bool)
+
+	if req.Filter != nil {
+		filter := *req.Filter
+		if ferr := validateTaskFilter(&filter); ferr != nil {
+			return nil, nil, ferr
+		}
+		if ferr := resolveCustomFields(c.Context(), &filter, nil, userID, h.projectRepo); ferr != nil {
+			return nil, nil, ferr
+		}
+		if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch tasks")
+		}
+		found, err := h.taskRepo.Find(c.Context(), filter)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch tasks")
+		}
+		if len(found) > maxBulkTasks {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("filter matches %d tasks; bulk operations are limited to %d", len(found), maxBulkTasks))
+		}
+		for _, task := range found {
+			result := &BulkTaskResult{ID: task.ID.Hex()}
+			allowed, err := h.access.canEdit(c.Context(), task, userID)
+			if err != nil {
+				result.fail(fiber.StatusInternalServerError, "failed to fetch task")
+				task = nil
+			} else if !allowed {
+				result.fail(fiber.StatusForbidden, "you cannot edit this task")
+				task = nil
+			}
+			results = append(results, result)
+			tasks = append(tasks, task)
+		}
+		return results, tasks, nil
+	}
+
+	if len(req.IDs) > maxBulkTasks {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("bulk operations are limited to %d tasks", maxBulkTasks))
+	}
+	for _, ref := range req.IDs {
+		result := &BulkTaskResult{ID: ref}
+		task, ferr := h.access.load(c.Context(), ref, userID, true)
+		if ferr != nil {
+			result.fail(ferr.Code, ferr.Message)
+		} else if seen[task.ID] {
+			continue
+		} else {
+			seen[task.ID] = true
+			result.ID = task.ID.Hex()
+		}
+		results = append(results, result)
+		tasks = append(tasks, task)
+	}
+	return results, tasks, nil
+}
+
+// applyBulk stores the changes of items. Inside a transaction the first
+// failure aborts it; otherwise the failure is recorded on the item and the
+// rest are still applied.
+func (h *TaskHandler) applyBulk(ctx context.Context, actorID primitive.ObjectID, items []*bulkItem, transaction bool) error {
+	trashed := make(map[primitive.ObjectID]bool)
+	for _, item := range items {
+		err := h.applyBulkItem(ctx, actorID, item, trashed)
+		if err == nil {
+			continue
+		}
+		if transaction {
+			return err
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			item.result.fail(fiber.StatusPreconditionFailed, "task was modified concurrently")
+		} else {
+			item.result.fail(fiber.StatusInternalServerError, "failed to update task")
+		}
+	}
+	return nil
+}
+
+func (h *TaskHandler) applyBulkItem(ctx context.Context, actorID primitive.ObjectID, item *bulkItem, trashed map[primitive.ObjectID]bool) error {
+	if item.update != nil {
+		if item.keyProject != nil {
+			key, ferr := h.nextTaskKey(ctx, item.keyProject)
+			if ferr != nil {
+				return ferr
+			}
+			item.update.Key = &key
+		}
+		updated, err := h.storeTaskUpdate(ctx, actorID, item.task, item.update)
+		if err != nil {
+			return err
+		}
+		item.result.Task = updated
+		return nil
+	}
+
+	// A task selected along with its parent may already be in the trash.
+	for _, subtask := range item.subtasks {
+		if trashed[subtask.ID] {
+			continue
+		}
+		if err := h.trashTaskAs(ctx, actorID, subtask, &item.task.ID); err != nil {
+			return err
+		}
+		trashed[subtask.ID] = true
+	}
+	if !trashed[item.task.ID] {
+		if err := h.trashTaskAs(ctx, actorID, item.task, nil); err != nil {
+			return err
+		}
+		trashed[item.task.ID] = true
+	}
+	item.result.Task = nil
+	return nil
+}
+
+// notifyBulk notifies the people involved in the updated tasks, once the
+// changes are committed, so a transaction that is retried or rolled back
+// does not notify anyone twice or of changes that never happened.
+func (h *TaskHandler) notifyBulk(ctx context.Context, actorID primitive.ObjectID, items []*bulkItem) {
+	for _, item := range items {
+		if item.result.OK && item.update != nil {
+			h.notifier.notifyTaskChange(ctx, actorID, item.task, item.result.Task)
+		}
+	}
+}
+
+// broadcastBulk sends everyone who can see any of the changed tasks a single
+// message listing the ones they can see.
+func (h *TaskHandler) broadcastBulk(c *fiber.Ctx, operation string, items []*bulkItem) {
+	type batch struct {
+		Operation string         `json:"operation"`
+		Updated   []*models.Task `json:"updated"`
+		Deleted   []fiber.Map    `json:"deleted"`
+	}
+	batches := make(map[primitive.ObjectID]*batch)
+	var order []primitive.ObjectID
+	deleted := make(map[primitive.ObjectID]bool)
+	batchFor := func(userID primitive.ObjectID) *batch {
+		b, ok := batches[userID]
+		if !ok {
+			b = &batch{Operation: operation, Updated: []*models.Task{}, Deleted: []fiber.Map{}}
+			batches[userID] = b
+			order = append(order, userID)
+		}
+		return b
+	}
+
+	for _, item := range items {
+		if !item.result.OK {
+			continue
+		}
+		if item.update != nil {
+			for _, userID := range h.access.audience(c.Context(), item.result.Task) {
+				b := batchFor(userID)
+				b.Updated = append(b.Updated, item.result.Task)
+			}
+			continue
+		}
+		for _, task := range append(item.subtasks, item.task) {
+			if deleted[task.ID] {
+				continue
+			}
+			deleted[task.ID] = true
+			for _, userID := range h.access.audience(c.Context(), task) {
+				b := batchFor(userID)
+				b.Deleted = append(b.Deleted, fiber.Map{"id": task.ID, "reason": "trashed"})
+			}
+		}
+	}
+
+	for _, userID := range order {
+		h.hub.BroadcastToUser(userID, websocket.Message{
+			Type:    "tasks_bulk",
+			Payload: batches[userID],
+		})
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBulkMoveProjectLosingARace(t *testing.T) {
+	owner := primitive.NewObjectID()
+	for _, transaction := range []bool{true, false} {
+		h := newTestTaskHandler(t)
+		racing := &racingStore{TaskStore: h.taskRepo}
+		var store repository.TaskStore = racing
+		if transaction {
+			store = &txStore{TaskStore: racing}
+		}
+		useTaskStore(h, store)
+		app := testApp()
+		app.Post("/tasks/bulk", h.BulkUpdateTasks)
+
+		project := &models.Project{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{{UserID: owner, Role: models.ProjectRoleOwner}}}
+		if err := h.projectRepo.Create(context.Background(), project); err != nil {
+			t.Fatal(err)
+		}
+		first := createTask(t, h, &models.Task{Title: "first", Status: models.StatusTodo, CreatedBy: owner})
+		second := createTask(t, h, &models.Task{Title: "second", Status: models.StatusTodo, CreatedBy: owner})
+
+		racing.race = second.ID
+		code, body := send(t, app, owner, "POST", "/tasks/bulk", BulkTaskRequest{
+			IDs:       []string{first.ID.Hex(), second.ID.Hex()},
+			Operation: bulkMoveProject,
+			ProjectID: project.ID.Hex(),
+		})
+		if code != fiber.StatusOK {
+			t.Fatalf("transaction %v: bulk move = %d %s, want 200", transaction, code, body)
+		}
+		var resp struct {
+			Results     []BulkTaskResult `json:"results"`
+			Transaction bool             `json:"transaction"`
+		}
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != 2 || resp.Transaction != transaction {
+			t.Fatalf("transaction %v: response %s", transaction, body)
+		}
+		if r := resp.Results[1]; r.OK || r.Code != fiber.StatusPreconditionFailed {
+			t.Errorf("transaction %v: raced task result %+v, want a 412 failure", transaction, r)
+		}
+		if got := findTask(t, h, second.ID); got.ProjectID != nil {
+			t.Errorf("transaction %v: raced task was moved", transaction)
+		}
+
+		got := findTask(t, h, first.ID)
+		if transaction {
+			if r := resp.Results[0]; r.OK || !strings.Contains(r.Error, "no task was changed") {
+				t.Errorf("transaction %v: first result %+v, want it failed with the batch", transaction, r)
+			}
+			if got.ProjectID != nil || got.Key != "" {
+				t.Errorf("transaction %v: first task in project %v with key %q after a rollback", transaction, got.ProjectID, got.Key)
+			}
+			continue
+		}
+		// Without a transaction the task moved before the failure stays
+		// moved.
+		if r := resp.Results[0]; !r.OK {
+			t.Errorf("transaction %v: first result %+v, want it moved", transaction, r)
+		}
+		if got.ProjectID == nil || *got.ProjectID != project.ID || got.Key != "OPS-1" {
+			t.Errorf("transaction %v: first task in project %v with key %q, want OPS-1", transaction, got.ProjectID, got.Key)
+		}
+	}
+}
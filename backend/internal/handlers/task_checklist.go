@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChecklistItemRequest adds an item to a checklist. Position is the 0-based
+// index to insert it at; it is appended by default.
+type ChecklistItemRequest struct {
+	Text     string `json:"text"`
+	Position *int   `json:"position"`
+}
+
+// UpdateChecklistItemRequest changes the text of an item or ticks it on or
+// off.
+type UpdateChecklistItemRequest struct {
+	Text *string `json:"text"`
+	Done *bool   `json:"done"`
+}
+
+// MoveChecklistItemRequest moves an item to a 0-based index.
+type MoveChecklistItemRequest struct {
+	Position *int `json:"position"`
+}
+
+// ChecklistChange describes a change to one checklist item. It is both the
+// response to a checklist request and the payload of the checklist_item_*
+// events, which carry it instead of the whole task. Position is the item's
+// index after the change; Version is the task's.
+type ChecklistChange struct {
+	TaskID   primitive.ObjectID        `json:"task_id"`
+	ItemID   primitive.ObjectID        `json:"item_id"`
+	Item     *models.ChecklistItem     `json:"item,omitempty"`
+	Position *int                      `json:"position,omitempty"`
+	Progress *models.ChecklistProgress `json:"progress"`
+	Version  int64                     `json:"version"`
+}
+
+// AddChecklistItem adds an item to a task's checklist.
+func (h *TaskHandler) AddChecklistItem(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req ChecklistItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	text, ferr := checklistText(req.Text)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	position := -1
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "position cannot be negative",
+			})
+		}
+		position = *req.Position
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	item := &models.ChecklistItem{ID: primitive.NewObjectID(), Text: text}
+	updated, err := h.changeChecklist(c, task, func(ctx context.Context) error {
+		return h.taskRepo.AddChecklistItem(ctx, task.ID, item, position)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrChecklistFull) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("a checklist can have at most %d items", models.MaxChecklistItems),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to add checklist item",
+		})
+	}
+
+	change := checklistChange(updated, item.ID)
+	h.broadcastTask(c, updated, websocket.Message{
+		Type:    "checklist_item_added",
+		Payload: change,
+	})
+
+	setTaskETag(c, updated)
+	return c.Status(fiber.StatusCreated).JSON(change)
+}
+
+// UpdateChecklistItem edits an item's text or toggles it done. Ticking off
+// an item records who did it and when.
+func (h *TaskHandler) UpdateChecklistItem(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(c.Params("itemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid checklist item id",
+		})
+	}
+
+	var req UpdateChecklistItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	update := &models.ChecklistItemUpdate{Done: req.Done, By: userID, At: time.Now()}
+	if req.Text != nil {
+		text, ferr := checklistText(*req.Text)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		update.Text = &text
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	updated, err := h.changeChecklist(c, task, func(ctx context.Context) error {
+		return h.taskRepo.UpdateChecklistItem(ctx, task.ID, itemID, update)
+	})
+	if err != nil {
+		return respondChecklistError(c, err, "failed to update checklist item")
+	}
+
+	change := checklistChange(updated, itemID)
+	h.broadcastTask(c, updated, websocket.Message{
+		Type:    "checklist_item_updated",
+		Payload: change,
+	})
+
+	setTaskETag(c, updated)
+	return c.Status(fiber.StatusOK).JSON(change)
+}
+
+// MoveChecklistItem moves an item to another position in the checklist.
+func (h *TaskHandler) MoveChecklistItem(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(c.Params("itemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid checklist item id",
+		})
+	}
+
+	var req MoveChecklistItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.Position == nil || *req.Position < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "position must be an index of 0 or more",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	updated, err := h.changeChecklist(c, task, func(ctx context.Context) error {
+		return h.taskRepo.MoveChecklistItem(ctx, task.ID, itemID, *req.Position)
+	})
+	if err != nil {
+		return respondChecklistError(c, err, "failed to move checklist item")
+	}
+
+	change := checklistChange(updated, itemID)
+	change.Item = nil
+	h.broadcastTask(c, updated, websocket.Message{
+		Type:    "checklist_item_moved",
+		Payload: change,
+	})
+
+	setTaskETag(c, updated)
+	return c.Status(fiber.StatusOK).JSON(change)
+}
+
+// DeleteChecklistItem removes an item from a task's checklist.
+func (h *TaskHandler) DeleteChecklistItem(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(c.Params("itemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid checklist item id",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	updated, err := h.changeChecklist(c, task, func(ctx context.Context) error {
+		return h.taskRepo.DeleteChecklistItem(ctx, task.ID, itemID)
+	})
+	if err != nil {
+		return respondChecklistError(c, err, "failed to delete checklist item")
+	}
+
+	h.broadcastTask(c, updated, websocket.Message{
+		Type:    "checklist_item_deleted",
+		Payload: checklistChange(updated, itemID),
+	})
+
+	setTaskETag(c, updated)
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// changeChecklist applies a checklist change to task, records it in the
+// task's history and returns the task as stored afterwards.
+func (h *TaskHandler) changeChecklist(c *fiber.Ctx, task *models.Task, change func(ctx context.Context) error) (*models.Task, error) {
+	if err := change(c.Context()); err != nil {
+		return nil, err
+	}
+	updated, err := h.taskRepo.FindByID(c.Context(), task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, repository.ErrChecklistItemNotFound
+	}
+	h.recordActivity(c, models.ActivityUpdated, task, updated)
+	return updated, nil
+}
+
+// checklistChange describes the state of an item after a change. Items no
+// longer in the checklist have no Item or Position.
+func checklistChange(task *models.Task, itemID primitive.ObjectID) *ChecklistChange {
+	change := &ChecklistChange{
+		TaskID:   task.ID,
+		ItemID:   itemID,
+		Progress: task.ChecklistProgress,
+		Version:  task.Version,
+	}
+	for i := range task.Checklist {
+		if task.Checklist[i].ID == itemID {
+			position := i
+			change.Item, change.Position = &task.Checklist[i], &position
+			break
+		}
+	}
+	return change
+}
+
+func checklistText(raw string) (string, *fiber.Error) {
+	text := strings.TrimSpace(raw)
+	if text == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "text is required")
+	}
+	if len(text) > models.MaxChecklistText {
+		return "", fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("text cannot be longer than %d characters", models.MaxChecklistText))
+	}
+	return text, nil
+}
+
+func respondChecklistError(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, repository.ErrChecklistItemNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "checklist item not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": message,
+	})
+}
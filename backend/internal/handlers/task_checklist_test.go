@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChecklistReorderAndCompletion(t *testing.T) {
+	h := newTestTaskHandler(t)
+	app := testApp()
+	app.Post("/tasks/:id/checklist", h.AddChecklistItem)
+	app.Put("/tasks/:id/checklist/:itemId", h.UpdateChecklistItem)
+	app.Post("/tasks/:id/checklist/:itemId/move", h.MoveChecklistItem)
+	app.Delete("/tasks/:id/checklist/:itemId", h.DeleteChecklistItem)
+
+	owner, viewer := primitive.NewObjectID(), primitive.NewObjectID()
+	task := createTask(t, h, &models.Task{Title: "release", Status: models.StatusTodo, CreatedBy: owner, SharedWith: []models.TaskShare{{UserID: viewer, Role: models.ShareRoleViewer}}})
+	base := "/tasks/" + task.ID.Hex() + "/checklist"
+
+	// change makes a checklist request and decodes the change it reports.
+	change := func(caller primitive.ObjectID, method, target string, body interface{}, want int) *ChecklistChange {
+		t.Helper()
+		code, resp := send(t, app, caller, method, target, body)
+		if code != want {
+			t.Fatalf("%s %s = %d %s, want %d", method, target, code, resp, want)
+		}
+		if code != fiber.StatusOK && code != fiber.StatusCreated {
+			return nil
+		}
+		var out ChecklistChange
+		if err := json.Unmarshal([]byte(resp), &out); err != nil {
+			t.Fatal(err)
+		}
+		return &out
+	}
+	assertOrder := func(want ...string) {
+		t.Helper()
+		var got []string
+		for _, item := range findTask(t, h, task.ID).Checklist {
+			got = append(got, item.Text)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("checklist = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("checklist = %v, want %v", got, want)
+			}
+		}
+	}
+
+	ids := map[string]string{}
+	for _, text := range []string{"build", "test", "ship"} {
+		ids[text] = change(owner, "POST", base, fiber.Map{"text": text}, fiber.StatusCreated).ItemID.Hex()
+	}
+	assertOrder("build", "test", "ship")
+
+	moved := change(owner, "POST", base+"/"+ids["ship"]+"/move", fiber.Map{"position": 0}, fiber.StatusOK)
+	if moved.Position == nil || *moved.Position != 0 || moved.Item != nil {
+		t.Errorf("move to the top reported %+v, want position 0 and no item", moved)
+	}
+	assertOrder("ship", "build", "test")
+	// A position past the end moves the item last.
+	change(owner, "POST", base+"/"+ids["build"]+"/move", fiber.Map{"position": 10}, fiber.StatusOK)
+	assertOrder("ship", "test", "build")
+
+	done := change(owner, "PUT", base+"/"+ids["test"], fiber.Map{"done": true}, fiber.StatusOK)
+	if done.Item == nil || !done.Item.Done || done.Item.DoneBy == nil || *done.Item.DoneBy != owner || done.Item.DoneAt == nil {
+		t.Errorf("ticked item = %+v, want it done by the owner with a time", done.Item)
+	}
+	if done.Progress == nil || done.Progress.Done != 1 || done.Progress.Total != 3 {
+		t.Errorf("progress after ticking = %+v, want 1 of 3", done.Progress)
+	}
+	undone := change(owner, "PUT", base+"/"+ids["test"], fiber.Map{"done": false}, fiber.StatusOK)
+	if undone.Item.Done || undone.Item.DoneBy != nil || undone.Item.DoneAt != nil || undone.Progress.Done != 0 {
+		t.Errorf("unticked item = %+v with progress %+v, want it open with no completion", undone.Item, undone.Progress)
+	}
+
+	change(owner, "PUT", base+"/"+ids["ship"], fiber.Map{"done": true}, fiber.StatusOK)
+	change(owner, "DELETE", base+"/"+ids["build"], nil, fiber.StatusNoContent)
+	assertOrder("ship", "test")
+	if progress := findTask(t, h, task.ID).ChecklistProgress; progress == nil || progress.Done != 1 || progress.Total != 2 {
+		t.Errorf("stored progress = %+v, want 1 of 2", progress)
+	}
+
+	change(owner, "PUT", base+"/"+ids["build"], fiber.Map{"done": true}, fiber.StatusNotFound)
+	// A viewer sees the checklist but cannot change it.
+	change(viewer, "PUT", base+"/"+ids["test"], fiber.Map{"done": true}, fiber.StatusNotFound)
+	change(viewer, "POST", base+"/"+ids["test"]+"/move", fiber.Map{"position": 0}, fiber.StatusNotFound)
+	assertOrder("ship", "test")
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/dueoffset"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CloneTaskRequest holds the options of a task clone. Tags are copied and
+// the assignee is not unless told otherwise. DueShift moves the due dates of
+// the copies by an offset such as "+1 week".
+type CloneTaskRequest struct {
+	Title           *string `json:"title"`
+	IncludeTags     *bool   `json:"include_tags"`
+	IncludeAssignee *bool   `json:"include_assignee"`
+	IncludeSubtasks bool    `json:"include_subtasks"`
+	DueShift        string  `json:"due_shift"`
+}
+
+// taskDraft is a task waiting to be created together with its subtasks.
+type taskDraft struct {
+	task     *models.Task
+	children []*taskDraft
+}
+
+// CloneTask copies a task, and with include_subtasks the subtasks the
+// caller can see, next to the original: in the same project and under the
+// same parent. The copies start over in the initial status of their
+// workflow, with their checklists unticked and without comments, time,
+// links or recurrence.
+func (h *TaskHandler) CloneTask(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req CloneTaskRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+	var shift *dueoffset.Offset
+	if req.DueShift != "" {
+		offset, err := dueoffset.Parse(req.DueShift)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "due_shift: " + err.Error(),
+			})
+		}
+		shift = &offset
+	}
+	includeTags := req.IncludeTags == nil || *req.IncludeTags
+	includeAssignee := req.IncludeAssignee != nil && *req.IncludeAssignee
+
+	source, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if source.ParentID != nil {
+		if _, ferr := h.validateParent(c.Context(), nil, *source.ParentID, userID); ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+	}
+
+	tree := &TaskNode{Task: source}
+	if req.IncludeSubtasks {
+		var err error
+		if tree, err = h.visibleTree(c.Context(), source, userID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch subtasks",
+			})
+		}
+	}
+
+	var copyNode func(node *TaskNode) *taskDraft
+	copyNode = func(node *TaskNode) *taskDraft {
+		original := node.Task
+		task := &models.Task{
+			Title:        original.Title,
+			Description:  original.Description,
+			Priority:     original.Priority,
+			DueDate:      original.DueDate,
+			CreatedBy:    userID,
+			ProjectID:    original.ProjectID,
+			Estimate:     original.Estimate,
+			CustomFields: original.CustomFields,
+		}
+		for _, item := range original.Checklist {
+			task.Checklist = append(task.Checklist, models.ChecklistItem{ID: primitive.NewObjectID(), Text: item.Text})
+		}
+		if includeTags {
+			task.Tags = original.Tags
+		}
+		if includeAssignee {
+			task.AssignedTo = original.AssignedTo
+		}
+		if shift != nil && !task.DueDate.IsZero() {
+			task.DueDate = shift.Apply(task.DueDate)
+		}
+		draft := &taskDraft{task: task}
+		for _, child := range node.Children {
+			draft.children = append(draft.children, copyNode(child))
+		}
+		return draft
+	}
+	root := copyNode(tree)
+	root.task.ParentID = source.ParentID
+	if req.Title != nil {
+		root.task.Title = strings.TrimSpace(*req.Title)
+		if root.task.Title == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "title cannot be empty",
+			})
+		}
+	}
+
+	created, warnings, ferr := h.createTaskTree(c, userID, root)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	setTaskETag(c, created[0])
+	setWIPWarnings(c, warnings)
+	return c.Status(fiber.StatusCreated).JSON(created[0])
+}
+
+// createTaskTree creates the tasks of a draft tree, parents before their
+// subtasks, and returns them in that order. Every task goes through the
+// checks of CreateTask: project access, the initial workflow status and WIP
+// limits, counting the tasks of the tree together. Nothing is stored unless
+// all of them pass.
+func (h *TaskHandler) createTaskTree(c *fiber.Ctx, userID primitive.ObjectID, root *taskDraft) ([]*models.Task, []string, *fiber.Error) {
+	var tasks []*models.Task
+	var walk func(draft *taskDraft, parentID *primitive.ObjectID)
+	walk = func(draft *taskDraft, parentID *primitive.ObjectID) {
+		// IDs are chosen up front so subtasks and WIP violations can refer
+		// to their tasks.
+		draft.task.ID = primitive.NewObjectID()
+		if parentID != nil {
+			draft.task.ParentID = parentID
+		}
+		tasks = append(tasks, draft.task)
+		for _, child := range draft.children {
+			walk(child, &draft.task.ID)
+		}
+	}
+	walk(root, nil)
+
+	tally := make(wipTally)
+	var warnings []string
+	for _, task := range tasks {
+		if task.ProjectID != nil {
+			if task.CustomFields == nil {
+				values, ferr := h.customFieldValues(c.Context(), task.ProjectID, nil, true)
+				if ferr != nil {
+					return nil, nil, ferr
+				}
+				task.CustomFields = values
+			}
+			key, ferr := h.allocateTaskKey(c, *task.ProjectID, userID)
+			if ferr != nil {
+				return nil, nil, ferr
+			}
+			task.Key = key
+		}
+		if ferr := h.startWorkflow(c.Context(), task); ferr != nil {
+			return nil, nil, ferr
+		}
+		found, ferr := h.checkWIP(c.Context(), userID, nil, task, tally)
+		if ferr != nil {
+			return nil, nil, ferr
+		}
+		warnings = append(warnings, found...)
+	}
+
+	for _, task := range tasks {
+		if err := h.createTask(c, task); err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "failed to create task")
+		}
+		h.broadcastTask(c, task, websocket.Message{
+			Type:    "task_created",
+			Payload: task,
+		})
+	}
+	return tasks, warnings, nil
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// customFieldOps maps the comparisons of filter conditions to the bounds of
+// a CustomFieldMatch.
+var customFieldOps = map[string]string{
+	"<":  "$lt",
+	"<=": "$lte",
+	">":  "$gt",
+	">=": "$gte",
+}
+
+// resolveCustomFields turns the custom field conditions of filter, and the
+// custom field sort of listing if there is one, into the field IDs and
+// stored values the stores work with. Custom fields belong to a project, so
+// both need the filter to name one. listing may be nil.
+func resolveCustomFields(ctx context.Context, filter *models.TaskFilter, listing *taskListing, userID primitive.ObjectID, projects repository.ProjectStore) *fiber.Error {
+	sortKey, customSort := "", false
+	if listing != nil {
+		sortKey, customSort = customSortKey(listing.page)
+	}
+	if len(filter.CustomFields) == 0 && !customSort {
+		return nil
+	}
+
+	if filter.ProjectID == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "custom fields can only be used together with a project filter")
+	}
+	project, err := projects.FindByID(ctx, *filter.ProjectID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil || !project.CanView(userID) {
+		return fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+
+	filter.CustomFieldMatches = nil
+	matches := make(map[primitive.ObjectID]*models.CustomFieldMatch)
+	var order []primitive.ObjectID
+	for _, condition := range filter.CustomFields {
+		field := project.FindCustomField(condition.Field, true)
+		if field == nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown custom field %q", condition.Field))
+		}
+		bound, compared := customFieldOps[condition.Op]
+		switch {
+		case condition.Op != "" && !compared:
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid comparison %q", condition.Op))
+		case compared && !field.Comparable():
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s cannot be compared", field.Key))
+		case compared && len(condition.Values) != 1:
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s takes a single value when compared", field.Key))
+		case len(condition.Values) == 0:
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("missing value for %s", field.Key))
+		}
+
+		match, ok := matches[field.ID]
+		if !ok {
+			match = &models.CustomFieldMatch{FieldID: field.ID.Hex()}
+			matches[field.ID] = match
+			order = append(order, field.ID)
+		}
+		for _, raw := range condition.Values {
+			value, ferr := customFilterValue(field, raw, userID)
+			if ferr != nil {
+				return ferr
+			}
+			switch {
+			case compared:
+				if match.Bounds == nil {
+					match.Bounds = make(map[string]interface{})
+				}
+				match.Bounds[bound] = value
+			case condition.Negated:
+				match.Exclude = append(match.Exclude, value)
+			default:
+				match.Include = append(match.Include, value)
+			}
+		}
+	}
+	for _, id := range order {
+		filter.CustomFieldMatches = append(filter.CustomFieldMatches, *matches[id])
+	}
+
+	if !customSort {
+		return nil
+	}
+	field := project.FindCustomField(sortKey, true)
+	if field == nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown custom field %q", sortKey))
+	}
+	if !field.Sortable() {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("tasks cannot be sorted by %s", field.Key))
+	}
+	listing.page.Sort = models.CustomFieldSort(field.ID)
+	if listing.cursor != "" {
+		after, err := decodeTaskCursor(listing.cursor, listing.page, field)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		listing.page.After = after
+	}
+	return nil
+}
+
+// customFilterValue parses one filter value for field. User fields accept
+// "me".
+func customFilterValue(field *models.CustomField, raw string, userID primitive.ObjectID) (interface{}, *fiber.Error) {
+	if field.Type == models.CustomFieldUser {
+		id, ok := userRef(raw, userID)
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s must be me or a user id", field.Key))
+		}
+		return id, nil
+	}
+	value, err := field.ParseFilterValue(raw)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return value, nil
+}
+
+// customFieldValues checks the custom field values of a task being created
+// or updated in the project. raw names fields by key or ID; the result is
+// keyed by field ID, with nil for values being removed. New tasks must have
+// a value for every required field, and updates cannot remove one.
+func (h *TaskHandler) customFieldValues(ctx context.Context, projectID *primitive.ObjectID, raw map[string]interface{}, creating bool) (map[string]interface{}, *fiber.Error) {
+	if projectID == nil {
+		if len(raw) > 0 {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "custom fields can only be set on tasks in a project")
+		}
+		return nil, nil
+	}
+	if len(raw) == 0 && !creating {
+		return nil, nil
+	}
+
+	project, err := h.projectRepo.FindByID(ctx, *projectID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+
+	values := make(map[string]interface{}, len(raw))
+	for ref, value := range raw {
+		field := project.FindCustomField(ref, false)
+		if field == nil {
+			if project.FindCustomField(ref, true) != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("custom field %q is archived", ref))
+			}
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown custom field %q", ref))
+		}
+		if value == nil {
+			if field.Required {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s is required", field.Key))
+			}
+			if !creating {
+				values[field.ID.Hex()] = nil
+			}
+			continue
+		}
+		normalized, err := field.NormalizeValue(value)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if id, ok := normalized.(primitive.ObjectID); ok && !project.CanView(id) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s must be a member of the project", field.Key))
+		}
+		values[field.ID.Hex()] = normalized
+	}
+
+	if creating {
+		for _, field := range project.CustomFields {
+			if field.Required && !field.Archived && values[field.ID.Hex()] == nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s is required", field.Key))
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AddDependencyRequest struct {
+	// BlockedBy is the ID or key of the task that must be finished first.
+	BlockedBy string `json:"blocked_by"`
+}
+
+// GetTaskDependencies lists the tasks blocking a task and the tasks it blocks,
+// leaving out those the caller cannot view.
+func (h *TaskHandler) GetTaskDependencies(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var blockers []*models.Task
+	for _, blockerID := range task.BlockedBy {
+		blocker, err := h.taskRepo.FindByID(c.Context(), blockerID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch dependencies",
+			})
+		}
+		if blocker != nil {
+			blockers = append(blockers, blocker)
+		}
+	}
+	blockedBy, err := h.access.summaries(c.Context(), userID, blockers)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch dependencies",
+		})
+	}
+
+	filter := models.TaskFilter{BlockedBy: &task.ID}
+	if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch dependencies",
+		})
+	}
+	dependents, err := h.taskRepo.Find(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch dependencies",
+		})
+	}
+	blocks := []models.TaskSummary{}
+	for _, dependent := range dependents {
+		blocks = append(blocks, dependent.Summary())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"blocked_by": blockedBy,
+		"blocks":     blocks,
+	})
+}
+
+// AddTaskDependency records that another task blocks this one. Edges that
+// would close a cycle in the dependency graph are refused, listing the tasks
+// on the cycle that the caller can view.
+func (h *TaskHandler) AddTaskDependency(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req AddDependencyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.BlockedBy == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "blocked_by is required",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	blocker, ferr := h.access.load(c.Context(), req.BlockedBy, userID, false)
+	if ferr != nil {
+		if ferr.Code == fiber.StatusNotFound {
+			ferr = fiber.NewError(fiber.StatusNotFound, "blocking task not found")
+		}
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if blocker.ID == task.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "a task cannot block itself",
+		})
+	}
+	for _, existing := range task.BlockedBy {
+		if existing == blocker.ID {
+			return c.Status(fiber.StatusOK).JSON(task)
+		}
+	}
+
+	cycle, err := h.dependencyPath(c.Context(), blocker, task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to check dependencies",
+		})
+	}
+	if cycle != nil {
+		path, err := h.access.summaries(c.Context(), userID, append([]*models.Task{task}, cycle...))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to check dependencies",
+			})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "dependency would create a cycle",
+			"cycle": path,
+		})
+	}
+
+	blockedBy := append(append([]primitive.ObjectID{}, task.BlockedBy...), blocker.ID)
+	return h.updateDependencies(c, task, blockedBy)
+}
+
+// RemoveTaskDependency deletes a blocked-by edge.
+func (h *TaskHandler) RemoveTaskDependency(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	blockerID, err := primitive.ObjectIDFromHex(c.Params("blockerId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid blocking task id",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	blockedBy := []primitive.ObjectID{}
+	for _, id := range task.BlockedBy {
+		if id != blockerID {
+			blockedBy = append(blockedBy, id)
+		}
+	}
+
+	return h.updateDependencies(c, task, blockedBy)
+}
+
+// GetCriticalPath returns the longest chain of unfinished tasks that must be
+// completed, one after another, before this task can be. When several chains
+// are equally long, the one whose first task is due soonest wins. Tasks the
+// caller cannot view are left out of the path but still count towards its
+// length.
+func (h *TaskHandler) GetCriticalPath(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	chain, err := h.criticalPath(c.Context(), task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to compute critical path",
+		})
+	}
+
+	path, err := h.access.summaries(c.Context(), userID, chain)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to compute critical path",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"path":   path,
+		"length": len(chain) - 1,
+	})
+}
+
+func (h *TaskHandler) updateDependencies(c *fiber.Ctx, task *models.Task, blockedBy []primitive.ObjectID) error {
+	updated, err := h.updateTask(c, task, &models.TaskUpdate{BlockedBy: &blockedBy})
+	if err != nil {
+		return h.respondUpdateFailed(c, task.ID, err)
+	}
+
+	h.broadcastTask(c, updated, websocket.Message{
+		Type:    "task_updated",
+		Payload: updated,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// unfinishedBlockers returns the tasks blocking task that are not done.
+func (h *TaskHandler) unfinishedBlockers(ctx context.Context, task *models.Task) ([]*models.Task, error) {
+	var blockers []*models.Task
+	for _, blockerID := range task.BlockedBy {
+		blocker, err := h.taskRepo.FindByID(ctx, blockerID)
+		if err != nil {
+			return nil, err
+		}
+		if blocker != nil && !blocker.Done() {
+			blockers = append(blockers, blocker)
+		}
+	}
+	return blockers, nil
+}
+
+// dependencyPath searches the blocked-by graph upwards from start and
+// returns the chain of tasks from start to targetID, or nil if start does not
+// (transitively) depend on targetID.
+func (h *TaskHandler) dependencyPath(ctx context.Context, start *models.Task, targetID primitive.ObjectID) ([]*models.Task, error) {
+	visited := make(map[primitive.ObjectID]bool)
+
+	var walk func(task *models.Task) ([]*models.Task, error)
+	walk = func(task *models.Task) ([]*models.Task, error) {
+		if task.ID == targetID {
+			return []*models.Task{task}, nil
+		}
+		if visited[task.ID] {
+			return nil, nil
+		}
+		visited[task.ID] = true
+
+		for _, blockerID := range task.BlockedBy {
+			blocker, err := h.taskRepo.FindByID(ctx, blockerID)
+			if err != nil {
+				return nil, err
+			}
+			if blocker == nil {
+				continue
+			}
+			path, err := walk(blocker)
+			if err != nil {
+				return nil, err
+			}
+			if path != nil {
+				return append([]*models.Task{task}, path...), nil
+			}
+		}
+		return nil, nil
+	}
+
+	return walk(start)
+}
+
+// criticalPath returns the longest chain of unfinished blockers ending in
+// task, ordered from the first task to work on to task itself.
+func (h *TaskHandler) criticalPath(ctx context.Context, task *models.Task) ([]*models.Task, error) {
+	memo := make(map[primitive.ObjectID][]*models.Task)
+	onPath := make(map[primitive.ObjectID]bool)
+
+	var longest func(task *models.Task) ([]*models.Task, error)
+	longest = func(task *models.Task) ([]*models.Task, error) {
+		if chain, ok := memo[task.ID]; ok {
+			return chain, nil
+		}
+		onPath[task.ID] = true
+		defer delete(onPath, task.ID)
+
+		var best []*models.Task
+		for _, blockerID := range task.BlockedBy {
+			if onPath[blockerID] {
+				continue
+			}
+			blocker, err := h.taskRepo.FindByID(ctx, blockerID)
+			if err != nil {
+				return nil, err
+			}
+			if blocker == nil || blocker.Done() {
+				continue
+			}
+			chain, err := longest(blocker)
+			if err != nil {
+				return nil, err
+			}
+			if longerChain(chain, best) {
+				best = chain
+			}
+		}
+
+		chain := append(append([]*models.Task{}, best...), task)
+		memo[task.ID] = chain
+		return chain, nil
+	}
+
+	return longest(task)
+}
+
+// longerChain reports whether chain a should be preferred over b: it has
+// more tasks, or as many tasks and a first task that is due sooner.
+func longerChain(a, b []*models.Task) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	if len(a) == 0 {
+		return false
+	}
+	first, other := a[0].DueDate, b[0].DueDate
+	if first.IsZero() {
+		return false
+	}
+	return other.IsZero() || first.Before(other)
+}
+
+// removeDependencyEdges drops a deleted task from the blocked-by lists of the
+// tasks it was blocking.
+func (h *TaskHandler) removeDependencyEdges(ctx context.Context, actorID, taskID primitive.ObjectID) error {
+	dependents, err := h.taskRepo.Find(ctx, models.TaskFilter{BlockedBy: &taskID})
+	if err != nil {
+		return err
+	}
+
+	for _, dependent := range dependents {
+		blockedBy := []primitive.ObjectID{}
+		for _, id := range dependent.BlockedBy {
+			if id != taskID {
+				blockedBy = append(blockedBy, id)
+			}
+		}
+		if _, err := h.updateTaskAs(ctx, actorID, dependent, &models.TaskUpdate{BlockedBy: &blockedBy}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/taskquery"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// dueResolution is the precision due dates are stored with. Inclusive bounds
+// are turned into the exclusive bounds of TaskFilter by moving them this far.
+const dueResolution = time.Millisecond
+
+// filterParams maps the filter query parameters onto query language terms,
+// so both are interpreted the same way. Parameters listing several values
+// separate them with commas.
+var filterParams = []struct {
+	param string
+	field string
+	op    string
+}{
+	{"status", taskquery.FieldStatus, ""},
+	{"category", taskquery.FieldCategory, ""},
+	{"priority", taskquery.FieldPriority, ""},
+	{"tags", taskquery.FieldTag, ""},
+	{"assigned_to", taskquery.FieldAssignee, ""},
+	{"created_by", taskquery.FieldCreator, ""},
+	{"due_before", taskquery.FieldDue, "<"},
+	{"due_after", taskquery.FieldDue, ">"},
+	{"project_id", taskquery.FieldProject, ""},
+	{"parent_id", taskquery.FieldParent, ""},
+	{"blocked_by", taskquery.FieldBlockedBy, ""},
+	{"series_id", taskquery.FieldSeries, ""},
+}
+
+// parseTaskFilter reads the task filter shared by every task listing
+// endpoint: one query parameter per filter field, plus q= in the syntax of
+// package taskquery. All of them must match. "me" stands for userID wherever
+// a user is expected.
+func parseTaskFilter(c *fiber.Ctx, userID primitive.ObjectID, projects repository.ProjectStore) (models.TaskFilter, *fiber.Error) {
+	return parseTaskFilterQuery(c, "q", userID, projects)
+}
+
+// parseTaskFilterQuery is parseTaskFilter with the query language read from
+// queryParam, for endpoints that use q= for something else.
+func parseTaskFilterQuery(c *fiber.Ctx, queryParam string, userID primitive.ObjectID, projects repository.ProjectStore) (models.TaskFilter, *fiber.Error) {
+	var filter models.TaskFilter
+
+	for _, p := range filterParams {
+		raw := c.Query(p.param)
+		if raw == "" {
+			continue
+		}
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		term, err := taskquery.NewTerm(p.field, p.op, values, false)
+		if err == nil {
+			err = applyFilterTerm(c.Context(), &filter, term, userID, projects)
+		}
+		if err != nil {
+			return filter, filterError(p.param, err, false)
+		}
+	}
+
+	// Custom fields are filtered with cf.<key>=value parameters.
+	var customErr *fiber.Error
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		param := strings.ToLower(string(key))
+		if customErr != nil || !strings.HasPrefix(param, customFieldPrefix) {
+			return
+		}
+		var values []string
+		for _, v := range strings.Split(string(value), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		term, err := taskquery.NewTerm(param, "", values, false)
+		if err == nil {
+			err = applyFilterTerm(c.Context(), &filter, term, userID, projects)
+		}
+		if err != nil {
+			customErr = filterError(param, err, false)
+		}
+	})
+	if customErr != nil {
+		return filter, customErr
+	}
+
+	if q := c.Query(queryParam); q != "" {
+		terms, err := taskquery.Parse(q)
+		if err != nil {
+			return filter, filterError(queryParam, err, true)
+		}
+		for _, term := range terms {
+			if err := applyFilterTerm(c.Context(), &filter, term, userID, projects); err != nil {
+				var qerr *taskquery.Error
+				if errors.As(err, &qerr) {
+					qerr.Pos = term.Pos
+				}
+				return filter, filterError(queryParam, err, true)
+			}
+		}
+	}
+
+	if err := checkFilterStatuses(c.Context(), &filter, userID, projects); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// checkFilterStatuses rejects statuses that no workflow the filter can match
+// has: the filtered project's, or else those of the caller's projects and
+// the default workflow. It runs once all terms are applied, since the
+// project may be named after the statuses.
+func checkFilterStatuses(ctx context.Context, filter *models.TaskFilter, userID primitive.ObjectID, projects repository.ProjectStore) *fiber.Error {
+	if len(filter.Status) == 0 && len(filter.ExcludeStatus) == 0 {
+		return nil
+	}
+
+	workflows := []*models.Workflow{models.DefaultWorkflow()}
+	var project *models.Project
+	if filter.ProjectID != nil {
+		var err error
+		if project, err = projects.FindByID(ctx, *filter.ProjectID); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch tasks")
+		}
+	}
+	if project != nil && project.CanView(userID) {
+		workflows = []*models.Workflow{project.TaskWorkflow()}
+	} else {
+		member, err := projects.FindByMember(ctx, userID, true)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch tasks")
+		}
+		for _, p := range member {
+			workflows = append(workflows, p.TaskWorkflow())
+		}
+	}
+
+	for _, statuses := range [][]models.TaskStatus{filter.Status, filter.ExcludeStatus} {
+		for _, status := range statuses {
+			known := false
+			for _, workflow := range workflows {
+				if workflow.Status(status) != nil {
+					known = true
+					break
+				}
+			}
+			if !known {
+				return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown status %q", status))
+			}
+		}
+	}
+	return nil
+}
+
+// validateTaskFilter checks a TaskFilter sent as JSON, as saved views and
+// bulk operations take it, and clears the fields clients may not set.
+func validateTaskFilter(filter *models.TaskFilter) *fiber.Error {
+	for _, statuses := range [][]models.TaskStatus{filter.Status, filter.ExcludeStatus} {
+		for _, status := range statuses {
+			if !models.ValidTaskStatus(status) {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid status: "+string(status))
+			}
+		}
+	}
+	for _, categories := range [][]models.StatusCategory{filter.Category, filter.ExcludeCategory} {
+		for _, category := range categories {
+			if !models.ValidStatusCategory(category) {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid category: "+string(category))
+			}
+		}
+	}
+	for _, priorities := range [][]models.TaskPriority{filter.Priority, filter.ExcludePriority} {
+		for _, priority := range priorities {
+			if !models.ValidTaskPriority(priority) {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid priority: "+string(priority))
+			}
+		}
+	}
+	for _, condition := range filter.CustomFields {
+		if !models.ValidCustomFieldKey(condition.Field) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid custom field %q", condition.Field))
+		}
+		if _, ok := customFieldOps[condition.Op]; condition.Op != "" && !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid comparison %q", condition.Op))
+		}
+		if len(condition.Values) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "missing value for "+condition.Field)
+		}
+	}
+	filter.VisibleTo, filter.VisibleProjects = nil, nil
+	filter.CustomFieldMatches = nil
+	return nil
+}
+
+// filterError reports a bad filter value as a 400 naming the parameter, and
+// the position of the problem for query language parameters. Store failures
+// are reported as 500s.
+func filterError(param string, err error, positioned bool) *fiber.Error {
+	var qerr *taskquery.Error
+	if !errors.As(err, &qerr) {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch tasks")
+	}
+	if !positioned {
+		return fiber.NewError(fiber.StatusBadRequest, param+": "+qerr.Msg)
+	}
+	return fiber.NewError(fiber.StatusBadRequest, param+": "+qerr.Error())
+}
+
+// applyFilterTerm narrows filter by one term. Terms on a field that lists
+// values add to the values already there, so repeating a field widens it.
+// Fields that take a single value may only be given once.
+func applyFilterTerm(ctx context.Context, filter *models.TaskFilter, term taskquery.Term, userID primitive.ObjectID, projects repository.ProjectStore) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &taskquery.Error{Msg: fmt.Sprintf(format, args...)}
+	}
+	repeated := invalid("%s given more than once", term.Field)
+
+	switch term.Field {
+	case taskquery.FieldStatus:
+		for _, value := range term.Values {
+			status := models.TaskStatus(value)
+			if !models.ValidTaskStatus(status) {
+				return invalid("invalid status %q", value)
+			}
+			if term.Negated {
+				filter.ExcludeStatus = append(filter.ExcludeStatus, status)
+			} else {
+				filter.Status = append(filter.Status, status)
+			}
+		}
+
+	case taskquery.FieldCategory:
+		for _, value := range term.Values {
+			category := models.StatusCategory(value)
+			if !models.ValidStatusCategory(category) {
+				return invalid("invalid category %q", value)
+			}
+			if term.Negated {
+				filter.ExcludeCategory = append(filter.ExcludeCategory, category)
+			} else {
+				filter.Category = append(filter.Category, category)
+			}
+		}
+
+	case taskquery.FieldPriority:
+		for _, value := range term.Values {
+			priority := models.TaskPriority(value)
+			if !models.ValidTaskPriority(priority) {
+				return invalid("invalid priority %q", value)
+			}
+			if term.Negated {
+				filter.ExcludePriority = append(filter.ExcludePriority, priority)
+			} else {
+				filter.Priority = append(filter.Priority, priority)
+			}
+		}
+
+	case taskquery.FieldTag:
+		if term.Negated {
+			filter.ExcludeTags = append(filter.ExcludeTags, term.Values...)
+		} else {
+			filter.Tags = append(filter.Tags, term.Values...)
+		}
+
+	case taskquery.FieldDue:
+		if err := applyDueTerm(filter, term.Op, term.Values[0]); err != nil {
+			return invalid("%s", err.Error())
+		}
+
+	case taskquery.FieldAssignee:
+		if filter.AssignedTo != nil || filter.Unassigned {
+			return repeated
+		}
+		if strings.EqualFold(term.Values[0], "none") {
+			filter.Unassigned = true
+			break
+		}
+		id, ok := userRef(term.Values[0], userID)
+		if !ok {
+			return invalid("assignee must be me, none or a user id")
+		}
+		filter.AssignedTo = &id
+
+	case taskquery.FieldCreator:
+		if filter.CreatedBy != nil {
+			return repeated
+		}
+		id, ok := userRef(term.Values[0], userID)
+		if !ok {
+			return invalid("creator must be me or a user id")
+		}
+		filter.CreatedBy = &id
+
+	case taskquery.FieldProject:
+		if filter.ProjectID != nil {
+			return repeated
+		}
+		value := term.Values[0]
+		if id, err := primitive.ObjectIDFromHex(value); err == nil {
+			filter.ProjectID = &id
+			break
+		}
+		project, err := projects.FindByKey(ctx, strings.ToUpper(value))
+		if err != nil {
+			return err
+		}
+		// Projects the caller is not a member of are not revealed.
+		if project == nil || !project.CanView(userID) {
+			return invalid("unknown project %q", value)
+		}
+		filter.ProjectID = &project.ID
+
+	case taskquery.FieldParent:
+		for _, value := range term.Values {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return invalid("invalid parent id %q", value)
+			}
+			filter.ParentIDs = append(filter.ParentIDs, id)
+		}
+
+	case taskquery.FieldBlockedBy:
+		if filter.BlockedBy != nil {
+			return repeated
+		}
+		id, err := primitive.ObjectIDFromHex(term.Values[0])
+		if err != nil {
+			return invalid("invalid task id %q", term.Values[0])
+		}
+		filter.BlockedBy = &id
+
+	case taskquery.FieldSeries:
+		if filter.SeriesID != nil {
+			return repeated
+		}
+		id, err := primitive.ObjectIDFromHex(term.Values[0])
+		if err != nil {
+			return invalid("invalid series id %q", term.Values[0])
+		}
+		filter.SeriesID = &id
+
+	case taskquery.FieldCustom:
+		// Values are checked against the field's type once the project is
+		// known; see resolveCustomFields.
+		filter.CustomFields = append(filter.CustomFields, models.CustomFieldCondition{
+			Field:   term.Custom,
+			Op:      term.Op,
+			Values:  term.Values,
+			Negated: term.Negated,
+		})
+	}
+	return nil
+}
+
+// applyDueTerm sets the due date bounds for a comparison against value, an
+// RFC 3339 timestamp or a date. A date stands for the whole UTC day, so
+// "<=2026-11-01" includes tasks due at any time on the 1st and a bare date
+// matches that day.
+func applyDueTerm(filter *models.TaskFilter, op, value string) error {
+	start, err := time.Parse(time.RFC3339, value)
+	end := start.Add(dueResolution)
+	if err != nil {
+		start, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return fmt.Errorf("invalid due date %q, want YYYY-MM-DD or an RFC 3339 timestamp", value)
+		}
+		end = start.AddDate(0, 0, 1)
+	}
+
+	switch op {
+	case "<":
+		filter.DueBefore = &start
+	case "<=":
+		filter.DueBefore = &end
+	case ">":
+		after := end.Add(-dueResolution)
+		filter.DueAfter = &after
+	case ">=":
+		after := start.Add(-dueResolution)
+		filter.DueAfter = &after
+	default:
+		after := start.Add(-dueResolution)
+		filter.DueAfter, filter.DueBefore = &after, &end
+	}
+	return nil
+}
+
+// userRef resolves "me" or a user ID.
+func userRef(value string, userID primitive.ObjectID) (primitive.ObjectID, bool) {
+	if strings.EqualFold(value, "me") {
+		return userID, true
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	return id, err == nil
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyDueTerm(t *testing.T) {
+	at := func(t *testing.T, value string) time.Time {
+		t.Helper()
+		due, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return due
+	}
+	// Both bounds of TaskFilter are exclusive.
+	matches := func(filter models.TaskFilter, due time.Time) bool {
+		if filter.DueAfter != nil && !due.After(*filter.DueAfter) {
+			return false
+		}
+		if filter.DueBefore != nil && !due.Before(*filter.DueBefore) {
+			return false
+		}
+		return true
+	}
+
+	tests := []struct {
+		name     string
+		op       string
+		value    string
+		included []string
+		excluded []string
+	}{
+		{
+			name:     "before a day",
+			op:       "<",
+			value:    "2026-11-01",
+			included: []string{"2026-10-31T23:59:59.999Z"},
+			excluded: []string{"2026-11-01T00:00:00Z", "2026-11-01T12:00:00Z"},
+		},
+		{
+			name:     "up to and including a day",
+			op:       "<=",
+			value:    "2026-11-01",
+			included: []string{"2026-11-01T00:00:00Z", "2026-11-01T23:59:59.999Z"},
+			excluded: []string{"2026-11-02T00:00:00Z"},
+		},
+		{
+			name:     "after a day",
+			op:       ">",
+			value:    "2026-11-01",
+			included: []string{"2026-11-02T00:00:00Z"},
+			excluded: []string{"2026-11-01T00:00:00Z", "2026-11-01T23:59:59.999Z"},
+		},
+		{
+			name:     "from a day on",
+			op:       ">=",
+			value:    "2026-11-01",
+			included: []string{"2026-11-01T00:00:00Z", "2026-11-05T00:00:00Z"},
+			excluded: []string{"2026-10-31T23:59:59.999Z"},
+		},
+		{
+			name:     "on a day",
+			value:    "2026-11-01",
+			included: []string{"2026-11-01T00:00:00Z", "2026-11-01T23:59:59.999Z"},
+			excluded: []string{"2026-10-31T23:59:59.999Z", "2026-11-02T00:00:00Z"},
+		},
+		{
+			name:     "before a time",
+			op:       "<",
+			value:    "2026-11-01T12:00:00Z",
+			included: []string{"2026-11-01T11:59:59.999Z"},
+			excluded: []string{"2026-11-01T12:00:00Z"},
+		},
+		{
+			name:     "up to and including a time",
+			op:       "<=",
+			value:    "2026-11-01T12:00:00Z",
+			included: []string{"2026-11-01T12:00:00Z"},
+			excluded: []string{"2026-11-01T12:00:00.001Z"},
+		},
+		{
+			name:     "after a time",
+			op:       ">",
+			value:    "2026-11-01T12:00:00Z",
+			included: []string{"2026-11-01T12:00:00.001Z"},
+			excluded: []string{"2026-11-01T12:00:00Z"},
+		},
+		{
+			name:     "from a time on",
+			op:       ">=",
+			value:    "2026-11-01T12:00:00Z",
+			included: []string{"2026-11-01T12:00:00Z"},
+			excluded: []string{"2026-11-01T11:59:59.999Z"},
+		},
+		{
+			name:     "at a time with an offset",
+			value:    "2026-11-01T12:00:00+02:00",
+			included: []string{"2026-11-01T10:00:00Z"},
+			excluded: []string{"2026-11-01T09:59:59.999Z", "2026-11-01T10:00:00.001Z"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var filter models.TaskFilter
+			if err := applyDueTerm(&filter, tc.op, tc.value); err != nil {
+				t.Fatalf("applyDueTerm(%q, %q): %v", tc.op, tc.value, err)
+			}
+			for _, due := range tc.included {
+				if !matches(filter, at(t, due)) {
+					t.Errorf("due %s not matched by due:%s%s", due, tc.op, tc.value)
+				}
+			}
+			for _, due := range tc.excluded {
+				if matches(filter, at(t, due)) {
+					t.Errorf("due %s matched by due:%s%s", due, tc.op, tc.value)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDueTermRejectsInvalidDates(t *testing.T) {
+	for _, value := range []string{"tomorrow", "2026-13-01", "01/11/2026", "2026-11-01T12:00"} {
+		var filter models.TaskFilter
+		if err := applyDueTerm(&filter, "<", value); err == nil {
+			t.Errorf("applyDueTerm(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseTaskFilterStatuses(t *testing.T) {
+	projects := repository.NewMemoryProjectRepository()
+	caller, other := primitive.NewObjectID(), primitive.NewObjectID()
+	review := &models.Workflow{
+		Initial: "open",
+		Statuses: []models.WorkflowStatus{
+			{Key: "open", Name: "Open", Category: models.CategoryTodo},
+			{Key: "review", Name: "Review", Category: models.CategoryActive},
+		},
+	}
+	qa := &models.Workflow{
+		Initial:  "qa",
+		Statuses: []models.WorkflowStatus{{Key: "qa", Name: "QA", Category: models.CategoryActive}},
+	}
+	for _, project := range []*models.Project{
+		{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{{UserID: caller, Role: models.ProjectRoleMember}}, Workflow: review},
+		{Name: "Web", Key: "WEB", Members: []models.ProjectMember{{UserID: other, Role: models.ProjectRoleOwner}}, Workflow: qa},
+	} {
+		if err := projects.Create(context.Background(), project); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := filterApp(projects)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"status=todo", fiber.StatusOK},
+		{"status=review", fiber.StatusOK},
+		{"q=-status:open", fiber.StatusOK},
+		{"status=bogus", fiber.StatusBadRequest},
+		{"q=status:bogus", fiber.StatusBadRequest},
+		{"status=todo,bogus", fiber.StatusBadRequest},
+		// Only the caller's projects contribute statuses.
+		{"status=qa", fiber.StatusBadRequest},
+		// A project narrows the statuses to its workflow.
+		{"q=status:review project:OPS", fiber.StatusOK},
+		{"q=status:todo project:OPS", fiber.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		query, err := url.ParseQuery(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code, body := send(t, app, caller, "GET", "/tasks?"+query.Encode(), nil); code != tc.want {
+			t.Errorf("%s = %d %s, want %d", tc.query, code, body, tc.want)
+		}
+	}
+}
+
+func TestParseTaskFilterSingleValues(t *testing.T) {
+	projects := repository.NewMemoryProjectRepository()
+	caller, other := primitive.NewObjectID(), primitive.NewObjectID()
+	for _, project := range []*models.Project{
+		{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{{UserID: caller, Role: models.ProjectRoleViewer}}},
+		{Name: "Web", Key: "WEB", Members: []models.ProjectMember{{UserID: other, Role: models.ProjectRoleOwner}}},
+	} {
+		if err := projects.Create(context.Background(), project); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := filterApp(projects)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"assigned_to=me&q=creator:" + other.Hex(), fiber.StatusOK},
+		{"assigned_to=me&q=assignee:" + other.Hex(), fiber.StatusBadRequest},
+		{"assigned_to=none&q=assignee:me", fiber.StatusBadRequest},
+		{"created_by=me&q=creator:me", fiber.StatusBadRequest},
+		{"q=project:OPS project:OPS", fiber.StatusBadRequest},
+		{"q=project:ops", fiber.StatusOK},
+		// Projects the caller is not in read as unknown.
+		{"q=project:WEB", fiber.StatusBadRequest},
+		{"q=project:NOPE", fiber.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		query, err := url.ParseQuery(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, body := send(t, app, caller, "GET", "/tasks?"+query.Encode(), nil)
+		if code != tc.want {
+			t.Errorf("%s = %d %s, want %d", tc.query, code, body, tc.want)
+		}
+		if strings.Contains(tc.query, "WEB") && !strings.Contains(body, "unknown project") {
+			t.Errorf("%s = %s, want the project reported unknown", tc.query, body)
+		}
+	}
+}
+
+// filterApp returns an app that parses the task filter of GET /tasks and
+// answers with the error, if any.
+func filterApp(projects repository.ProjectStore) *fiber.App {
+	app := testApp()
+	app.Get("/tasks", func(c *fiber.Ctx) error {
+		userID, _ := currentUserID(c)
+		if _, ferr := parseTaskFilter(c, userID, projects); ferr != nil {
+			return c.Status(ferr.Code).SendString(ferr.Message)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
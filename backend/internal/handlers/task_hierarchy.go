@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskNode is a task together with its subtasks, as returned by the tree
+// endpoint.
+type TaskNode struct {
+	*models.Task
+	Children []*TaskNode `json:"children"`
+}
+
+// GetTaskChildren lists the direct subtasks of a task, each with its own
+// rollup.
+func (h *TaskHandler) GetTaskChildren(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	tree, err := h.visibleTree(c.Context(), task, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch subtasks",
+		})
+	}
+
+	children := make([]*models.Task, 0, len(tree.Children))
+	for _, child := range tree.Children {
+		children = append(children, child.Task)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(children)
+}
+
+// GetTaskTree returns a task with all of its descendants nested below it.
+func (h *TaskHandler) GetTaskTree(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	tree, err := h.visibleTree(c.Context(), task, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch subtasks",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tree)
+}
+
+// attachRollup sets task.Rollup when the task has subtasks the user can see.
+func (h *TaskHandler) attachRollup(ctx context.Context, task *models.Task, userID primitive.ObjectID) error {
+	_, err := h.visibleTree(ctx, task, userID)
+	return err
+}
+
+// visibleTree builds the subtree below root from the descendants userID can
+// see, filling in the rollup of every node that has subtasks.
+func (h *TaskHandler) visibleTree(ctx context.Context, root *models.Task, userID primitive.ObjectID) (*TaskNode, error) {
+	var base models.TaskFilter
+	if err := h.access.restrictToVisible(ctx, userID, &base); err != nil {
+		return nil, err
+	}
+	descendants, err := h.descendants(ctx, root.ID, base)
+	if err != nil {
+		return nil, err
+	}
+
+	byParent := make(map[primitive.ObjectID][]*models.Task)
+	for _, task := range descendants {
+		byParent[*task.ParentID] = append(byParent[*task.ParentID], task)
+	}
+
+	var build func(task *models.Task) *TaskNode
+	build = func(task *models.Task) *TaskNode {
+		node := &TaskNode{Task: task, Children: []*TaskNode{}}
+		children := byParent[task.ID]
+		sort.SliceStable(children, func(i, j int) bool {
+			return children[i].CreatedAt.Before(children[j].CreatedAt)
+		})
+
+		var rollup models.TaskRollup
+		for _, child := range children {
+			childNode := build(child)
+			node.Children = append(node.Children, childNode)
+
+			rollup.Total++
+			if child.Done() {
+				rollup.Completed++
+			}
+			if !child.DueDate.IsZero() {
+				rollup.EarliestDueDate = earlier(rollup.EarliestDueDate, child.DueDate)
+			}
+			if child.Rollup != nil {
+				rollup.Total += child.Rollup.Total
+				rollup.Completed += child.Rollup.Completed
+				if child.Rollup.EarliestDueDate != nil {
+					rollup.EarliestDueDate = earlier(rollup.EarliestDueDate, *child.Rollup.EarliestDueDate)
+				}
+			}
+		}
+		if rollup.Total > 0 {
+			task.Rollup = &rollup
+		}
+		return node
+	}
+
+	return build(root), nil
+}
+
+// descendants walks the hierarchy below rootID breadth first, applying base
+// to every level. Subtasks excluded by base hide their own subtrees too.
+func (h *TaskHandler) descendants(ctx context.Context, rootID primitive.ObjectID, base models.TaskFilter) ([]*models.Task, error) {
+	var all []*models.Task
+	seen := map[primitive.ObjectID]bool{rootID: true}
+	frontier := []primitive.ObjectID{rootID}
+
+	for len(frontier) > 0 {
+		filter := base
+		filter.ParentIDs = frontier
+		level, err := h.taskRepo.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, task := range level {
+			if seen[task.ID] {
+				continue
+			}
+			seen[task.ID] = true
+			all = append(all, task)
+			frontier = append(frontier, task.ID)
+		}
+	}
+
+	return all, nil
+}
+
+// validateParent checks that parentID can become the parent of task (nil
+// when the task is being created): the caller must be able to edit the
+// parent, and the new link must not close a cycle.
+func (h *TaskHandler) validateParent(ctx context.Context, task *models.Task, parentID, userID primitive.ObjectID) (*models.Task, *fiber.Error) {
+	parent, ferr := h.access.load(ctx, parentID.Hex(), userID, true)
+	if ferr != nil {
+		if ferr.Code == fiber.StatusNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "parent task not found")
+		}
+		return nil, ferr
+	}
+	if task == nil {
+		return parent, nil
+	}
+
+	if parent.ID == task.ID {
+		return nil, fiber.NewError(fiber.StatusConflict, "a task cannot be its own parent")
+	}
+
+	seen := map[primitive.ObjectID]bool{parent.ID: true}
+	ancestor := parent
+	for ancestor.ParentID != nil {
+		if *ancestor.ParentID == task.ID {
+			return nil, fiber.NewError(fiber.StatusConflict, "a task cannot be moved below one of its own subtasks")
+		}
+		if seen[*ancestor.ParentID] {
+			break
+		}
+		seen[*ancestor.ParentID] = true
+
+		next, err := h.taskRepo.FindByID(ctx, *ancestor.ParentID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch parent task")
+		}
+		if next == nil {
+			break
+		}
+		ancestor = next
+	}
+
+	return parent, nil
+}
+
+// deleteSubtasks handles the subtasks of a task that is about to be deleted.
+// mode "cascade" moves every descendant to the trash along with the task,
+// so that restoring or purging the task does the same to them; "reparent"
+// moves the direct children up to the task's own parent. Either is refused,
+// before anything changes, unless userID may edit every subtask it touches.
+// Any other mode is refused when the task has subtasks.
+func (h *TaskHandler) deleteSubtasks(c *fiber.Ctx, task *models.Task, userID primitive.ObjectID, mode string) *fiber.Error {
+	ctx := c.Context()
+	descendants, err := h.descendants(ctx, task.ID, models.TaskFilter{})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch subtasks")
+	}
+	if len(descendants) == 0 {
+		return nil
+	}
+
+	switch mode {
+	case "cascade":
+		for _, descendant := range descendants {
+			allowed, err := h.access.canEdit(ctx, descendant, userID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch subtasks")
+			}
+			if !allowed {
+				return fiber.NewError(fiber.StatusForbidden, "task has subtasks you cannot delete")
+			}
+		}
+		for _, descendant := range descendants {
+			if err := h.removeTask(c, descendant, &task.ID); err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to delete subtasks")
+			}
+		}
+		return nil
+
+	case "reparent":
+		newParent := primitive.NilObjectID
+		if task.ParentID != nil {
+			newParent = *task.ParentID
+		}
+		var children []*models.Task
+		for _, descendant := range descendants {
+			if *descendant.ParentID != task.ID {
+				continue
+			}
+			allowed, err := h.access.canEdit(ctx, descendant, userID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch subtasks")
+			}
+			if !allowed {
+				return fiber.NewError(fiber.StatusForbidden, "task has subtasks you cannot move")
+			}
+			children = append(children, descendant)
+		}
+		for _, child := range children {
+			updated, err := h.updateTask(c, child, &models.TaskUpdate{ParentID: &newParent})
+			if err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, "failed to move subtasks")
+			}
+			h.broadcastTask(c, updated, websocket.Message{
+				Type:    "task_updated",
+				Payload: updated,
+			})
+		}
+		return nil
+
+	default:
+		return fiber.NewError(fiber.StatusConflict, "task has subtasks; delete with ?children=cascade or ?children=reparent")
+	}
+}
+
+func earlier(current *time.Time, candidate time.Time) *time.Time {
+	if current == nil || candidate.Before(*current) {
+		return &candidate
+	}
+	return current
+}
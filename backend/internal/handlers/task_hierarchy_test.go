@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/storage"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteReparentChecksSubtaskAccess(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := websocket.NewHub()
+	h := NewTaskHandler(
+		repository.NewMemoryTaskRepository(),
+		repository.NewMemoryProjectRepository(),
+		repository.NewMemoryCommentRepository(),
+		repository.NewMemoryActivityRepository(),
+		repository.NewMemoryWIPViolationRepository(),
+		repository.NewMemoryWorkLogRepository(),
+		repository.NewMemoryAttachmentRepository(),
+		blobs,
+		NewNotifier(repository.NewMemoryNotificationRepository(), repository.NewMemoryUserRepository(), hub),
+		hub,
+	)
+
+	owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+	create := func(t *testing.T, task *models.Task) *models.Task {
+		t.Helper()
+		if err := h.taskRepo.Create(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	find := func(t *testing.T, id primitive.ObjectID) *models.Task {
+		t.Helper()
+		task, err := h.taskRepo.FindByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", owner)
+		return c.Next()
+	})
+	app.Delete("/tasks/:id", h.DeleteTask)
+	remove := func(t *testing.T, id primitive.ObjectID) (int, string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/tasks/"+id.Hex()+"?children=reparent", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	grandparent := create(t, &models.Task{Title: "grandparent", CreatedBy: owner})
+	parent := create(t, &models.Task{Title: "parent", CreatedBy: owner, ParentID: &grandparent.ID})
+	mine := create(t, &models.Task{Title: "mine", CreatedBy: owner, ParentID: &parent.ID})
+	theirs := create(t, &models.Task{Title: "theirs", CreatedBy: other, ParentID: &parent.ID})
+
+	code, body := remove(t, parent.ID)
+	if code != fiber.StatusForbidden || !strings.Contains(body, "subtasks you cannot move") {
+		t.Fatalf("delete = %d %s, want 403", code, body)
+	}
+	if find(t, parent.ID) == nil {
+		t.Fatal("parent was deleted")
+	}
+	for _, child := range []*models.Task{mine, theirs} {
+		if got := find(t, child.ID); got.ParentID == nil || *got.ParentID != parent.ID {
+			t.Errorf("%s moved to %v after a refused delete", child.Title, got.ParentID)
+		}
+	}
+
+	// Shared for editing, the other user's subtask can be moved too.
+	if err := h.taskRepo.Update(ctx, theirs.ID, &models.TaskUpdate{SharedWith: &[]models.TaskShare{{UserID: owner, Role: models.ShareRoleEditor}}}); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := remove(t, parent.ID); code != fiber.StatusNoContent {
+		t.Fatalf("delete = %d %s, want 204", code, body)
+	}
+	for _, child := range []*models.Task{mine, theirs} {
+		if got := find(t, child.ID); got.ParentID == nil || *got.ParentID != grandparent.ID {
+			t.Errorf("%s has parent %v, want the grandparent", child.Title, got.ParentID)
+		}
+	}
+}
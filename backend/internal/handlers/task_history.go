@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+// GetTaskHistory pages through a task's activity, newest first. Pass the
+// returned next_cursor as ?cursor= to fetch the following page.
+func (h *TaskHandler) GetTaskHistory(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	limit := defaultHistoryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxHistoryLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "limit must be between 1 and 200",
+			})
+		}
+		limit = n
+	}
+	var cursor *primitive.ObjectID
+	if raw := c.Query("cursor"); raw != "" {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid cursor",
+			})
+		}
+		cursor = &id
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	activities, err := h.activityRepo.FindByTask(c.Context(), task.ID, cursor, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch history",
+		})
+	}
+	if activities == nil {
+		activities = []*models.Activity{}
+	}
+
+	response := fiber.Map{"events": activities}
+	if len(activities) == limit {
+		response["next_cursor"] = activities[len(activities)-1].ID.Hex()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetTaskSnapshot reconstructs a task as it was at ?at= (RFC 3339) by
+// undoing, newest first, every change recorded after that time.
+func (h *TaskHandler) GetTaskSnapshot(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "at must be an RFC 3339 timestamp",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	activities, err := h.activityRepo.FindSince(c.Context(), task.ID, at)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch history",
+		})
+	}
+
+	snapshot := task
+	for _, activity := range activities {
+		if activity.Action == models.ActivityCreated {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "task did not exist at that time",
+			})
+		}
+		snapshot, err = models.RevertChanges(snapshot, activity.Changes)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to reconstruct task",
+			})
+		}
+		if activity.Version > 0 {
+			snapshot.Version = activity.Version - 1
+		}
+	}
+	snapshot.ID = task.ID
+
+	return c.Status(fiber.StatusOK).JSON(snapshot)
+}
+
+// createTask stores a new task, records its creation and notifies its
+// assignee.
+func (h *TaskHandler) createTask(c *fiber.Ctx, task *models.Task) error {
+	if err := h.taskRepo.Create(c.Context(), task); err != nil {
+		return err
+	}
+	h.recordActivity(c, models.ActivityCreated, nil, task)
+	actorID, _ := currentUserID(c)
+	h.notifier.notifyTaskChange(c.Context(), actorID, nil, task)
+	return nil
+}
+
+// updateTask applies update to task, records the fields that changed and
+// returns the task as stored afterwards. Unless the update says otherwise it
+// only applies if the task is still at the version that was loaded, and
+// fails with repository.ErrVersionConflict if not.
+func (h *TaskHandler) updateTask(c *fiber.Ctx, task *models.Task, update *models.TaskUpdate) (*models.Task, error) {
+	actorID, _ := currentUserID(c)
+	return h.updateTaskAs(c.Context(), actorID, task, update)
+}
+
+// updateTaskAs is updateTask outside of a request, on behalf of actorID. It
+// also notifies the people involved in the task of the change.
+func (h *TaskHandler) updateTaskAs(ctx context.Context, actorID primitive.ObjectID, task *models.Task, update *models.TaskUpdate) (*models.Task, error) {
+	updated, err := h.storeTaskUpdate(ctx, actorID, task, update)
+	if err != nil {
+		return nil, err
+	}
+	h.notifier.notifyTaskChange(ctx, actorID, task, updated)
+	return updated, nil
+}
+
+// storeTaskUpdate is updateTaskAs without the notifications, for changes
+// made inside a transaction, whose notifications must wait for it to commit.
+func (h *TaskHandler) storeTaskUpdate(ctx context.Context, actorID primitive.ObjectID, task *models.Task, update *models.TaskUpdate) (*models.Task, error) {
+	if update.ExpectedVersion == nil {
+		version := task.Version
+		update.ExpectedVersion = &version
+	}
+	if err := h.taskRepo.Update(ctx, task.ID, update); err != nil {
+		return nil, err
+	}
+	updated, err := h.taskRepo.FindByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("task disappeared during update")
+	}
+	h.appendActivity(ctx, actorID, models.ActivityUpdated, task, updated)
+	return updated, nil
+}
+
+// trashTask moves a task to the trash and records it. with names the task
+// whose deletion is taking this one along, if any.
+func (h *TaskHandler) trashTask(c *fiber.Ctx, task *models.Task, with *primitive.ObjectID) error {
+	actorID, _ := currentUserID(c)
+	return h.trashTaskAs(c.Context(), actorID, task, with)
+}
+
+// trashTaskAs is trashTask outside of a request, on behalf of actorID. Like
+// updates, it fails with repository.ErrVersionConflict if the task changed
+// since it was loaded.
+func (h *TaskHandler) trashTaskAs(ctx context.Context, actorID primitive.ObjectID, task *models.Task, with *primitive.ObjectID) error {
+	version := task.Version
+	deletion := models.TaskDeletion{By: actorID, With: with, ExpectedVersion: &version}
+	if err := h.taskRepo.Delete(ctx, task.ID, deletion); err != nil {
+		return err
+	}
+	trashed, err := h.findTrashed(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if trashed != nil {
+		h.appendActivity(ctx, actorID, models.ActivityTrashed, task, trashed)
+	}
+	return nil
+}
+
+// restoreTask takes a task out of the trash, records it and returns the
+// task as stored afterwards.
+func (h *TaskHandler) restoreTask(c *fiber.Ctx, task *models.Task) (*models.Task, error) {
+	if err := h.taskRepo.Restore(c.Context(), task.ID); err != nil {
+		return nil, err
+	}
+	restored, err := h.taskRepo.FindByID(c.Context(), task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if restored == nil {
+		return nil, errors.New("task disappeared during restore")
+	}
+	h.recordActivity(c, models.ActivityRestored, task, restored)
+	return restored, nil
+}
+
+// deleteTask removes a task for good and records its final state.
+func (h *TaskHandler) deleteTask(ctx context.Context, actorID primitive.ObjectID, task *models.Task) error {
+	if err := h.taskRepo.Purge(ctx, task.ID); err != nil {
+		return err
+	}
+	h.appendActivity(ctx, actorID, models.ActivityDeleted, task, nil)
+	return nil
+}
+
+// findTrashed returns the task with the given ID if it is in the trash.
+func (h *TaskHandler) findTrashed(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
+	tasks, err := h.taskRepo.Find(ctx, models.TaskFilter{IDs: []primitive.ObjectID{id}, Trashed: true})
+	if err != nil || len(tasks) == 0 {
+		return nil, err
+	}
+	return tasks[0], nil
+}
+
+// recordActivity appends the difference between two versions of a task to
+// its history, on behalf of the caller.
+func (h *TaskHandler) recordActivity(c *fiber.Ctx, action models.ActivityAction, before, after *models.Task) {
+	actorID, _ := currentUserID(c)
+	h.appendActivity(c.Context(), actorID, action, before, after)
+}
+
+// appendActivity appends the difference between two versions of a task to
+// its history. The change itself has already been stored, so a failure to
+// record it is logged rather than reported to the client.
+func (h *TaskHandler) appendActivity(ctx context.Context, actorID primitive.ObjectID, action models.ActivityAction, before, after *models.Task) {
+	taskID := primitive.NilObjectID
+	var version int64
+	if after != nil {
+		taskID, version = after.ID, after.Version
+	} else if before != nil {
+		taskID, version = before.ID, before.Version
+	}
+
+	changes, err := models.DiffTasks(before, after)
+	if err != nil {
+		log.Printf("Warning: Failed to diff task %s: %v", taskID.Hex(), err)
+		return
+	}
+	if action == models.ActivityUpdated && len(changes) == 0 {
+		return
+	}
+
+	activity := &models.Activity{
+		TaskID:  taskID,
+		ActorID: actorID,
+		Action:  action,
+		Changes: changes,
+		Version: version,
+	}
+	if err := h.activityRepo.Append(ctx, activity); err != nil {
+		log.Printf("Warning: Failed to record activity for task %s: %v", taskID.Hex(), err)
+	}
+}
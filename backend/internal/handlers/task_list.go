@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultTaskPageLimit = 50
+	maxTaskPageLimit     = 200
+
+	// defaultTaskSort lists the newest tasks first.
+	defaultTaskSort = "-created_at"
+
+	// customFieldPrefix starts the name of a custom field in sorts and
+	// filters, as in sort=-cf.story_points.
+	customFieldPrefix = "cf."
+)
+
+// taskListing is the parsed form of the paging, sorting and projection
+// parameters of GET /api/tasks.
+type taskListing struct {
+	page   models.TaskPage
+	fields []string
+	// paged is set when the client asked for a page (limit, cursor or
+	// count); the response is then an envelope rather than a bare array.
+	paged bool
+	// cursor holds the cursor of a custom field sort until
+	// resolveCustomFields knows the field's type.
+	cursor string
+}
+
+// pageCursor is the decoded form of an opaque next_cursor. It records the
+// sort it was issued for so it cannot be replayed against another order.
+type pageCursor struct {
+	Sort       models.TaskSortField `json:"s"`
+	Descending bool                 `json:"d"`
+	Value      json.RawMessage      `json:"v"`
+	ID         string               `json:"id"`
+}
+
+// parseTaskListing reads ?sort=, ?limit=, ?cursor=, ?fields= and ?count=.
+// sort names a field, or a custom field as cf.<key>, prefixed with "-" for
+// descending order, and defaults to defaultSort. Custom field sorts are left
+// for resolveCustomFields to complete.
+func parseTaskListing(c *fiber.Ctx, defaultSort string) (*taskListing, *fiber.Error) {
+	listing := &taskListing{}
+
+	sort := c.Query("sort", defaultSort)
+	if sort == "" {
+		sort = defaultTaskSort
+	}
+	if !parseTaskSort(sort, &listing.page) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "sort must be one of created_at, due_date, priority, updated_at, title or cf.<key>")
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxTaskPageLimit {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "limit must be between 1 and 200")
+		}
+		listing.page.Limit = n
+		listing.paged = true
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		listing.paged = true
+		if _, custom := customSortKey(listing.page); custom {
+			listing.cursor = raw
+		} else {
+			after, err := decodeTaskCursor(raw, listing.page, nil)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			listing.page.After = after
+		}
+	}
+
+	if raw := c.Query("count"); raw != "" {
+		count, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "count must be true or false")
+		}
+		listing.page.CountTotal = count
+		listing.paged = true
+	}
+
+	if listing.paged && listing.page.Limit == 0 {
+		listing.page.Limit = defaultTaskPageLimit
+	}
+
+	if raw := c.Query("fields"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !models.IsTaskField(field) {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "unknown field: "+field)
+			}
+			listing.fields = append(listing.fields, field)
+		}
+		listing.page.Fields = listing.fields
+	}
+
+	return listing, nil
+}
+
+// parseTaskSort sets the sort of page from a sort parameter such as
+// "-due_date", reporting false if it names no sortable field. A custom
+// field sort is kept as cf.<key> until it is resolved.
+func parseTaskSort(raw string, page *models.TaskPage) bool {
+	field := models.TaskSortField(strings.TrimPrefix(raw, "-"))
+	if key, custom := customSortKey(models.TaskPage{Sort: field}); custom {
+		if !models.ValidCustomFieldKey(key) {
+			return false
+		}
+	} else if !models.ValidTaskSortField(field) {
+		return false
+	}
+	page.Sort = field
+	page.Descending = strings.HasPrefix(raw, "-")
+	return true
+}
+
+// customSortKey returns the key of the custom field page sorts by, if its
+// sort is an unresolved cf.<key>.
+func customSortKey(page models.TaskPage) (string, bool) {
+	key := strings.TrimPrefix(string(page.Sort), customFieldPrefix)
+	return key, key != string(page.Sort)
+}
+
+func encodeTaskCursor(cursor *models.TaskCursor, page models.TaskPage) (string, error) {
+	value := cursor.Value
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339Nano)
+	}
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(pageCursor{
+		Sort:       page.Sort,
+		Descending: page.Descending,
+		Value:      encodedValue,
+		ID:         cursor.ID.Hex(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeTaskCursor decodes a cursor for page. field is the custom field
+// page sorts by, if any.
+func decodeTaskCursor(raw string, page models.TaskPage, field *models.CustomField) (*models.TaskCursor, error) {
+	invalid := fiber.NewError(fiber.StatusBadRequest, "invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var decoded pageCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, invalid
+	}
+	if decoded.Sort != page.Sort || decoded.Descending != page.Descending {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "cursor does not match sort")
+	}
+	id, err := primitive.ObjectIDFromHex(decoded.ID)
+	if err != nil {
+		return nil, invalid
+	}
+
+	cursor := &models.TaskCursor{ID: id}
+	if field != nil {
+		cursor.Value, err = decodeCustomCursorValue(decoded.Value, field)
+		if err != nil {
+			return nil, invalid
+		}
+		return cursor, nil
+	}
+	switch page.Sort {
+	case models.SortPriority:
+		var rank int
+		err = json.Unmarshal(decoded.Value, &rank)
+		cursor.Value = rank
+	case models.SortTitle:
+		var title string
+		err = json.Unmarshal(decoded.Value, &title)
+		cursor.Value = title
+	default:
+		var at string
+		if err = json.Unmarshal(decoded.Value, &at); err == nil {
+			var t time.Time
+			t, err = time.Parse(time.RFC3339Nano, at)
+			cursor.Value = t
+		}
+	}
+	if err != nil {
+		return nil, invalid
+	}
+	return cursor, nil
+}
+
+// decodeCustomCursorValue decodes the sort value of a cursor for a custom
+// field sort. Tasks without a value have a null one.
+func decodeCustomCursorValue(data json.RawMessage, field *models.CustomField) (interface{}, error) {
+	if string(data) == "null" {
+		return nil, nil
+	}
+	switch field.Type {
+	case models.CustomFieldNumber:
+		var n float64
+		err := json.Unmarshal(data, &n)
+		return n, err
+	case models.CustomFieldCheckbox:
+		var b bool
+		err := json.Unmarshal(data, &b)
+		return b, err
+	case models.CustomFieldDate:
+		var at string
+		if err := json.Unmarshal(data, &at); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, at)
+	case models.CustomFieldUser:
+		var hex string
+		if err := json.Unmarshal(data, &hex); err != nil {
+			return nil, err
+		}
+		return primitive.ObjectIDFromHex(hex)
+	}
+	var s string
+	err := json.Unmarshal(data, &s)
+	return s, err
+}
+
+// projectTasks renders tasks with only the requested fields, plus id.
+func projectTasks(tasks []*models.Task, fields []string) ([]map[string]json.RawMessage, error) {
+	projected := make([]map[string]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		data, err := json.Marshal(task)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		item := map[string]json.RawMessage{"id": all["id"]}
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				item[field] = value
+			}
+		}
+		projected = append(projected, item)
+	}
+	return projected, nil
+}
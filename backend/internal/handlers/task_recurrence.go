@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/recurrence"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultOccurrencePreview = 5
+	maxOccurrencePreview     = 100
+)
+
+// UpdateSeriesRequest edits a recurring task together with every later
+// occurrence of its series. Rule replaces the recurrence rule from this
+// occurrence on; an empty rule stops the series here.
+type UpdateSeriesRequest struct {
+	Title       *string              `json:"title,omitempty"`
+	Description *string              `json:"description,omitempty"`
+	Priority    *models.TaskPriority `json:"priority,omitempty"`
+	DueDate     *time.Time           `json:"due_date,omitempty"`
+	AssignedTo  *primitive.ObjectID  `json:"assigned_to,omitempty"`
+	Tags        *[]string            `json:"tags,omitempty"`
+	Rule        *string              `json:"rule,omitempty"`
+}
+
+// GetTaskOccurrences previews the due dates of the occurrences that will
+// follow this one, up to ?count= (default 5).
+func (h *TaskHandler) GetTaskOccurrences(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	count := defaultOccurrencePreview
+	if raw := c.Query("count"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxOccurrencePreview {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "count must be between 1 and 100",
+			})
+		}
+		count = n
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if task.Recurrence == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "task does not recur",
+		})
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence.Rule)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "task has an invalid recurrence rule",
+		})
+	}
+
+	occurrences := rule.Occurrences(task.Recurrence.Start, task.Recurrence.Index+1, count)
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"rule":        task.Recurrence.Rule,
+		"occurrences": occurrences,
+	})
+}
+
+// UpdateTaskSeries applies an edit to this occurrence and every later one in
+// its series. Changing the rule or the due date starts a new series anchored
+// at this occurrence, so earlier occurrences keep the schedule they had. The
+// occurrences are checked against WIP limits together and, like bulk
+// updates, change in one transaction when the database supports it.
+func (h *TaskHandler) UpdateTaskSeries(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req UpdateSeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if !ifMatch(c, task) {
+		return h.respondStale(c, task.ID)
+	}
+	if task.Recurrence == nil && req.Rule == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "task does not recur",
+		})
+	}
+
+	// The occurrences affected are this one and those created after it.
+	occurrences := []*models.Task{task}
+	if task.Recurrence != nil {
+		series, err := h.taskRepo.Find(c.Context(), models.TaskFilter{SeriesID: &task.Recurrence.SeriesID})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch series",
+			})
+		}
+		for _, occurrence := range series {
+			if occurrence.Recurrence.Index <= task.Recurrence.Index {
+				continue
+			}
+			allowed, err := h.access.canEdit(c.Context(), occurrence, userID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "failed to fetch series",
+				})
+			}
+			if allowed {
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+		sort.Slice(occurrences, func(i, j int) bool {
+			return occurrences[i].Recurrence.Index < occurrences[j].Recurrence.Index
+		})
+	}
+
+	// Work out the schedule from this occurrence on. A nil rule with
+	// reschedule set means the series ends here.
+	var rule *recurrence.Rule
+	if task.Recurrence != nil {
+		parsed, err := recurrence.Parse(task.Recurrence.Rule)
+		if err == nil {
+			rule = parsed
+		}
+	}
+	reschedule := req.DueDate != nil
+	if req.Rule != nil {
+		reschedule = true
+		rule = nil
+		if *req.Rule != "" {
+			parsed, err := recurrence.Parse(*req.Rule)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "invalid recurrence rule: " + err.Error(),
+				})
+			}
+			rule = parsed
+		}
+	}
+	start := task.DueDate
+	if req.DueDate != nil {
+		start = *req.DueDate
+	}
+	if reschedule && rule != nil && start.IsZero() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "recurring tasks need a due date",
+		})
+	}
+	seriesID := primitive.NewObjectID()
+
+	updates := make([]*models.TaskUpdate, len(occurrences))
+	for i, occurrence := range occurrences {
+		update := models.TaskUpdate{
+			Title:       req.Title,
+			Description: req.Description,
+			Priority:    req.Priority,
+			AssignedTo:  req.AssignedTo,
+			Tags:        req.Tags,
+		}
+
+		if reschedule {
+			if rule == nil {
+				update.Recurrence = &models.Recurrence{}
+			} else if dueDate, ok := rule.Nth(start, i+1); ok {
+				next := &models.Recurrence{
+					Rule:     rule.String(),
+					SeriesID: seriesID,
+					Start:    start,
+					Index:    i + 1,
+				}
+				if occurrence.Recurrence != nil {
+					next.NextID = occurrence.Recurrence.NextID
+				}
+				update.Recurrence = next
+				update.DueDate = &dueDate
+			} else {
+				// The new schedule ends before this occurrence; it stays
+				// as a one-off task.
+				update.Recurrence = &models.Recurrence{}
+			}
+		}
+		updates[i] = &update
+	}
+
+	// A new assignee or tags can take the occurrences over a WIP limit,
+	// counting those earlier in the series as well. Any strict limit
+	// refuses the whole edit.
+	if req.AssignedTo != nil || req.Tags != nil {
+		var warnings []string
+		tally := wipTally{}
+		for i, occurrence := range occurrences {
+			found, ferr := h.checkWIP(c.Context(), userID, occurrence, previewTask(occurrence, updates[i]), tally)
+			if ferr != nil {
+				return c.Status(ferr.Code).JSON(fiber.Map{
+					"error": ferr.Message,
+				})
+			}
+			warnings = append(warnings, found...)
+		}
+		setWIPWarnings(c, warnings)
+	}
+
+	// The occurrences change together when the database supports
+	// transactions. Otherwise they change one at a time, and a failure
+	// reports those already changed.
+	var updated []*models.Task
+	var failed primitive.ObjectID
+	apply := func(ctx context.Context) error {
+		updated = updated[:0]
+		for i, occurrence := range occurrences {
+			fresh, err := h.storeTaskUpdate(ctx, userID, occurrence, updates[i])
+			if err != nil {
+				failed = occurrence.ID
+				return err
+			}
+			updated = append(updated, fresh)
+		}
+		return nil
+	}
+	transaction := true
+	err := h.taskRepo.WithTransaction(c.Context(), apply)
+	if errors.Is(err, repository.ErrTransactionsUnsupported) {
+		transaction = false
+		err = apply(c.Context())
+	}
+	if err != nil && transaction {
+		updated = nil
+	}
+
+	for i, fresh := range updated {
+		h.notifier.notifyTaskChange(c.Context(), userID, occurrences[i], fresh)
+		h.broadcastTask(c, fresh, websocket.Message{
+			Type:    "task_updated",
+			Payload: fresh,
+		})
+	}
+
+	if err != nil {
+		if len(updated) == 0 && failed == task.ID {
+			return h.respondUpdateFailed(c, task.ID, err)
+		}
+		code, message := fiber.StatusInternalServerError, "failed to update occurrence "+failed.Hex()
+		if errors.Is(err, repository.ErrVersionConflict) {
+			code, message = fiber.StatusConflict, "occurrence "+failed.Hex()+" was modified concurrently"
+		}
+		if transaction {
+			return c.Status(code).JSON(fiber.Map{
+				"error": "no occurrence was changed: " + message,
+			})
+		}
+		return c.Status(code).JSON(fiber.Map{
+			"error":   message,
+			"updated": updated,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(updated)
+}
+
+// scheduleNextOccurrence creates the occurrence that follows a recurring task
+// that has just been completed, copying its details onto the next due date
+// of the series. It does nothing once the series has ended or the next
+// occurrence already exists. The completion has already been stored, so
+// callers log a failure here rather than report it.
+func (h *TaskHandler) scheduleNextOccurrence(c *fiber.Ctx, task *models.Task) (*models.Task, *fiber.Error) {
+	if task.Recurrence == nil || task.Recurrence.NextID != nil {
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence.Rule)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "task has an invalid recurrence rule")
+	}
+	dueDate, ok := rule.Nth(task.Recurrence.Start, task.Recurrence.Index+1)
+	if !ok {
+		return nil, nil
+	}
+
+	next := &models.Task{
+		Title:        task.Title,
+		Description:  task.Description,
+		Priority:     task.Priority,
+		DueDate:      dueDate,
+		CreatedBy:    task.CreatedBy,
+		AssignedTo:   task.AssignedTo,
+		Tags:         task.Tags,
+		SharedWith:   task.SharedWith,
+		ProjectID:    task.ProjectID,
+		ParentID:     task.ParentID,
+		CustomFields: task.CustomFields,
+		Recurrence: &models.Recurrence{
+			Rule:     task.Recurrence.Rule,
+			SeriesID: task.Recurrence.SeriesID,
+			Start:    task.Recurrence.Start,
+			Index:    task.Recurrence.Index + 1,
+		},
+	}
+	// The series belongs to the project rather than to whoever completed
+	// this occurrence, so their role in the project does not matter.
+	if task.ProjectID != nil {
+		project, err := h.projectRepo.FindByID(c.Context(), *task.ProjectID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+		}
+		if project == nil {
+			return nil, fiber.NewError(fiber.StatusNotFound, "project not found")
+		}
+		key, ferr := h.nextTaskKey(c.Context(), project)
+		if ferr != nil {
+			return nil, ferr
+		}
+		next.Key = key
+	}
+	if ferr := h.startWorkflow(c.Context(), next); ferr != nil {
+		return nil, ferr
+	}
+
+	if err := h.createTask(c, next); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to create next occurrence")
+	}
+
+	current := *task.Recurrence
+	current.NextID = &next.ID
+	updated, err := h.updateTask(c, task, &models.TaskUpdate{Recurrence: &current})
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to update task")
+	}
+	*task = *updated
+
+	h.broadcastTask(c, next, websocket.Message{
+		Type:    "task_created",
+		Payload: next,
+	})
+
+	return next, nil
+}
+
+// newRecurrence validates a rule for a task created with the given due date
+// and starts a series with the task as its first occurrence.
+func newRecurrence(rawRule string, dueDate time.Time) (*models.Recurrence, *fiber.Error) {
+	rule, err := recurrence.Parse(rawRule)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid recurrence rule: "+err.Error())
+	}
+	if dueDate.IsZero() {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "recurring tasks need a due date")
+	}
+	return &models.Recurrence{
+		Rule:     rule.String(),
+		SeriesID: primitive.NewObjectID(),
+		Start:    dueDate,
+		Index:    1,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// createSeries stores n occurrences of a daily series, due from today on.
+func createSeries(t *testing.T, h *TaskHandler, owner primitive.ObjectID, projectID *primitive.ObjectID, n int) []*models.Task {
+	t.Helper()
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	series := primitive.NewObjectID()
+	var occurrences []*models.Task
+	for i := 0; i < n; i++ {
+		occurrences = append(occurrences, createTask(t, h, &models.Task{
+			Title:     "chore",
+			Status:    models.StatusTodo,
+			CreatedBy: owner,
+			ProjectID: projectID,
+			DueDate:   start.AddDate(0, 0, i),
+			Recurrence: &models.Recurrence{
+				Rule:     "FREQ=DAILY",
+				SeriesID: series,
+				Start:    start,
+				Index:    i + 1,
+			},
+		}))
+	}
+	return occurrences
+}
+
+func TestUpdateTaskSeriesChecksWIPAcrossOccurrences(t *testing.T) {
+	owner, assignee := primitive.NewObjectID(), primitive.NewObjectID()
+	for _, tc := range []struct {
+		max  int
+		want int
+	}{
+		{max: 2, want: fiber.StatusConflict},
+		{max: 3, want: fiber.StatusOK},
+	} {
+		h := newTestTaskHandler(t)
+		app := testApp()
+		app.Put("/tasks/:id/series", h.UpdateTaskSeries)
+
+		project := &models.Project{
+			Name:    "Ops",
+			Key:     "OPS",
+			Members: []models.ProjectMember{{UserID: owner, Role: models.ProjectRoleOwner}},
+			WIPLimits: []models.WIPLimit{{
+				ID:          primitive.NewObjectID(),
+				Status:      models.StatusTodo,
+				PerAssignee: true,
+				Max:         tc.max,
+				Enforcement: models.WIPStrict,
+			}},
+		}
+		if err := h.projectRepo.Create(context.Background(), project); err != nil {
+			t.Fatal(err)
+		}
+		occurrences := createSeries(t, h, owner, &project.ID, 3)
+
+		code, body := send(t, app, owner, "PUT", "/tasks/"+occurrences[0].ID.Hex()+"/series", fiber.Map{"assigned_to": assignee.Hex()})
+		if code != tc.want {
+			t.Fatalf("max %d: assigning the series = %d %s, want %d", tc.max, code, body, tc.want)
+		}
+		for _, occurrence := range occurrences {
+			got := findTask(t, h, occurrence.ID)
+			if assigned := got.AssignedTo != nil; assigned != (tc.want == fiber.StatusOK) {
+				t.Errorf("max %d: occurrence %d assigned = %v", tc.max, got.Recurrence.Index, assigned)
+			}
+		}
+	}
+}
+
+func TestUpdateTaskSeriesLosingARace(t *testing.T) {
+	owner := primitive.NewObjectID()
+	for _, transaction := range []bool{true, false} {
+		h := newTestTaskHandler(t)
+		racing := &racingStore{TaskStore: h.taskRepo}
+		var store repository.TaskStore = racing
+		if transaction {
+			store = &txStore{TaskStore: racing}
+		}
+		useTaskStore(h, store)
+		app := testApp()
+		app.Put("/tasks/:id/series", h.UpdateTaskSeries)
+
+		occurrences := createSeries(t, h, owner, nil, 3)
+		racing.race = occurrences[1].ID
+		code, body := send(t, app, owner, "PUT", "/tasks/"+occurrences[0].ID.Hex()+"/series", fiber.Map{"title": "renamed"})
+		if code != fiber.StatusConflict || !strings.Contains(body, occurrences[1].ID.Hex()) {
+			t.Fatalf("transaction %v: update = %d %s, want 409 naming the second occurrence", transaction, code, body)
+		}
+
+		first, last := findTask(t, h, occurrences[0].ID), findTask(t, h, occurrences[2].ID)
+		if last.Title != "chore" {
+			t.Errorf("transaction %v: the occurrence after the failure was renamed", transaction)
+		}
+		if transaction {
+			if !strings.Contains(body, "no occurrence was changed") || first.Title != "chore" || first.Version != occurrences[0].Version {
+				t.Errorf("transaction %v: first occurrence %q at version %d after a rollback, body %s", transaction, first.Title, first.Version, body)
+			}
+			continue
+		}
+		// Without a transaction the occurrences already changed stay so,
+		// and are reported.
+		if first.Title != "renamed" || !strings.Contains(body, `"updated":[{"id":"`+first.ID.Hex()) {
+			t.Errorf("transaction %v: first occurrence %q, body %s; want it renamed and reported", transaction, first.Title, body)
+		}
+	}
+}
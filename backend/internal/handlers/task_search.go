@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/taskquery"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// searchSnippetLength bounds description snippets, in bytes.
+	searchSnippetLength = 160
+)
+
+// SearchResult is one ranked match of GET /api/search.
+type SearchResult struct {
+	Task       *models.Task     `json:"task"`
+	Score      float64          `json:"score"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights show where a task matched. Title and Description are
+// HTML-escaped with matches wrapped in <mark>; Description is a snippet
+// around the first match and is omitted if the description did not match.
+// Tags lists the tags that matched.
+type SearchHighlights struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// SearchTasks runs a full-text search (?q=, see taskquery.ParseText) over
+// the tasks the caller can see. It accepts the filter parameters of
+// GET /api/tasks, with the filter query language in ?filter= since q= holds
+// the search.
+func (h *TaskHandler) SearchTasks(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	text, err := taskquery.ParseText(c.Query("q"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q: " + err.Error(),
+		})
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "limit must be between 1 and 100",
+			})
+		}
+		limit = n
+	}
+
+	filter, ferr := parseTaskFilterQuery(c, "filter", userID, h.projectRepo)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if ferr := resolveCustomFields(c.Context(), &filter, nil, userID, h.projectRepo); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to search tasks",
+		})
+	}
+
+	found, err := h.taskRepo.Search(c.Context(), text, filter, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to search tasks",
+		})
+	}
+
+	matcher := taskquery.NewTextMatcher(text)
+	results := make([]SearchResult, 0, len(found))
+	for _, match := range found {
+		result := SearchResult{Task: match.Task, Score: match.Score}
+		result.Highlights.Title, _ = matcher.Highlight(match.Task.Title, len(match.Task.Title))
+		if snippet, ok := matcher.Highlight(match.Task.Description, searchSnippetLength); ok {
+			result.Highlights.Description = snippet
+		}
+		for _, tag := range match.Task.Tags {
+			if matcher.Matches(tag) {
+				result.Highlights.Tags = append(result.Highlights.Tags, tag)
+			}
+		}
+		results = append(results, result)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"results": results,
+	})
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/storage"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateTaskDetachesProject(t *testing.T) {
+	h := newTestTaskHandler(t)
+	app := testApp()
+	app.Put("/tasks/:id", h.UpdateTask)
+
+	member, viewer := primitive.NewObjectID(), primitive.NewObjectID()
+	project := &models.Project{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{
+		{UserID: member, Role: models.ProjectRoleMember},
+		{UserID: viewer, Role: models.ProjectRoleViewer},
+	}}
+	if err := h.projectRepo.Create(context.Background(), project); err != nil {
+		t.Fatal(err)
+	}
+	detach := fiber.Map{"project_id": ""}
+
+	// A viewer may edit a task they created, but not take it out of the
+	// project.
+	own := createTask(t, h, &models.Task{Title: "viewer's", Status: models.StatusTodo, CreatedBy: viewer, ProjectID: &project.ID, Key: "OPS-1"})
+	if code, body := send(t, app, viewer, "PUT", "/tasks/"+own.ID.Hex(), detach); code != fiber.StatusForbidden {
+		t.Errorf("detach as a viewer = %d %s, want 403", code, body)
+	}
+
+	task := createTask(t, h, &models.Task{Title: "member's", Status: models.StatusTodo, CreatedBy: member, ProjectID: &project.ID, Key: "OPS-2"})
+	if code, body := send(t, app, member, "PUT", "/tasks/"+task.ID.Hex(), detach); code != fiber.StatusOK {
+		t.Fatalf("detach = %d %s, want 200", code, body)
+	}
+	got := findTask(t, h, task.ID)
+	if got.ProjectID != nil || got.Key != "" || len(got.PreviousKeys) != 1 || got.PreviousKeys[0] != "OPS-2" {
+		t.Errorf("detached task has project %v, key %q, previous keys %v", got.ProjectID, got.Key, got.PreviousKeys)
+	}
+
+	// A task in no project stays that way.
+	if code, body := send(t, app, member, "PUT", "/tasks/"+task.ID.Hex(), detach); code != fiber.StatusOK {
+		t.Errorf("detach again = %d %s, want 200", code, body)
+	}
+}
+
+// newTestTaskHandler returns a TaskHandler on empty in-memory stores.
+func newTestTaskHandler(t *testing.T) *TaskHandler {
+	t.Helper()
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := websocket.NewHub()
+	h := NewTaskHandler(
+		repository.NewMemoryTaskRepository(),
+		repository.NewMemoryProjectRepository(),
+		repository.NewMemoryCommentRepository(),
+		repository.NewMemoryActivityRepository(),
+		repository.NewMemoryWIPViolationRepository(),
+		repository.NewMemoryWorkLogRepository(),
+		repository.NewMemoryAttachmentRepository(),
+		blobs,
+		NewNotifier(repository.NewMemoryNotificationRepository(), repository.NewMemoryUserRepository(), hub),
+		hub,
+	)
+	h.urlSecret = []byte("test secret")
+	return h
+}
+
+// testApp returns an app whose requests are made by the user in the X-User
+// header, standing in for middleware.Protected.
+func testApp() *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if id, err := primitive.ObjectIDFromHex(c.Get("X-User")); err == nil {
+			c.Locals("user_id", id)
+		}
+		return c.Next()
+	})
+	return app
+}
+
+// send makes a request to app as userID, with body as JSON unless it is nil,
+// and returns the response status and body. headers are name, value pairs.
+func send(t *testing.T, app *fiber.App, userID primitive.ObjectID, method, target string, body interface{}, headers ...string) (int, string) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", userID.Hex())
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(out)
+}
+
+// createTask stores task as it is, bypassing the handlers.
+func createTask(t *testing.T, h *TaskHandler, task *models.Task) *models.Task {
+	t.Helper()
+	if err := h.taskRepo.Create(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// findTask loads a task from the store, nil if it is gone or in the trash.
+func findTask(t *testing.T, h *TaskHandler, id primitive.ObjectID) *models.Task {
+	t.Helper()
+	task, err := h.taskRepo.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// racingStore changes the task named by race just before the next write to
+// it, as another request would between the handler loading the task and
+// storing it.
+type racingStore struct {
+	repository.TaskStore
+	race primitive.ObjectID
+}
+
+func (s *racingStore) interfere(ctx context.Context, id primitive.ObjectID) error {
+	if s.race.IsZero() || id != s.race {
+		return nil
+	}
+	s.race = primitive.NilObjectID
+	title := "changed elsewhere"
+	return s.TaskStore.Update(ctx, id, &models.TaskUpdate{Title: &title})
+}
+
+func (s *racingStore) Update(ctx context.Context, id primitive.ObjectID, update *models.TaskUpdate) error {
+	if err := s.interfere(ctx, id); err != nil {
+		return err
+	}
+	return s.TaskStore.Update(ctx, id, update)
+}
+
+func (s *racingStore) Delete(ctx context.Context, id primitive.ObjectID, deletion models.TaskDeletion) error {
+	if err := s.interfere(ctx, id); err != nil {
+		return err
+	}
+	return s.TaskStore.Delete(ctx, id, deletion)
+}
+
+// txStore runs transactions on a store that has none, for the tests of
+// all-or-nothing changes: when one fails, the tasks it updated or trashed
+// are put back as they were before it, versions included.
+type txStore struct {
+	repository.TaskStore
+	inTransaction bool
+	before        []*models.Task
+}
+
+func (s *txStore) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.inTransaction, s.before = true, nil
+	err := fn(ctx)
+	s.inTransaction = false
+	if err == nil {
+		return nil
+	}
+	for i := len(s.before) - 1; i >= 0; i-- {
+		if rerr := s.putBack(ctx, s.before[i]); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+	}
+	return err
+}
+
+func (s *txStore) remember(ctx context.Context, id primitive.ObjectID) error {
+	if !s.inTransaction {
+		return nil
+	}
+	task, err := s.TaskStore.FindByID(ctx, id)
+	if err == nil && task != nil {
+		s.before = append(s.before, task)
+	}
+	return err
+}
+
+// putBack replaces the stored task with task, then bumps the version the
+// store resets on Create back to task's.
+func (s *txStore) putBack(ctx context.Context, task *models.Task) error {
+	version := task.Version
+	if err := s.TaskStore.Purge(ctx, task.ID); err != nil {
+		return err
+	}
+	if err := s.TaskStore.Create(ctx, task); err != nil {
+		return err
+	}
+	for task.Version < version {
+		if err := s.TaskStore.Update(ctx, task.ID, &models.TaskUpdate{}); err != nil {
+			return err
+		}
+		task.Version++
+	}
+	return nil
+}
+
+func (s *txStore) Update(ctx context.Context, id primitive.ObjectID, update *models.TaskUpdate) error {
+	if err := s.remember(ctx, id); err != nil {
+		return err
+	}
+	return s.TaskStore.Update(ctx, id, update)
+}
+
+func (s *txStore) Delete(ctx context.Context, id primitive.ObjectID, deletion models.TaskDeletion) error {
+	if err := s.remember(ctx, id); err != nil {
+		return err
+	}
+	return s.TaskStore.Delete(ctx, id, deletion)
+}
+
+// useTaskStore makes h read and write tasks through store.
+func useTaskStore(h *TaskHandler, store repository.TaskStore) {
+	h.taskRepo, h.access.taskRepo = store, store
+}
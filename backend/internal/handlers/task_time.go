@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxWorkLogDuration bounds a single manual work log.
+const maxWorkLogDuration = 24 * 60 * 60
+
+// TimerRequest optionally attaches a note to a timer when it is started or
+// stopped.
+type TimerRequest struct {
+	Note *string `json:"note"`
+}
+
+// WorkLogRequest logs time on a task by hand. Duration is in seconds;
+// StartedAt defaults to Duration ago.
+type WorkLogRequest struct {
+	Duration  int64      `json:"duration"`
+	StartedAt *time.Time `json:"started_at"`
+	Note      string     `json:"note"`
+}
+
+// StartTimer starts a timer for the caller on a task. A user has one running
+// timer at a time; starting another while one runs is a conflict that
+// reports the running timer.
+func (h *TaskHandler) StartTimer(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req TimerRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	timer := &models.WorkLog{
+		TaskID:    task.ID,
+		UserID:    userID,
+		ProjectID: task.ProjectID,
+		Source:    models.WorkLogTimer,
+		StartedAt: time.Now(),
+		Running:   true,
+	}
+	if req.Note != nil {
+		timer.Note = *req.Note
+	}
+	if err := h.workLogRepo.Create(c.Context(), timer); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return h.respondTimerRunning(c, userID)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to start timer",
+		})
+	}
+
+	h.hub.BroadcastToUser(userID, websocket.Message{
+		Type:    "timer_started",
+		Payload: timer,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(timer)
+}
+
+// StopTimer stops the caller's running timer on a task and records the time
+// it ran.
+func (h *TaskHandler) StopTimer(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req TimerRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	timer, err := h.workLogRepo.FindRunning(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch timer",
+		})
+	}
+	if timer == nil || timer.TaskID != task.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "no timer is running on this task",
+		})
+	}
+
+	end := time.Now()
+	timer.Duration = timer.Elapsed(end)
+	if req.Note != nil {
+		timer.Note = *req.Note
+	}
+	if err := h.workLogRepo.Stop(c.Context(), timer.ID, end, timer.Duration, timer.Note); err != nil {
+		if errors.Is(err, repository.ErrTimerNotRunning) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "no timer is running on this task",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to stop timer",
+		})
+	}
+	timer.Running = false
+	timer.EndedAt = &end
+
+	h.hub.BroadcastToUser(userID, websocket.Message{
+		Type:    "timer_stopped",
+		Payload: timer,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(timer)
+}
+
+// GetTimer returns the caller's running timer, or null when none runs.
+func (h *TaskHandler) GetTimer(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	timer, err := h.workLogRepo.FindRunning(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch timer",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"timer": timer,
+	})
+}
+
+// AddWorkLog records time the caller spent on a task by hand.
+func (h *TaskHandler) AddWorkLog(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req WorkLogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.Duration < 1 || req.Duration > maxWorkLogDuration {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "duration must be between 1 second and 24 hours",
+		})
+	}
+	now := time.Now()
+	startedAt := now.Add(-time.Duration(req.Duration) * time.Second)
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
+	}
+	endedAt := startedAt.Add(time.Duration(req.Duration) * time.Second)
+	if endedAt.After(now) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "work logs cannot end in the future",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	log := &models.WorkLog{
+		TaskID:    task.ID,
+		UserID:    userID,
+		ProjectID: task.ProjectID,
+		Source:    models.WorkLogManual,
+		Note:      req.Note,
+		StartedAt: startedAt,
+		EndedAt:   &endedAt,
+		Duration:  req.Duration,
+	}
+	if err := h.workLogRepo.Create(c.Context(), log); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to log time",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(log)
+}
+
+// GetWorkLogs lists the time logged on a task, oldest first, with the total
+// against the task's estimate.
+func (h *TaskHandler) GetWorkLogs(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	logs, err := h.workLogRepo.FindByTask(c.Context(), task.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch work logs",
+		})
+	}
+	if logs == nil {
+		logs = []*models.WorkLog{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"time":     models.SumWorkLogs(task.Estimate, logs, time.Now()),
+		"worklogs": logs,
+	})
+}
+
+// DeleteWorkLog removes one of the caller's work logs from a task. Deleting
+// a running timer discards it.
+func (h *TaskHandler) DeleteWorkLog(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	logID, err := primitive.ObjectIDFromHex(c.Params("logId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid work log id",
+		})
+	}
+
+	task, ferr := h.access.load(c.Context(), c.Params("id"), userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	log, err := h.workLogRepo.FindByID(c.Context(), logID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch work log",
+		})
+	}
+	if log == nil || log.TaskID != task.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "work log not found",
+		})
+	}
+	if log.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only the user who logged the time can delete it",
+		})
+	}
+
+	if err := h.workLogRepo.Delete(c.Context(), log.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete work log",
+		})
+	}
+	if log.Running {
+		h.hub.BroadcastToUser(userID, websocket.Message{
+			Type:    "timer_stopped",
+			Payload: fiber.Map{"id": log.ID, "task_id": log.TaskID, "discarded": true},
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// attachTime sets task.Time when the task has an estimate or logged time.
+func (h *TaskHandler) attachTime(ctx context.Context, task *models.Task) error {
+	logs, err := h.workLogRepo.FindByTask(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if task.Estimate > 0 || len(logs) > 0 {
+		task.Time = models.SumWorkLogs(task.Estimate, logs, time.Now())
+	}
+	return nil
+}
+
+func (h *TaskHandler) respondTimerRunning(c *fiber.Ctx, userID primitive.ObjectID) error {
+	running, err := h.workLogRepo.FindRunning(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch timer",
+		})
+	}
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":   "another timer is already running; stop it first",
+		"running": running,
+	})
+}
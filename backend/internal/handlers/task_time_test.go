@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTimerRunsOnOneTaskAtATime(t *testing.T) {
+	h := newTestTaskHandler(t)
+	app := testApp()
+	app.Get("/tasks/:id", h.GetTask)
+	app.Post("/tasks/:id/timer/start", h.StartTimer)
+	app.Post("/tasks/:id/timer/stop", h.StopTimer)
+
+	user := primitive.NewObjectID()
+	first := createTask(t, h, &models.Task{Title: "first", Status: models.StatusTodo, CreatedBy: user})
+	second := createTask(t, h, &models.Task{Title: "second", Status: models.StatusTodo, CreatedBy: user})
+	timer := func(task *models.Task, action string) (int, string) {
+		return send(t, app, user, "POST", "/tasks/"+task.ID.Hex()+"/timer/"+action, nil)
+	}
+
+	if code, body := timer(first, "start"); code != fiber.StatusCreated {
+		t.Fatalf("start = %d %s, want 201", code, body)
+	}
+	if code, body := timer(second, "start"); code != fiber.StatusConflict || !strings.Contains(body, first.ID.Hex()) {
+		t.Errorf("start a second timer = %d %s, want 409 reporting the running one", code, body)
+	}
+	if code, body := timer(second, "stop"); code != fiber.StatusConflict {
+		t.Errorf("stop on a task without a timer = %d %s, want 409", code, body)
+	}
+
+	code, body := send(t, app, user, "GET", "/tasks/"+first.ID.Hex(), nil)
+	var task models.Task
+	if code != fiber.StatusOK || json.Unmarshal([]byte(body), &task) != nil {
+		t.Fatalf("get = %d %s, want 200", code, body)
+	}
+	if task.Time == nil || task.Time.Running != 1 {
+		t.Errorf("time on the timed task = %+v, want one running timer", task.Time)
+	}
+
+	if code, body := timer(first, "stop"); code != fiber.StatusOK {
+		t.Fatalf("stop = %d %s, want 200", code, body)
+	}
+	if code, body := timer(first, "stop"); code != fiber.StatusConflict {
+		t.Errorf("stop again = %d %s, want 409", code, body)
+	}
+	// Once stopped, a timer can start on another task.
+	if code, body := timer(second, "start"); code != fiber.StatusCreated {
+		t.Errorf("start after stopping = %d %s, want 201", code, body)
+	}
+}
+
+func TestTimesheetTotals(t *testing.T) {
+	h := newTestTaskHandler(t)
+	app := testApp()
+	app.Get("/tasks/:id", h.GetTask)
+	app.Post("/tasks/:id/worklogs", h.AddWorkLog)
+	app.Get("/timesheet", h.GetTimesheet)
+
+	user := primitive.NewObjectID()
+	project := &models.Project{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{{UserID: user, Role: models.ProjectRoleOwner}}}
+	if err := h.projectRepo.Create(context.Background(), project); err != nil {
+		t.Fatal(err)
+	}
+	loose := createTask(t, h, &models.Task{Title: "loose", Status: models.StatusTodo, CreatedBy: user, Estimate: 7200})
+	filed := createTask(t, h, &models.Task{Title: "filed", Status: models.StatusTodo, CreatedBy: user, ProjectID: &project.ID, Key: "OPS-1"})
+
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	for _, log := range []struct {
+		task     *models.Task
+		start    time.Time
+		duration int64
+	}{
+		{loose, day.Add(9 * time.Hour), 3600},
+		{filed, day.Add(14 * time.Hour), 900},
+		{loose, day.Add(33 * time.Hour), 1800},
+		// Outside the timesheet.
+		{loose, day.Add(-time.Hour), 600},
+	} {
+		code, body := send(t, app, user, "POST", "/tasks/"+log.task.ID.Hex()+"/worklogs", fiber.Map{"duration": log.duration, "started_at": log.start})
+		if code != fiber.StatusCreated {
+			t.Fatalf("log time = %d %s, want 201", code, body)
+		}
+	}
+
+	code, body := send(t, app, user, "GET", "/tasks/"+loose.ID.Hex(), nil)
+	var task models.Task
+	if code != fiber.StatusOK || json.Unmarshal([]byte(body), &task) != nil {
+		t.Fatalf("get = %d %s, want 200", code, body)
+	}
+	if task.Time == nil || task.Time.Spent != 6000 || task.Time.Remaining == nil || *task.Time.Remaining != 1200 {
+		t.Errorf("time on the task = %+v, want 6000 spent and 1200 remaining", task.Time)
+	}
+
+	code, body = send(t, app, user, "GET", "/timesheet?from=2024-03-04&to=2024-03-05", nil)
+	var sheet struct {
+		Total int64           `json:"total"`
+		Days  []*TimesheetDay `json:"days"`
+	}
+	if code != fiber.StatusOK || json.Unmarshal([]byte(body), &sheet) != nil {
+		t.Fatalf("timesheet = %d %s, want 200", code, body)
+	}
+	if sheet.Total != 6300 || len(sheet.Days) != 2 {
+		t.Fatalf("timesheet = %s, want 6300 seconds over two days", body)
+	}
+	first, second := sheet.Days[0], sheet.Days[1]
+	if first.Date != "2024-03-04" || first.Total != 4500 || len(first.Projects) != 2 {
+		t.Fatalf("first day = %+v, want 4500 seconds in two projects", first)
+	}
+	// Projects come by name, with time outside any project last.
+	if p := first.Projects[0]; p.ProjectKey != "OPS" || p.Total != 900 {
+		t.Errorf("first day's first project = %+v, want OPS with 900 seconds", p)
+	}
+	if p := first.Projects[1]; p.ProjectID != nil || p.Total != 3600 {
+		t.Errorf("first day's second project = %+v, want no project with 3600 seconds", p)
+	}
+	if second.Date != "2024-03-05" || second.Total != 1800 {
+		t.Errorf("second day = %+v, want 1800 seconds on 2024-03-05", second)
+	}
+
+	code, body = send(t, app, user, "GET", "/timesheet?from=2024-03-04&to=2024-03-05&format=csv", nil)
+	if code != fiber.StatusOK {
+		t.Fatalf("timesheet as CSV = %d %s, want 200", code, body)
+	}
+	if rows := strings.Split(strings.TrimSpace(body), "\n"); len(rows) != 4 || !strings.HasPrefix(rows[1], "2024-03-04,Ops,OPS-1,filed,") {
+		t.Errorf("CSV = %q, want a header and three rows, OPS-1 first", body)
+	}
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultTimesheetDays = 7
+	maxTimesheetDays     = 366
+	timesheetDate        = "2006-01-02"
+)
+
+// TimesheetEntry is a work log with the task it was logged on.
+type TimesheetEntry struct {
+	models.WorkLog
+	TaskKey   string `json:"task_key,omitempty"`
+	TaskTitle string `json:"task_title"`
+}
+
+// TimesheetProject groups a day's entries by project. Entries on tasks
+// outside any project have no ProjectID.
+type TimesheetProject struct {
+	ProjectID  *primitive.ObjectID `json:"project_id"`
+	ProjectKey string              `json:"project_key,omitempty"`
+	Name       string              `json:"name"`
+	Total      int64               `json:"total"`
+	Entries    []*TimesheetEntry   `json:"entries"`
+}
+
+// TimesheetDay holds the time logged on one day, in seconds.
+type TimesheetDay struct {
+	Date     string              `json:"date"`
+	Total    int64               `json:"total"`
+	Projects []*TimesheetProject `json:"projects"`
+}
+
+// GetTimesheet reports the caller's logged time between ?from= and ?to=
+// (dates, both included; the last 7 days by default), grouped by the day
+// each log started on in ?tz= (an IANA zone, UTC by default) and then by
+// project. Running timers count up to now. With ?format=csv it returns one
+// row per work log instead.
+func (h *TaskHandler) GetTimesheet(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	location := time.UTC
+	if raw := c.Query("tz"); raw != "" {
+		loc, err := time.LoadLocation(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid tz",
+			})
+		}
+		location = loc
+	}
+	now := time.Now()
+	year, month, day := now.In(location).Date()
+	to := time.Date(year, month, day, 0, 0, 0, 0, location)
+	if raw := c.Query("to"); raw != "" {
+		t, err := time.ParseInLocation(timesheetDate, raw, location)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must be a date, YYYY-MM-DD",
+			})
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, 1-defaultTimesheetDays)
+	if raw := c.Query("from"); raw != "" {
+		t, err := time.ParseInLocation(timesheetDate, raw, location)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "from must be a date, YYYY-MM-DD",
+			})
+		}
+		from = t
+	}
+	if to.Before(from) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "from must not be after to",
+		})
+	}
+	if to.Sub(from) >= maxTimesheetDays*24*time.Hour {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "a timesheet covers at most 366 days",
+		})
+	}
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be json or csv",
+		})
+	}
+
+	logs, err := h.workLogRepo.FindByUser(c.Context(), userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch work logs",
+		})
+	}
+	days, err := h.buildTimesheet(c.Context(), logs, location, now)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to build timesheet",
+		})
+	}
+
+	if format == "csv" {
+		data, err := timesheetCSV(days, location)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to build timesheet",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="timesheet-%s-%s.csv"`, from.Format(timesheetDate), to.Format(timesheetDate)))
+		return c.Status(fiber.StatusOK).Send(data)
+	}
+
+	var total int64
+	for _, day := range days {
+		total += day.Total
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"user_id":  userID,
+		"from":     from.Format(timesheetDate),
+		"to":       to.Format(timesheetDate),
+		"timezone": location.String(),
+		"total":    total,
+		"days":     days,
+	})
+}
+
+// buildTimesheet groups work logs, oldest first, by day and project. Days
+// come in order; within a day projects are sorted by name, with time
+// outside any project last.
+func (h *TaskHandler) buildTimesheet(ctx context.Context, logs []*models.WorkLog, location *time.Location, now time.Time) ([]*TimesheetDay, error) {
+	taskIDs := make([]primitive.ObjectID, 0, len(logs))
+	projects := make(map[primitive.ObjectID]*models.Project)
+	for _, log := range logs {
+		taskIDs = append(taskIDs, log.TaskID)
+		if log.ProjectID != nil {
+			projects[*log.ProjectID] = nil
+		}
+	}
+
+	// Time logged on tasks since moved to the trash still counts.
+	tasks := make(map[primitive.ObjectID]*models.Task)
+	if len(taskIDs) > 0 {
+		for _, trashed := range []bool{false, true} {
+			found, err := h.taskRepo.Find(ctx, models.TaskFilter{IDs: taskIDs, Trashed: trashed})
+			if err != nil {
+				return nil, err
+			}
+			for _, task := range found {
+				tasks[task.ID] = task
+			}
+		}
+	}
+	for projectID := range projects {
+		project, err := h.projectRepo.FindByID(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		projects[projectID] = project
+	}
+
+	days := []*TimesheetDay{}
+	for _, log := range logs {
+		date := log.StartedAt.In(location).Format(timesheetDate)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, &TimesheetDay{Date: date, Projects: []*TimesheetProject{}})
+		}
+		day := days[len(days)-1]
+
+		var group *TimesheetProject
+		for _, candidate := range day.Projects {
+			if sameProject(candidate.ProjectID, log.ProjectID) {
+				group = candidate
+				break
+			}
+		}
+		if group == nil {
+			group = &TimesheetProject{ProjectID: log.ProjectID, Name: "No project"}
+			if log.ProjectID != nil {
+				group.Name = "Deleted project"
+				if project := projects[*log.ProjectID]; project != nil {
+					group.Name, group.ProjectKey = project.Name, project.Key
+				}
+			}
+			day.Projects = append(day.Projects, group)
+		}
+
+		entry := &TimesheetEntry{WorkLog: *log}
+		entry.Duration = log.Elapsed(now)
+		if task := tasks[log.TaskID]; task != nil {
+			entry.TaskKey, entry.TaskTitle = task.Key, task.Title
+		}
+		group.Entries = append(group.Entries, entry)
+		group.Total += entry.Duration
+		day.Total += entry.Duration
+	}
+
+	for _, day := range days {
+		sort.SliceStable(day.Projects, func(i, j int) bool {
+			a, b := day.Projects[i], day.Projects[j]
+			if (a.ProjectID == nil) != (b.ProjectID == nil) {
+				return b.ProjectID == nil
+			}
+			return a.Name < b.Name
+		})
+	}
+	return days, nil
+}
+
+func sameProject(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// timesheetCSV writes one row per work log, in timesheet order.
+func timesheetCSV(days []*TimesheetDay, location *time.Location) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"date", "project", "task", "title", "started_at", "ended_at", "seconds", "hours", "source", "note"})
+	for _, day := range days {
+		for _, project := range day.Projects {
+			for _, entry := range project.Entries {
+				ended := ""
+				if entry.EndedAt != nil {
+					ended = entry.EndedAt.In(location).Format(time.RFC3339)
+				}
+				w.Write([]string{
+					day.Date,
+					csvCell(project.Name),
+					entry.TaskKey,
+					csvCell(entry.TaskTitle),
+					entry.StartedAt.In(location).Format(time.RFC3339),
+					ended,
+					strconv.FormatInt(entry.Duration, 10),
+					strconv.FormatFloat(float64(entry.Duration)/3600, 'f', 2, 64),
+					string(entry.Source),
+					csvCell(entry.Note),
+				})
+			}
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvCell keeps user text from being read as a formula by spreadsheets.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultTrashRetentionDays applies when TRASH_RETENTION_DAYS is unset.
+const defaultTrashRetentionDays = 30
+
+// TrashedTask is a task in the trash and the time it will be purged.
+type TrashedTask struct {
+	*models.Task
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// trashRetentionFromEnv reads TRASH_RETENTION_DAYS, a whole number of days.
+func trashRetentionFromEnv() time.Duration {
+	days := defaultTrashRetentionDays
+	if raw := os.Getenv("TRASH_RETENTION_DAYS"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			log.Printf("Warning: Invalid TRASH_RETENTION_DAYS %q, using %d", raw, defaultTrashRetentionDays)
+		} else {
+			days = n
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetTrash lists the deleted tasks the caller can see, most recently deleted
+// first.
+func (h *TaskHandler) GetTrash(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	filter := models.TaskFilter{Trashed: true}
+	if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch trash",
+		})
+	}
+	tasks, err := h.taskRepo.Find(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch trash",
+		})
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].DeletedAt.After(*tasks[j].DeletedAt)
+	})
+	trash := make([]TrashedTask, 0, len(tasks))
+	for _, task := range tasks {
+		trash = append(trash, TrashedTask{Task: task, PurgeAt: task.DeletedAt.Add(h.trashRetention)})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(trash)
+}
+
+// RestoreTask takes a task out of the trash, along with the subtasks that
+// were deleted with it. A task whose parent is no longer there is restored
+// at the top level.
+func (h *TaskHandler) RestoreTask(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.loadTrashed(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	subtasks, err := h.taskRepo.Find(c.Context(), models.TaskFilter{Trashed: true, DeletedWith: &task.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch subtasks",
+		})
+	}
+
+	restored, err := h.restoreTask(c, task)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to restore task",
+		})
+	}
+	if restored.ParentID != nil {
+		parent, err := h.taskRepo.FindByID(c.Context(), *restored.ParentID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch parent task",
+			})
+		}
+		if parent == nil {
+			detached := primitive.NilObjectID
+			restored, err = h.updateTask(c, restored, &models.TaskUpdate{ParentID: &detached})
+			if err != nil {
+				return h.respondUpdateFailed(c, task.ID, err)
+			}
+		}
+	}
+	h.broadcastTask(c, restored, websocket.Message{
+		Type:    "task_restored",
+		Payload: restored,
+	})
+
+	for _, subtask := range subtasks {
+		restoredSubtask, err := h.restoreTask(c, subtask)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to restore subtasks",
+			})
+		}
+		h.broadcastTask(c, restoredSubtask, websocket.Message{
+			Type:    "task_restored",
+			Payload: restoredSubtask,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(restored)
+}
+
+// PurgeTask permanently deletes a task in the trash, along with the subtasks
+// that were deleted with it.
+func (h *TaskHandler) PurgeTask(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	task, ferr := h.loadTrashed(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if _, err := h.purgeTask(c.Context(), userID, task); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete task",
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// RunTrashPurger purges tasks that have been in the trash for longer than
+// the retention period, checking every interval, until ctx is cancelled.
+func (h *TaskHandler) RunTrashPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := h.purgeExpiredTrash(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Warning: Failed to purge trash: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d task(s) from the trash", n)
+		}
+		h.collectBlobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpiredTrash purges the tasks deleted more than the retention period
+// ago and returns how many were purged.
+func (h *TaskHandler) purgeExpiredTrash(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-h.trashRetention)
+	expired, err := h.taskRepo.Find(ctx, models.TaskFilter{Trashed: true, DeletedBefore: &cutoff})
+	if err != nil {
+		return 0, err
+	}
+
+	purged := make(map[primitive.ObjectID]bool, len(expired))
+	for _, task := range expired {
+		if purged[task.ID] {
+			continue
+		}
+		ids, err := h.purgeTask(ctx, primitive.NilObjectID, task)
+		for _, id := range ids {
+			purged[id] = true
+		}
+		if err != nil {
+			return len(purged), err
+		}
+	}
+	return len(purged), nil
+}
+
+// purgeTask permanently deletes a task in the trash and the subtasks deleted
+// with it, with their comments, work logs, attachments and the dependencies
+// on them, and returns the IDs of the tasks it deleted. actorID is zero when
+// the server purges tasks on its own.
+func (h *TaskHandler) purgeTask(ctx context.Context, actorID primitive.ObjectID, task *models.Task) ([]primitive.ObjectID, error) {
+	subtasks, err := h.taskRepo.Find(ctx, models.TaskFilter{Trashed: true, DeletedWith: &task.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []primitive.ObjectID
+	for _, doomed := range append(subtasks, task) {
+		if err := h.deleteTask(ctx, actorID, doomed); err != nil {
+			return purged, err
+		}
+		purged = append(purged, doomed.ID)
+		if err := h.removeDependencyEdges(ctx, actorID, doomed.ID); err != nil {
+			return purged, err
+		}
+		if err := h.commentRepo.DeleteByTask(ctx, doomed.ID); err != nil {
+			return purged, err
+		}
+		if err := h.workLogRepo.DeleteByTask(ctx, doomed.ID); err != nil {
+			return purged, err
+		}
+		if err := h.attachmentRepo.MarkDeletedByTask(ctx, doomed.ID, time.Now()); err != nil {
+			return purged, err
+		}
+
+		h.broadcastTaskCtx(ctx, doomed, websocket.Message{
+			Type:    "task_deleted",
+			Payload: fiber.Map{"id": doomed.ID, "reason": "purged"},
+		})
+	}
+	h.collectBlobs(ctx)
+	return purged, nil
+}
+
+// loadTrashed resolves the :id parameter to a task in the trash that userID
+// may edit. Other tasks are reported as not found.
+func (h *TaskHandler) loadTrashed(c *fiber.Ctx, userID primitive.ObjectID) (*models.Task, *fiber.Error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid task id")
+	}
+
+	task, err := h.findTrashed(c.Context(), id)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch task")
+	}
+	if task == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "task not found in trash")
+	}
+	allowed, err := h.access.canEdit(c.Context(), task, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch task")
+	}
+	if !allowed {
+		return nil, fiber.NewError(fiber.StatusNotFound, "task not found in trash")
+	}
+	return task, nil
+}
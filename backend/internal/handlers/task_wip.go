@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// wipTally counts the tasks a bulk operation has already moved into each
+// WIP limit group, which the store does not see until the batch is applied.
+type wipTally map[string]int
+
+func (t wipTally) key(limit *models.WIPLimit, task *models.Task) string {
+	if limit.PerAssignee {
+		return limit.ID.Hex() + "/" + task.AssignedTo.Hex()
+	}
+	return limit.ID.Hex()
+}
+
+// checkWIP checks a task entering its status, or changing assignee, tags or
+// project, against the WIP limits of its project. before is the task as
+// stored, nil for new tasks, and after the task as it would be. A group the
+// task already counted towards is not checked again. Strict limits refuse
+// the change with a conflict; soft ones return a warning. Both are
+// recorded as violations. tally may be nil.
+//
+// The count is not locked against the write that follows, so concurrent
+// requests can together exceed a strict limit; see models.WIPEnforcement.
+func (h *TaskHandler) checkWIP(ctx context.Context, actorID primitive.ObjectID, before, after *models.Task, tally wipTally) ([]string, *fiber.Error) {
+	if after.ProjectID == nil {
+		return nil, nil
+	}
+	project, ferr := h.taskProject(ctx, after.ProjectID)
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	var warnings []string
+	for i := range project.WIPLimits {
+		limit := &project.WIPLimits[i]
+		if !limit.Applies(after) {
+			continue
+		}
+		if before != nil && before.ProjectID != nil && *before.ProjectID == project.ID &&
+			limit.Applies(before) && limit.SameGroup(before, after) {
+			continue
+		}
+
+		result, err := h.taskRepo.FindPage(ctx, limit.Filter(project.ID, after), models.TaskPage{Sort: models.SortCreatedAt, Limit: 1, CountTotal: true})
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to check WIP limits")
+		}
+		count := int(*result.Total) + 1
+		if tally != nil {
+			count += tally[tally.key(limit, after)]
+		}
+		if count <= limit.Max {
+			if tally != nil {
+				tally[tally.key(limit, after)]++
+			}
+			continue
+		}
+
+		strict := limit.Enforcement == models.WIPStrict
+		h.recordWIPViolation(ctx, &models.WIPViolation{
+			ProjectID:  project.ID,
+			LimitID:    limit.ID,
+			TaskID:     after.ID,
+			ActorID:    actorID,
+			Status:     limit.Status,
+			Tag:        limit.Tag,
+			AssigneeID: wipAssignee(limit, after),
+			Max:        limit.Max,
+			Count:      count,
+			Rejected:   strict,
+		})
+		message := fmt.Sprintf("WIP limit %s would be exceeded (%d tasks)", limit, count)
+		if strict {
+			return nil, fiber.NewError(fiber.StatusConflict, message)
+		}
+		if tally != nil {
+			tally[tally.key(limit, after)]++
+		}
+		warnings = append(warnings, message)
+	}
+	return warnings, nil
+}
+
+// recordWIPViolation stores a violation. Failing to record one does not fail
+// the request.
+func (h *TaskHandler) recordWIPViolation(ctx context.Context, violation *models.WIPViolation) {
+	if err := h.wipRepo.Append(ctx, violation); err != nil {
+		log.Printf("Warning: Failed to record WIP violation for task %s: %v", violation.TaskID.Hex(), err)
+	}
+}
+
+func wipAssignee(limit *models.WIPLimit, task *models.Task) *primitive.ObjectID {
+	if !limit.PerAssignee {
+		return nil
+	}
+	return task.AssignedTo
+}
+
+// setWIPWarnings passes soft WIP limit warnings on to the client in a
+// Warning header.
+func setWIPWarnings(c *fiber.Ctx, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	quoted := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		quoted = append(quoted, fmt.Sprintf("299 - %q", warning))
+	}
+	c.Set(fiber.HeaderWarning, strings.Join(quoted, ", "))
+}
+
+// previewTask returns the task as it would be after update, as far as WIP
+// limits are concerned.
+func previewTask(task *models.Task, update *models.TaskUpdate) *models.Task {
+	preview := *task
+	if update.Status != nil {
+		preview.Status = *update.Status
+	}
+	if update.AssignedTo != nil {
+		preview.AssignedTo = nil
+		if !update.AssignedTo.IsZero() {
+			assignedTo := *update.AssignedTo
+			preview.AssignedTo = &assignedTo
+		}
+	}
+	if update.Tags != nil {
+		preview.Tags = *update.Tags
+	}
+	if update.ProjectID != nil {
+		preview.ProjectID = nil
+		if !update.ProjectID.IsZero() {
+			projectID := *update.ProjectID
+			preview.ProjectID = &projectID
+		}
+	}
+	return &preview
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taskProject loads the project a task belongs to. Personal tasks have no
+// project and get nil, whose workflow is the default one.
+func (h *TaskHandler) taskProject(ctx context.Context, projectID *primitive.ObjectID) (*models.Project, *fiber.Error) {
+	if projectID == nil {
+		return nil, nil
+	}
+	project, err := h.projectRepo.FindByID(ctx, *projectID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+	return project, nil
+}
+
+// startWorkflow puts a new task in the initial status of its project's
+// workflow.
+func (h *TaskHandler) startWorkflow(ctx context.Context, task *models.Task) *fiber.Error {
+	project, ferr := h.taskProject(ctx, task.ProjectID)
+	if ferr != nil {
+		return ferr
+	}
+	workflow := project.TaskWorkflow()
+	task.Status = workflow.Initial
+	task.StatusCategory = workflow.Status(workflow.Initial).Category
+	return nil
+}
+
+// applyWorkflow checks update against the workflow of the project the task
+// ends up in, and sets the status category that goes with the new status.
+// A status change must follow one of the workflow's transitions and bring
+// everything the new status requires. A task moving to another project only
+// needs a status that exists there. Tasks in a status their workflow no
+// longer has may move to any status.
+func (h *TaskHandler) applyWorkflow(ctx context.Context, existing *models.Task, update *models.TaskUpdate) *fiber.Error {
+	moved := update.ProjectID != nil && (existing.ProjectID == nil || *existing.ProjectID != *update.ProjectID)
+	changed := update.Status != nil && *update.Status != existing.Status
+	if !moved && !changed {
+		return nil
+	}
+
+	projectID := existing.ProjectID
+	if moved {
+		projectID = update.ProjectID
+		if projectID.IsZero() {
+			projectID = nil
+		}
+	}
+	project, ferr := h.taskProject(ctx, projectID)
+	if ferr != nil {
+		return ferr
+	}
+	workflow := project.TaskWorkflow()
+
+	target := existing.Status
+	if update.Status != nil {
+		target = *update.Status
+	}
+	status := workflow.Status(target)
+	if status == nil {
+		if !changed {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("status %q does not exist in the target project; set a status as well, one of: %s", target, workflow.StatusList()))
+		}
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown status %q; the workflow has: %s", target, workflow.StatusList()))
+	}
+
+	if changed && !moved && workflow.Status(existing.Status) != nil && !workflow.CanTransition(existing.Status, target) {
+		next := workflow.Next(existing.Status)
+		if len(next) == 0 {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("tasks cannot leave status %q", existing.Status))
+		}
+		names := make([]string, 0, len(next))
+		for _, key := range next {
+			names = append(names, string(key))
+		}
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("cannot move a task from %q to %q; allowed next statuses: %s", existing.Status, target, strings.Join(names, ", ")))
+	}
+
+	if changed {
+		if missing := missingRequirements(status, project, existing, update); len(missing) > 0 {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("status %q requires %s", target, strings.Join(missing, ", ")))
+		}
+	}
+
+	if status.Category != existing.StatusCategory {
+		category := status.Category
+		update.StatusCategory = &category
+	}
+	return nil
+}
+
+// missingRequirements returns what the task, once updated, lacks of what
+// status requires. Custom field values in update are keyed by field ID, as
+// customFieldValues leaves them.
+func missingRequirements(status *models.WorkflowStatus, project *models.Project, existing *models.Task, update *models.TaskUpdate) []string {
+	var missing []string
+	for _, required := range status.Requires {
+		var present bool
+		switch required {
+		case models.RequireAssignee:
+			if update.AssignedTo != nil {
+				present = !update.AssignedTo.IsZero()
+			} else {
+				present = existing.AssignedTo != nil && !existing.AssignedTo.IsZero()
+			}
+		case models.RequireDueDate:
+			if update.DueDate != nil {
+				present = !update.DueDate.IsZero()
+			} else {
+				present = !existing.DueDate.IsZero()
+			}
+		case models.RequireDescription:
+			description := existing.Description
+			if update.Description != nil {
+				description = *update.Description
+			}
+			present = strings.TrimSpace(description) != ""
+		case models.RequireResolution:
+			resolution := existing.Resolution
+			if update.Resolution != nil {
+				resolution = *update.Resolution
+			}
+			present = strings.TrimSpace(resolution) != ""
+		default:
+			// Requirements on custom fields that have since been archived
+			// no longer apply.
+			var field *models.CustomField
+			if project != nil {
+				field = project.FindCustomField(required, false)
+			}
+			if field == nil {
+				present = true
+				break
+			}
+			if value, ok := update.CustomFields[field.ID.Hex()]; ok {
+				present = value != nil
+			} else {
+				present = existing.CustomFields[field.ID.Hex()] != nil
+			}
+		}
+		if !present {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
+
+// statusBlockers returns the unfinished tasks that keep update from moving
+// the task into its new status. Finished statuses need every blocker to be
+// done, and so do active ones when blockStart is set.
+func (h *TaskHandler) statusBlockers(ctx context.Context, existing *models.Task, update *models.TaskUpdate) ([]*models.Task, error) {
+	if update.Status == nil || *update.Status == existing.Status {
+		return nil, nil
+	}
+	category := existing.Category()
+	if update.StatusCategory != nil {
+		category = *update.StatusCategory
+	}
+	if category != models.CategoryDone && !(h.blockStart && category == models.CategoryActive) {
+		return nil, nil
+	}
+	return h.unfinishedBlockers(ctx, existing)
+}
+
+// respondBlocked refuses a status change with the blockers that prevent it.
+// Every blocker is counted, but only those userID can view are listed.
+func (h *TaskHandler) respondBlocked(c *fiber.Ctx, userID primitive.ObjectID, blockers []*models.Task) error {
+	summaries, err := h.access.summaries(c.Context(), userID, blockers)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to check dependencies",
+		})
+	}
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":    fmt.Sprintf("task is blocked by %d unfinished task(s)", len(blockers)),
+		"blockers": summaries,
+	})
+}
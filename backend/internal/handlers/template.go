@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/dueoffset"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TemplateHandler struct {
+	templateRepo repository.TaskTemplateStore
+	projectRepo  repository.ProjectStore
+	tasks        *TaskHandler
+}
+
+// NewTemplateHandler returns a handler for task templates. Instantiating a
+// template creates tasks through tasks, with the same checks as creating
+// them one by one.
+func NewTemplateHandler(templateRepo repository.TaskTemplateStore, projectRepo repository.ProjectStore, tasks *TaskHandler) *TemplateHandler {
+	return &TemplateHandler{
+		templateRepo: templateRepo,
+		projectRepo:  projectRepo,
+		tasks:        tasks,
+	}
+}
+
+// TemplateRequest holds a template as created or, in full, replaced.
+type TemplateRequest struct {
+	Name      string `json:"name"`
+	ProjectID string `json:"project_id"`
+	Shared    bool   `json:"shared"`
+	models.TemplateItem
+}
+
+// InstantiateTemplateRequest fills in a template. Start is the date due
+// offsets count from, a date or an RFC 3339 time, today by default. The
+// tasks go to ProjectID if given, else to the parent's project, else to the
+// template's. AssignedTo ("me" or a user ID) assigns every task created.
+type InstantiateTemplateRequest struct {
+	Variables  map[string]string `json:"variables"`
+	ProjectID  string            `json:"project_id"`
+	ParentID   string            `json:"parent_id"`
+	Start      string            `json:"start"`
+	AssignedTo string            `json:"assigned_to"`
+}
+
+// GetTemplates lists the caller's templates and the templates shared with
+// projects the caller is a member of.
+func (h *TemplateHandler) GetTemplates(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	projectIDs, err := h.memberProjectIDs(c, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch templates",
+		})
+	}
+
+	templates, err := h.templateRepo.FindVisible(c.Context(), userID, projectIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch templates",
+		})
+	}
+	if templates == nil {
+		templates = []*models.TaskTemplate{}
+	}
+	for _, template := range templates {
+		template.FillVariables()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(templates)
+}
+
+func (h *TemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	template := &models.TaskTemplate{OwnerID: userID}
+	if ferr := h.applyRequest(c, template, &req, userID); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.templateRepo.Create(c.Context(), template); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create template",
+		})
+	}
+
+	template.FillVariables()
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+func (h *TemplateHandler) GetTemplate(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	template, ferr := h.loadTemplate(c, userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	template.FillVariables()
+	return c.Status(fiber.StatusOK).JSON(template)
+}
+
+// UpdateTemplate replaces a template. Only its owner can change it.
+func (h *TemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	template, ferr := h.loadTemplate(c, userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if ferr := h.applyRequest(c, template, &req, userID); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.templateRepo.Replace(c.Context(), template); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update template",
+		})
+	}
+
+	template.FillVariables()
+	return c.Status(fiber.StatusOK).JSON(template)
+}
+
+// DeleteTemplate removes a template. Only its owner can remove it.
+func (h *TemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	template, ferr := h.loadTemplate(c, userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.templateRepo.Delete(c.Context(), template.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete template",
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// InstantiateTemplate creates the tasks of a template, filling in its
+// variables. Every variable the template uses must be given a value. The
+// response is {"tasks"}, parents before their subtasks.
+func (h *TemplateHandler) InstantiateTemplate(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req InstantiateTemplateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	template, ferr := h.loadTemplate(c, userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	template.FillVariables()
+	var missing []string
+	for _, name := range template.Variables {
+		if _, ok := req.Variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "missing values for variables: " + strings.Join(missing, ", "),
+			"missing": missing,
+		})
+	}
+
+	start := time.Now()
+	if req.Start != "" {
+		parsed, err := time.Parse(time.RFC3339, req.Start)
+		if err != nil {
+			parsed, err = time.Parse(timesheetDate, req.Start)
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "start must be a date, YYYY-MM-DD, or an RFC 3339 time",
+			})
+		}
+		start = parsed
+	}
+
+	var assignedTo *primitive.ObjectID
+	if req.AssignedTo != "" {
+		id, ok := userRef(req.AssignedTo, userID)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid assigned_to id",
+			})
+		}
+		assignedTo = &id
+	}
+
+	projectID := template.ProjectID
+	var parentID *primitive.ObjectID
+	if req.ParentID != "" {
+		id, err := primitive.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid parent_id",
+			})
+		}
+		parent, ferr := h.tasks.validateParent(c.Context(), nil, id, userID)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error": ferr.Message,
+			})
+		}
+		parentID = &parent.ID
+		projectID = parent.ProjectID
+	}
+	if req.ProjectID != "" {
+		id, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid project_id",
+			})
+		}
+		projectID = &id
+	}
+
+	var draft func(item *models.TemplateItem) *taskDraft
+	draft = func(item *models.TemplateItem) *taskDraft {
+		task := &models.Task{
+			Title:       models.FillPlaceholders(item.Title, req.Variables),
+			Description: models.FillPlaceholders(item.Description, req.Variables),
+			Priority:    item.Priority,
+			CreatedBy:   userID,
+			AssignedTo:  assignedTo,
+			Tags:        item.Tags,
+			ProjectID:   projectID,
+			Estimate:    item.Estimate,
+		}
+		// Offsets were checked when the template was saved.
+		if offset, err := dueoffset.Parse(item.DueOffset); err == nil {
+			task.DueDate = offset.Apply(start)
+		}
+		node := &taskDraft{task: task}
+		for i := range item.Children {
+			node.children = append(node.children, draft(&item.Children[i]))
+		}
+		return node
+	}
+	root := draft(&template.TemplateItem)
+	root.task.ParentID = parentID
+
+	created, warnings, ferr := h.tasks.createTaskTree(c, userID, root)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	setWIPWarnings(c, warnings)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"tasks": created,
+	})
+}
+
+// applyRequest sets the fields of template from req and checks the result.
+// Templates can only be attached to projects the owner can see.
+func (h *TemplateHandler) applyRequest(c *fiber.Ctx, template *models.TaskTemplate, req *TemplateRequest, userID primitive.ObjectID) *fiber.Error {
+	template.Name = req.Name
+	template.Shared = req.Shared
+	template.TemplateItem = req.TemplateItem
+	template.ProjectID = nil
+	if req.ProjectID != "" {
+		projectID, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid project id")
+		}
+		template.ProjectID = &projectID
+	}
+
+	if err := template.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if template.ProjectID == nil {
+		return nil
+	}
+	project, err := h.projectRepo.FindByID(c.Context(), *template.ProjectID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil || !project.CanView(userID) {
+		return fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+	return nil
+}
+
+// loadTemplate resolves the :id parameter to a template the caller can see.
+// Templates the caller cannot see are reported as not found; requireOwner
+// rejects templates the caller can see but does not own.
+func (h *TemplateHandler) loadTemplate(c *fiber.Ctx, userID primitive.ObjectID, requireOwner bool) (*models.TaskTemplate, *fiber.Error) {
+	templateID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid template id")
+	}
+
+	template, err := h.templateRepo.FindByID(c.Context(), templateID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch template")
+	}
+	if template == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "template not found")
+	}
+	if template.OwnerID != userID {
+		projectIDs, err := h.memberProjectIDs(c, userID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch template")
+		}
+		if !template.CanView(userID, projectIDs) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "template not found")
+		}
+		if requireOwner {
+			return nil, fiber.NewError(fiber.StatusForbidden, "only the owner can change a template")
+		}
+	}
+	return template, nil
+}
+
+func (h *TemplateHandler) memberProjectIDs(c *fiber.Ctx, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	projects, err := h.projectRepo.FindByMember(c.Context(), userID, true)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(projects))
+	for _, project := range projects {
+		ids = append(ids, project.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,552 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ViewHandler struct {
+	viewRepo    repository.SavedViewStore
+	taskRepo    repository.TaskStore
+	projectRepo repository.ProjectStore
+	userRepo    repository.UserStore
+	access      *taskAccess
+}
+
+func NewViewHandler(viewRepo repository.SavedViewStore, taskRepo repository.TaskStore, projectRepo repository.ProjectStore, userRepo repository.UserStore) *ViewHandler {
+	return &ViewHandler{
+		viewRepo:    viewRepo,
+		taskRepo:    taskRepo,
+		projectRepo: projectRepo,
+		userRepo:    userRepo,
+		access:      &taskAccess{taskRepo: taskRepo, projectRepo: projectRepo},
+	}
+}
+
+type CreateViewRequest struct {
+	Name      string             `json:"name"`
+	ProjectID string             `json:"project_id"`
+	Shared    bool               `json:"shared"`
+	Filter    models.ViewFilter  `json:"filter"`
+	Sort      string             `json:"sort"`
+	GroupBy   models.ViewGroupBy `json:"group_by"`
+}
+
+// UpdateViewRequest holds the fields of a partial view update. An empty
+// project_id detaches the view from its project.
+type UpdateViewRequest struct {
+	Name      *string             `json:"name"`
+	ProjectID *string             `json:"project_id"`
+	Shared    *bool               `json:"shared"`
+	Filter    *models.ViewFilter  `json:"filter"`
+	Sort      *string             `json:"sort"`
+	GroupBy   *models.ViewGroupBy `json:"group_by"`
+}
+
+// ViewGroup is one group of the tasks of a grouped view.
+type ViewGroup struct {
+	Key   string      `json:"key"`
+	Tasks interface{} `json:"tasks"`
+}
+
+// GetViews lists the caller's views and the views shared with projects the
+// caller is a member of.
+func (h *ViewHandler) GetViews(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	projectIDs, err := h.memberProjectIDs(c, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch views",
+		})
+	}
+
+	views, err := h.viewRepo.FindVisible(c.Context(), userID, projectIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch views",
+		})
+	}
+	if views == nil {
+		views = []*models.SavedView{}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(views)
+}
+
+func (h *ViewHandler) CreateView(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	var req CreateViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	view := &models.SavedView{
+		Name:    strings.TrimSpace(req.Name),
+		OwnerID: userID,
+		Shared:  req.Shared,
+		Filter:  req.Filter,
+		Sort:    req.Sort,
+		GroupBy: req.GroupBy,
+	}
+	if req.ProjectID != "" {
+		projectID, err := primitive.ObjectIDFromHex(req.ProjectID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid project id",
+			})
+		}
+		view.ProjectID = &projectID
+	}
+
+	if ferr := h.validateView(c, view, userID); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.viewRepo.Create(c.Context(), view); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create view",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(view)
+}
+
+func (h *ViewHandler) GetView(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	view, ferr := h.loadView(c, userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(view)
+}
+
+// UpdateView changes a view. Only its owner can change it.
+func (h *ViewHandler) UpdateView(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	view, ferr := h.loadView(c, userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	var req UpdateViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	// Apply the update to a copy of the view so the result can be validated
+	// as a whole; a view cannot be left shared without a project.
+	update := &models.SavedViewUpdate{
+		Shared:  req.Shared,
+		Filter:  req.Filter,
+		Sort:    req.Sort,
+		GroupBy: req.GroupBy,
+	}
+	updated := *view
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		update.Name = &name
+		updated.Name = name
+	}
+	if req.ProjectID != nil {
+		var projectID primitive.ObjectID
+		if *req.ProjectID != "" {
+			var err error
+			projectID, err = primitive.ObjectIDFromHex(*req.ProjectID)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "invalid project id",
+				})
+			}
+		}
+		update.ProjectID = &projectID
+		updated.ProjectID = nil
+		if !projectID.IsZero() {
+			updated.ProjectID = &projectID
+		}
+	}
+	if req.Shared != nil {
+		updated.Shared = *req.Shared
+	}
+	if req.Filter != nil {
+		updated.Filter = *req.Filter
+	}
+	if req.Sort != nil {
+		updated.Sort = *req.Sort
+	}
+	if req.GroupBy != nil {
+		updated.GroupBy = *req.GroupBy
+	}
+
+	if ferr := h.validateView(c, &updated, userID); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if update.Filter != nil {
+		update.Filter = &updated.Filter
+	}
+
+	if err := h.viewRepo.Update(c.Context(), view.ID, update); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update view",
+		})
+	}
+
+	saved, err := h.viewRepo.FindByID(c.Context(), view.ID)
+	if err != nil || saved == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch updated view",
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(saved)
+}
+
+// DeleteView removes a view. Only its owner can remove it.
+func (h *ViewHandler) DeleteView(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	view, ferr := h.loadView(c, userID, true)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	if err := h.viewRepo.Delete(c.Context(), view.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete view",
+		})
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// GetViewTasks runs a view for the caller. Relative due dates are resolved in
+// the caller's timezone, or in ?tz= if given. The view's sort can be
+// overridden, and results paged, with the parameters of GET /api/tasks. The
+// response is {"view", "tasks", "next_cursor", "total"}, with "groups" of
+// {"key", "tasks"} in place of "tasks" for grouped views.
+func (h *ViewHandler) GetViewTasks(c *fiber.Ctx) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	view, ferr := h.loadView(c, userID, false)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	loc, ferr := h.location(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	listing, ferr := parseTaskListing(c, view.Sort)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+
+	filter := view.Filter.Resolve(userID, time.Now(), loc)
+	if view.ProjectID != nil {
+		filter.ProjectID = view.ProjectID
+	}
+	if ferr := resolveCustomFields(c.Context(), &filter, listing, userID, h.projectRepo); ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error": ferr.Message,
+		})
+	}
+	if err := h.access.restrictToVisible(c.Context(), userID, &filter); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+
+	result, err := h.taskRepo.FindPage(c.Context(), filter, listing.page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+
+	render := func(tasks []*models.Task) (interface{}, error) {
+		if len(listing.fields) > 0 {
+			return projectTasks(tasks, listing.fields)
+		}
+		if tasks == nil {
+			return []*models.Task{}, nil
+		}
+		return tasks, nil
+	}
+
+	response := fiber.Map{"view": view, "next_cursor": nil}
+	if view.GroupBy == models.GroupByNone {
+		response["tasks"], err = render(result.Tasks)
+	} else {
+		// Views of a project list their status groups in the project's
+		// workflow.
+		var project *models.Project
+		if view.GroupBy == models.GroupByStatus && filter.ProjectID != nil {
+			if project, err = h.projectRepo.FindByID(c.Context(), *filter.ProjectID); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "failed to fetch project",
+				})
+			}
+		}
+		groups := make([]ViewGroup, 0)
+		for _, group := range groupTasks(result.Tasks, view.GroupBy, project.TaskWorkflow()) {
+			var items interface{}
+			if items, err = render(group.tasks); err != nil {
+				break
+			}
+			groups = append(groups, ViewGroup{Key: group.key, Tasks: items})
+		}
+		response["groups"] = groups
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch tasks",
+		})
+	}
+
+	if result.Next != nil {
+		next, err := encodeTaskCursor(result.Next, listing.page)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch tasks",
+			})
+		}
+		response["next_cursor"] = next
+	}
+	if result.Total != nil {
+		response["total"] = *result.Total
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// validateView checks a view before it is saved. A shared view must belong
+// to a project, and views can only be attached to projects the owner can see.
+func (h *ViewHandler) validateView(c *fiber.Ctx, view *models.SavedView, userID primitive.ObjectID) *fiber.Error {
+	if view.Name == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "name is required")
+	}
+	if view.Sort != "" && !parseTaskSort(view.Sort, &models.TaskPage{}) {
+		return fiber.NewError(fiber.StatusBadRequest, "sort must be one of created_at, due_date, priority, updated_at, title or cf.<key>")
+	}
+	if !models.ValidViewGroupBy(view.GroupBy) {
+		return fiber.NewError(fiber.StatusBadRequest, "group_by must be one of status, priority, assignee, project, tag")
+	}
+	if !models.ValidDueWindow(view.Filter.Due) {
+		return fiber.NewError(fiber.StatusBadRequest, "filter.due must be one of today, this_week, overdue")
+	}
+	if ferr := validateTaskFilter(&view.Filter.TaskFilter); ferr != nil {
+		return ferr
+	}
+
+	if view.ProjectID == nil {
+		if view.Shared {
+			return fiber.NewError(fiber.StatusBadRequest, "shared views must belong to a project")
+		}
+		return nil
+	}
+	project, err := h.projectRepo.FindByID(c.Context(), *view.ProjectID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to fetch project")
+	}
+	if project == nil || !project.CanView(userID) {
+		return fiber.NewError(fiber.StatusNotFound, "project not found")
+	}
+	return nil
+}
+
+// loadView resolves the :id parameter to a view the caller can see. Views
+// the caller cannot see are reported as not found; requireOwner rejects
+// views the caller can see but does not own.
+func (h *ViewHandler) loadView(c *fiber.Ctx, userID primitive.ObjectID, requireOwner bool) (*models.SavedView, *fiber.Error) {
+	viewID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid view id")
+	}
+
+	view, err := h.viewRepo.FindByID(c.Context(), viewID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch view")
+	}
+	if view == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "view not found")
+	}
+	if view.OwnerID != userID {
+		projectIDs, err := h.memberProjectIDs(c, userID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch view")
+		}
+		if !view.CanView(userID, projectIDs) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "view not found")
+		}
+		if requireOwner {
+			return nil, fiber.NewError(fiber.StatusForbidden, "only the owner can change a view")
+		}
+	}
+	return view, nil
+}
+
+// location returns the timezone to resolve relative dates in.
+func (h *ViewHandler) location(c *fiber.Ctx, userID primitive.ObjectID) (*time.Location, *fiber.Error) {
+	if tz := c.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid timezone")
+		}
+		return loc, nil
+	}
+
+	user, err := h.userRepo.FindByID(c.Context(), userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to fetch user")
+	}
+	if user == nil {
+		return time.UTC, nil
+	}
+	return user.Location(), nil
+}
+
+func (h *ViewHandler) memberProjectIDs(c *fiber.Ctx, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	projects, err := h.projectRepo.FindByMember(c.Context(), userID, true)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(projects))
+	for _, project := range projects {
+		ids = append(ids, project.ID)
+	}
+	return ids, nil
+}
+
+type taskGroup struct {
+	key   string
+	tasks []*models.Task
+}
+
+// groupTasks splits tasks into groups, keeping their order within each
+// group. Status groups come in the order of workflow and priority groups in
+// priority order; other groups in the order they first appear. A task with
+// several tags appears in the group of each.
+func groupTasks(tasks []*models.Task, by models.ViewGroupBy, workflow *models.Workflow) []taskGroup {
+	var groups []taskGroup
+	index := make(map[string]int)
+	group := func(key string) int {
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, taskGroup{key: key})
+		}
+		return i
+	}
+	add := func(key string, task *models.Task) {
+		i := group(key)
+		groups[i].tasks = append(groups[i].tasks, task)
+	}
+
+	switch by {
+	case models.GroupByStatus:
+		for _, status := range workflow.Statuses {
+			group(string(status.Key))
+		}
+	case models.GroupByPriority:
+		for _, priority := range []models.TaskPriority{models.PriorityHigh, models.PriorityMedium, models.PriorityLow} {
+			group(string(priority))
+		}
+	}
+
+	for _, task := range tasks {
+		switch by {
+		case models.GroupByStatus:
+			add(string(task.Status), task)
+		case models.GroupByPriority:
+			add(string(task.Priority), task)
+		case models.GroupByAssignee:
+			if task.AssignedTo == nil {
+				add("unassigned", task)
+			} else {
+				add(task.AssignedTo.Hex(), task)
+			}
+		case models.GroupByProject:
+			if task.ProjectID == nil {
+				add("none", task)
+			} else {
+				add(task.ProjectID.Hex(), task)
+			}
+		case models.GroupByTag:
+			if len(task.Tags) == 0 {
+				add("untagged", task)
+			}
+			for _, tag := range task.Tags {
+				add(tag, task)
+			}
+		}
+	}
+
+	// Seeded groups that stayed empty are left out.
+	nonEmpty := groups[:0]
+	for _, group := range groups {
+		if len(group.tasks) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
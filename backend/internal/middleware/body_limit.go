@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies larger than limit bytes. The server
+// streams request bodies, so this reads them into memory up to the limit
+// for the handlers that follow. Requests for which skip returns true are
+// passed on unread, for handlers that stream and cap the body themselves.
+func BodyLimit(limit int, skip func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if skip != nil && skip(c) {
+			return c.Next()
+		}
+
+		if c.Request().Header.ContentLength() > limit {
+			return bodyTooLarge(c)
+		}
+		stream := c.Context().RequestBodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "failed to read request body",
+			})
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c)
+		}
+		c.Request().SetBody(body)
+		return c.Next()
+	}
+}
+
+// bodyTooLarge answers with 413 and closes the connection, since the rest
+// of the body is left unread.
+func bodyTooLarge(c *fiber.Ctx) error {
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"error": "request body too large",
+	})
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ActivityAction string
+
+const (
+	ActivityCreated ActivityAction = "created"
+	ActivityUpdated ActivityAction = "updated"
+	ActivityDeleted ActivityAction = "deleted"
+	// ActivityTrashed and ActivityRestored record a task moving into and
+	// out of the trash; ActivityDeleted is only recorded when it is purged.
+	ActivityTrashed  ActivityAction = "trashed"
+	ActivityRestored ActivityAction = "restored"
+)
+
+// Activity is an immutable record of one change to a task. Changes lists
+// every stored field that changed, so creations go from nil to the initial
+// values and deletions from the final values to nil. Version is the task's
+// version after the change (before it, for deletions). ActorID is zero for
+// changes made by the server itself.
+type Activity struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID  primitive.ObjectID `json:"task_id" bson:"task_id"`
+	ActorID primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	Action  ActivityAction     `json:"action" bson:"action"`
+	Changes []FieldChange      `json:"changes" bson:"changes"`
+	Version int64              `json:"version" bson:"version"`
+	At      time.Time          `json:"at" bson:"at"`
+}
+
+// FieldChange holds the stored (BSON) values of a task field before and
+// after a change. A nil value means the field was absent.
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// untrackedFields change on every write or never change, and are left out
+// of activity records.
+var untrackedFields = map[string]bool{
+	"_id":           true,
+	"created_at":    true,
+	"updated_at":    true,
+	"version":       true,
+	"priority_rank": true,
+	"rank":          true,
+	// checklist_progress follows from checklist.
+	"checklist_progress": true,
+}
+
+// DiffTasks lists the fields that differ between two versions of a task,
+// sorted by field name. Either version may be nil.
+func DiffTasks(before, after *Task) ([]FieldChange, error) {
+	beforeDoc, err := taskDocument(before)
+	if err != nil {
+		return nil, err
+	}
+	afterDoc, err := taskDocument(after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool)
+	for field := range beforeDoc {
+		fields[field] = true
+	}
+	for field := range afterDoc {
+		fields[field] = true
+	}
+
+	var changes []FieldChange
+	for field := range fields {
+		if untrackedFields[field] || reflect.DeepEqual(beforeDoc[field], afterDoc[field]) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Field:  field,
+			Before: beforeDoc[field],
+			After:  afterDoc[field],
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// RevertChanges returns a copy of task with each change undone, restoring
+// the Before values. It is used to walk a task back through its history.
+func RevertChanges(task *Task, changes []FieldChange) (*Task, error) {
+	doc, err := taskDocument(task)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.Before == nil {
+			delete(doc, change.Field)
+		} else {
+			doc[change.Field] = change.Before
+		}
+	}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var reverted Task
+	if err := bson.Unmarshal(data, &reverted); err != nil {
+		return nil, err
+	}
+	return &reverted, nil
+}
+
+func taskDocument(task *Task) (bson.M, error) {
+	doc := bson.M{}
+	if task == nil {
+		return doc, nil
+	}
+	data, err := bson.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// MarshalJSON renders the BSON values of the change as plain JSON, with
+// embedded documents as objects rather than key/value lists.
+func (c FieldChange) MarshalJSON() ([]byte, error) {
+	type plain FieldChange
+	return json.Marshal(plain{
+		Field:  c.Field,
+		Before: jsonValue(c.Before),
+		After:  jsonValue(c.After),
+	})
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case primitive.D:
+		out := make(map[string]interface{}, len(value))
+		for _, elem := range value {
+			out[elem.Key] = jsonValue(elem.Value)
+		}
+		return out
+	case primitive.M:
+		out := make(map[string]interface{}, len(value))
+		for key, elem := range value {
+			out[key] = jsonValue(elem)
+		}
+		return out
+	case primitive.A:
+		out := make([]interface{}, len(value))
+		for i, elem := range value {
+			out[i] = jsonValue(elem)
+		}
+		return out
+	}
+	return v
+}
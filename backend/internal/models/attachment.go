@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment describes a file uploaded to a task. The content lives in the
+// blob store under BlobKey. ContentType is the type detected from the
+// content rather than the one the client claimed. Attachments being
+// removed are marked with DeletedAt until their blob is gone.
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID      primitive.ObjectID `json:"task_id" bson:"task_id"`
+	UploadedBy  primitive.ObjectID `json:"uploaded_by" bson:"uploaded_by"`
+	Filename    string             `json:"filename" bson:"filename"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	SHA256      string             `json:"sha256" bson:"sha256"`
+	BlobKey     string             `json:"-" bson:"blob_key"`
+	DeletedAt   *time.Time         `json:"-" bson:"deleted_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
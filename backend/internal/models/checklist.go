@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxChecklistItems bounds the checklist of a task.
+	MaxChecklistItems = 100
+	// MaxChecklistText bounds the text of a checklist item.
+	MaxChecklistText = 500
+)
+
+// ChecklistItem is a to-do item inside a task. Items are kept in the order
+// they are listed in. DoneBy and DoneAt record who ticked the item off, and
+// when.
+type ChecklistItem struct {
+	ID     primitive.ObjectID  `json:"id" bson:"id"`
+	Text   string              `json:"text" bson:"text"`
+	Done   bool                `json:"done" bson:"done"`
+	DoneBy *primitive.ObjectID `json:"done_by,omitempty" bson:"done_by,omitempty"`
+	DoneAt *time.Time          `json:"done_at,omitempty" bson:"done_at,omitempty"`
+}
+
+// ChecklistProgress counts the items of a task's checklist. Stores keep it
+// up to date along with the checklist.
+type ChecklistProgress struct {
+	Total int `json:"total" bson:"total"`
+	Done  int `json:"done" bson:"done"`
+}
+
+// ChecklistItemUpdate changes the text of an item or ticks it on or off. By
+// and At are recorded when Done is set.
+type ChecklistItemUpdate struct {
+	Text *string
+	Done *bool
+	By   primitive.ObjectID
+	At   time.Time
+}
+
+// CountChecklist returns the progress of a checklist, or nil for an empty
+// one.
+func CountChecklist(items []ChecklistItem) *ChecklistProgress {
+	if len(items) == 0 {
+		return nil
+	}
+	progress := &ChecklistProgress{Total: len(items)}
+	for _, item := range items {
+		if item.Done {
+			progress.Done++
+		}
+	}
+	return progress
+}
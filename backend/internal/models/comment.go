@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comment is a Markdown message in a task's discussion thread. Mentions holds
+// the users referenced with @email or @name in the body, and Edits keeps
+// every earlier version of the body, oldest first.
+type Comment struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID   `json:"task_id" bson:"task_id"`
+	AuthorID  primitive.ObjectID   `json:"author_id" bson:"author_id"`
+	Body      string               `json:"body" bson:"body"`
+	Mentions  []primitive.ObjectID `json:"mentions,omitempty" bson:"mentions,omitempty"`
+	Edits     []CommentEdit        `json:"edits,omitempty" bson:"edits,omitempty"`
+	EditedAt  *time.Time           `json:"edited_at,omitempty" bson:"edited_at,omitempty"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// CommentEdit is a previous version of a comment's body, replaced at
+// ReplacedAt.
+type CommentEdit struct {
+	Body       string    `json:"body" bson:"body"`
+	ReplacedAt time.Time `json:"replaced_at" bson:"replaced_at"`
+}
+
+// CommentUpdate replaces a comment's body and mentions, recording the body
+// being replaced in the comment's edit history.
+type CommentUpdate struct {
+	Body     string
+	Mentions []primitive.ObjectID
+	Previous CommentEdit
+}
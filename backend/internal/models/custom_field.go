@@ -0,0 +1,386 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CustomFieldType string
+
+const (
+	CustomFieldText         CustomFieldType = "text"
+	CustomFieldNumber       CustomFieldType = "number"
+	CustomFieldDate         CustomFieldType = "date"
+	CustomFieldSingleSelect CustomFieldType = "single_select"
+	CustomFieldMultiSelect  CustomFieldType = "multi_select"
+	CustomFieldUser         CustomFieldType = "user"
+	CustomFieldCheckbox     CustomFieldType = "checkbox"
+)
+
+// MaxCustomTextLength bounds the length of text custom field values.
+const MaxCustomTextLength = 2000
+
+// customFieldKeyPattern matches keys such as "story_points": a lowercase
+// letter followed by up to 39 lowercase letters, digits or underscores.
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// ValidCustomFieldType reports whether t is one of the known field types.
+func ValidCustomFieldType(t CustomFieldType) bool {
+	switch t {
+	case CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldSingleSelect,
+		CustomFieldMultiSelect, CustomFieldUser, CustomFieldCheckbox:
+		return true
+	}
+	return false
+}
+
+// ValidCustomFieldKey reports whether key can be used as a custom field key.
+func ValidCustomFieldKey(key string) bool {
+	return customFieldKeyPattern.MatchString(key)
+}
+
+// CustomFieldKey derives a field key from a field name, e.g. "Story points"
+// becomes "story_points". It returns "" if nothing usable is left.
+func CustomFieldKey(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+			if b.Len() == 0 && '0' <= r && r <= '9' {
+				continue
+			}
+			b.WriteRune(r)
+			underscore = false
+		case b.Len() > 0 && !underscore:
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	key := strings.TrimRight(b.String(), "_")
+	if len(key) > 40 {
+		key = strings.TrimRight(key[:40], "_")
+	}
+	return key
+}
+
+// CustomField is a typed field a project defines for its tasks. Tasks store
+// their values in Task.CustomFields under the field's ID, so a field can be
+// renamed, or its Key changed, without touching them. Key names the field in
+// filters and sorts. Options lists the choices of select fields, and Min and
+// Max bound number fields. Removing a field archives it: it no longer
+// accepts values, but the values tasks already have are kept.
+type CustomField struct {
+	ID         primitive.ObjectID `json:"id" bson:"id"`
+	Key        string             `json:"key" bson:"key"`
+	Name       string             `json:"name" bson:"name"`
+	Type       CustomFieldType    `json:"type" bson:"type"`
+	Options    []string           `json:"options,omitempty" bson:"options,omitempty"`
+	Required   bool               `json:"required" bson:"required"`
+	Min        *float64           `json:"min,omitempty" bson:"min,omitempty"`
+	Max        *float64           `json:"max,omitempty" bson:"max,omitempty"`
+	Archived   bool               `json:"archived" bson:"archived"`
+	ArchivedAt *time.Time         `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+}
+
+// Validate checks the definition of the field itself.
+func (f *CustomField) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !ValidCustomFieldKey(f.Key) {
+		return fmt.Errorf("key must be 1-40 lowercase letters, digits or underscores starting with a letter")
+	}
+	if !ValidCustomFieldType(f.Type) {
+		return fmt.Errorf("type must be one of text, number, date, single_select, multi_select, user, checkbox")
+	}
+
+	selects := f.Type == CustomFieldSingleSelect || f.Type == CustomFieldMultiSelect
+	if selects && len(f.Options) == 0 {
+		return fmt.Errorf("select fields need options")
+	}
+	if !selects && len(f.Options) > 0 {
+		return fmt.Errorf("only select fields have options")
+	}
+	seen := make(map[string]bool)
+	for _, option := range f.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("options cannot be empty")
+		}
+		if seen[option] {
+			return fmt.Errorf("duplicate option %q", option)
+		}
+		seen[option] = true
+	}
+
+	if f.Type != CustomFieldNumber && (f.Min != nil || f.Max != nil) {
+		return fmt.Errorf("only number fields have min and max")
+	}
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return fmt.Errorf("min cannot be greater than max")
+	}
+	if f.Required && f.Type == CustomFieldCheckbox {
+		return fmt.Errorf("checkbox fields cannot be required")
+	}
+	return nil
+}
+
+// Sortable reports whether tasks can be sorted by the field. Multi-select
+// values are lists and have no single order.
+func (f *CustomField) Sortable() bool {
+	return f.Type != CustomFieldMultiSelect
+}
+
+// Comparable reports whether the field's values can be compared with <, <=,
+// > and >=.
+func (f *CustomField) Comparable() bool {
+	return f.Type == CustomFieldNumber || f.Type == CustomFieldDate
+}
+
+// NormalizeValue checks a value decoded from JSON against the field and
+// returns it in its stored form: a string for text and single-select fields,
+// a float64 for numbers, a time.Time for dates (an RFC 3339 timestamp, or a
+// YYYY-MM-DD date meaning midnight UTC), a []string for multi-select fields,
+// an ObjectID for users and a bool for checkboxes.
+func (f *CustomField) NormalizeValue(raw interface{}) (interface{}, error) {
+	switch f.Type {
+	case CustomFieldText:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string", f.Key)
+		}
+		if len(s) > MaxCustomTextLength {
+			return nil, fmt.Errorf("%s cannot be longer than %d characters", f.Key, MaxCustomTextLength)
+		}
+		return s, nil
+
+	case CustomFieldNumber:
+		n, ok := raw.(float64)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("%s must be a number", f.Key)
+		}
+		if f.Min != nil && n < *f.Min {
+			return nil, fmt.Errorf("%s must be at least %s", f.Key, strconv.FormatFloat(*f.Min, 'f', -1, 64))
+		}
+		if f.Max != nil && n > *f.Max {
+			return nil, fmt.Errorf("%s must be at most %s", f.Key, strconv.FormatFloat(*f.Max, 'f', -1, 64))
+		}
+		return n, nil
+
+	case CustomFieldDate:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a date", f.Key)
+		}
+		t, err := ParseCustomDate(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Key, err)
+		}
+		return t, nil
+
+	case CustomFieldSingleSelect:
+		s, ok := raw.(string)
+		if !ok || !f.hasOption(s) {
+			return nil, fmt.Errorf("%s must be one of %s", f.Key, strings.Join(f.Options, ", "))
+		}
+		return s, nil
+
+	case CustomFieldMultiSelect:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be a list", f.Key)
+		}
+		values := []string{}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok || !f.hasOption(s) {
+				return nil, fmt.Errorf("%s values must be among %s", f.Key, strings.Join(f.Options, ", "))
+			}
+			if !containsString(values, s) {
+				values = append(values, s)
+			}
+		}
+		return values, nil
+
+	case CustomFieldUser:
+		s, _ := raw.(string)
+		id, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a user id", f.Key)
+		}
+		return id, nil
+
+	case CustomFieldCheckbox:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s must be true or false", f.Key)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("%s has an unknown type", f.Key)
+}
+
+// ParseFilterValue parses a value given in a filter, where every value is a
+// string. Multi-select filters take a single option and match tasks that
+// have it. User fields take a user ID; resolving "me" is left to the caller.
+func (f *CustomField) ParseFilterValue(s string) (interface{}, error) {
+	switch f.Type {
+	case CustomFieldNumber:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.Key)
+		}
+		return n, nil
+	case CustomFieldCheckbox:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", f.Key)
+		}
+		return b, nil
+	case CustomFieldMultiSelect:
+		if !f.hasOption(s) {
+			return nil, fmt.Errorf("%s must be one of %s", f.Key, strings.Join(f.Options, ", "))
+		}
+		return s, nil
+	}
+	return f.NormalizeValue(s)
+}
+
+func (f *CustomField) hasOption(option string) bool {
+	return containsString(f.Options, option)
+}
+
+// ParseCustomDate parses an RFC 3339 timestamp or a YYYY-MM-DD date, which
+// stands for midnight UTC.
+func ParseCustomDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD or an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// FormatCustomValue renders a stored value for people to read.
+func FormatCustomValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339)
+	case primitive.ObjectID:
+		return v.Hex()
+	case []string:
+		return strings.Join(v, ", ")
+	case primitive.A:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, FormatCustomValue(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// FindCustomField returns the project's field with the given key or ID.
+// Archived fields are only returned when includeArchived is set.
+func (p *Project) FindCustomField(ref string, includeArchived bool) *CustomField {
+	for i := range p.CustomFields {
+		field := &p.CustomFields[i]
+		if field.Archived && !includeArchived {
+			continue
+		}
+		if field.Key == ref || field.ID.Hex() == ref {
+			return field
+		}
+	}
+	return nil
+}
+
+// CustomFieldCondition is one condition on a custom field in a TaskFilter,
+// naming the field by its key. Values are compared as the field's type
+// parses them; Op is one of <, <=, > or >= for number and date fields, or
+// empty to match any of the values.
+type CustomFieldCondition struct {
+	Field   string   `json:"field" bson:"field"`
+	Op      string   `json:"op,omitempty" bson:"op,omitempty"`
+	Values  []string `json:"values" bson:"values"`
+	Negated bool     `json:"negated,omitempty" bson:"negated,omitempty"`
+}
+
+// CustomFieldMatch is the resolved form of the conditions on one custom
+// field: the stored values the field must have one of (Include) and none of
+// (Exclude), and comparison bounds keyed by MongoDB operator ($lt, $lte,
+// $gt or $gte).
+type CustomFieldMatch struct {
+	FieldID string
+	Include []interface{}
+	Exclude []interface{}
+	Bounds  map[string]interface{}
+}
+
+// CustomFieldPath is the stored path of a custom field's value in a task.
+func CustomFieldPath(fieldID string) string {
+	return "custom_fields." + fieldID
+}
+
+// CustomFieldSort is the sort field that orders tasks by a custom field.
+func CustomFieldSort(fieldID primitive.ObjectID) TaskSortField {
+	return TaskSortField(CustomFieldPath(fieldID.Hex()))
+}
+
+// CustomFieldID returns the ID of the custom field f sorts by, if any.
+func (f TaskSortField) CustomFieldID() (string, bool) {
+	id := strings.TrimPrefix(string(f), "custom_fields.")
+	return id, id != string(f)
+}
+
+// CustomValue returns the task's value for a custom field in a form that
+// compares the same whichever store it came from: dates as time.Time and
+// lists as []string. Missing values are nil.
+func (t *Task) CustomValue(fieldID string) interface{} {
+	return normalizeStoredValue(t.CustomFields[fieldID])
+}
+
+func normalizeStoredValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case time.Time:
+		return v.UTC()
+	case primitive.A:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return value
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Lease grants one server at a time the right to run a background job.
+// Holder keeps the lease until ExpiresAt unless it renews it. Cursor records
+// how far the job has got, so whichever server holds the lease next carries
+// on from there.
+type Lease struct {
+	Name      string     `json:"name" bson:"_id"`
+	Holder    string     `json:"holder" bson:"holder"`
+	ExpiresAt time.Time  `json:"expires_at" bson:"expires_at"`
+	Cursor    *time.Time `json:"cursor,omitempty" bson:"cursor,omitempty"`
+}
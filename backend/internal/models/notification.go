@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationType string
+
+const (
+	// NotificationAssigned tells a user a task was assigned to them.
+	NotificationAssigned NotificationType = "assigned"
+	// NotificationStatusChanged tells the creator and assignee of a task
+	// that someone else moved it to another status.
+	NotificationStatusChanged NotificationType = "status_changed"
+	// NotificationMentioned tells a user they were mentioned in a comment.
+	NotificationMentioned NotificationType = "mentioned"
+	// NotificationDueSoon reminds users that a task is due within one of
+	// the configured reminder offsets.
+	NotificationDueSoon NotificationType = "due_soon"
+	// NotificationOverdue tells users a task has passed its due date
+	// without being done.
+	NotificationOverdue NotificationType = "overdue"
+)
+
+// NotificationTypes lists every notification type, in the order
+// preferences are shown.
+var NotificationTypes = []NotificationType{
+	NotificationAssigned,
+	NotificationStatusChanged,
+	NotificationMentioned,
+	NotificationDueSoon,
+	NotificationOverdue,
+}
+
+// ValidNotificationType reports whether t is a known notification type.
+func ValidNotificationType(t NotificationType) bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is a message for one user, stored so it can be read after
+// the fact as well as pushed live. ActorID is the user whose action caused
+// it, and is unset for notifications the server sends on its own. Key
+// identifies the event it was generated for; notifications with a Key are
+// created at most once.
+type Notification struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Type      NotificationType    `json:"type" bson:"type"`
+	TaskID    *primitive.ObjectID `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	ActorID   *primitive.ObjectID `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Message   string              `json:"message" bson:"message"`
+	DueDate   *time.Time          `json:"due_date,omitempty" bson:"due_date,omitempty"`
+	Read      bool                `json:"read" bson:"read"`
+	ReadAt    *time.Time          `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	Key       string              `json:"-" bson:"key,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProjectRole string
+
+const (
+	ProjectRoleOwner  ProjectRole = "owner"
+	ProjectRoleAdmin  ProjectRole = "admin"
+	ProjectRoleMember ProjectRole = "member"
+	ProjectRoleViewer ProjectRole = "viewer"
+)
+
+// projectKeyPattern matches keys such as "OPS" or "WEB2": an uppercase letter
+// followed by up to nine uppercase letters or digits.
+var projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+type ProjectMember struct {
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Role   ProjectRole        `json:"role" bson:"role"`
+}
+
+type Project struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name         string             `json:"name" bson:"name"`
+	Key          string             `json:"key" bson:"key"`
+	Description  string             `json:"description" bson:"description"`
+	Members      []ProjectMember    `json:"members" bson:"members"`
+	Archived     bool               `json:"archived" bson:"archived"`
+	CustomFields []CustomField      `json:"custom_fields,omitempty" bson:"custom_fields,omitempty"`
+	Workflow     *Workflow          `json:"workflow,omitempty" bson:"workflow,omitempty"`
+	WIPLimits    []WIPLimit         `json:"wip_limits,omitempty" bson:"wip_limits,omitempty"`
+	TaskCounter  int64              `json:"-" bson:"task_counter"`
+	Version      int64              `json:"version" bson:"version"`
+	CreatedBy    primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ProjectUpdate changes the fields that are set and bumps the project's
+// Version. The slices and Workflow replace what is stored, so callers that
+// read and modify them set ExpectedVersion: the update then only applies if
+// the project is still at that version.
+type ProjectUpdate struct {
+	Name         *string          `json:"name,omitempty"`
+	Description  *string          `json:"description,omitempty"`
+	Archived     *bool            `json:"archived,omitempty"`
+	Members      *[]ProjectMember `json:"-"`
+	CustomFields *[]CustomField   `json:"-"`
+	Workflow     *Workflow        `json:"-"`
+	WIPLimits    *[]WIPLimit      `json:"-"`
+
+	ExpectedVersion *int64 `json:"-"`
+}
+
+// ValidProjectKey reports whether key can be used as a project key.
+func ValidProjectKey(key string) bool {
+	return projectKeyPattern.MatchString(key)
+}
+
+// ValidProjectRole reports whether role is one of the known project roles.
+func ValidProjectRole(role ProjectRole) bool {
+	switch role {
+	case ProjectRoleOwner, ProjectRoleAdmin, ProjectRoleMember, ProjectRoleViewer:
+		return true
+	}
+	return false
+}
+
+// MemberRole returns the user's role in the project, if they are a member.
+func (p *Project) MemberRole(userID primitive.ObjectID) (ProjectRole, bool) {
+	for _, member := range p.Members {
+		if member.UserID == userID {
+			return member.Role, true
+		}
+	}
+	return "", false
+}
+
+// CanView reports whether the user is a member of the project.
+func (p *Project) CanView(userID primitive.ObjectID) bool {
+	_, ok := p.MemberRole(userID)
+	return ok
+}
+
+// CanEditTasks reports whether the user may create and change tasks in the
+// project. Viewers are read-only.
+func (p *Project) CanEditTasks(userID primitive.ObjectID) bool {
+	role, ok := p.MemberRole(userID)
+	return ok && role != ProjectRoleViewer
+}
+
+// CanManage reports whether the user may change the project itself.
+func (p *Project) CanManage(userID primitive.ObjectID) bool {
+	role, ok := p.MemberRole(userID)
+	return ok && (role == ProjectRoleOwner || role == ProjectRoleAdmin)
+}
+
+// MemberIDs returns the IDs of all project members.
+func (p *Project) MemberIDs() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(p.Members))
+	for _, member := range p.Members {
+		ids = append(ids, member.UserID)
+	}
+	return ids
+}
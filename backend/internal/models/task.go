@@ -19,36 +19,270 @@ const (
 	StatusCompleted  TaskStatus = "completed"
 )
 
+type ShareRole string
+
+const (
+	ShareRoleViewer ShareRole = "viewer"
+	ShareRoleEditor ShareRole = "editor"
+)
+
+// ValidTaskStatus reports whether status is a well-formed status key. Which
+// statuses a task may have depends on the workflow of its project (see
+// Workflow).
+func ValidTaskStatus(status TaskStatus) bool {
+	return statusKeyPattern.MatchString(string(status))
+}
+
+// ValidTaskPriority reports whether priority is one of the known priorities.
+func ValidTaskPriority(priority TaskPriority) bool {
+	switch priority {
+	case PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// TaskShare grants a user other than the creator or assignee access to a task.
+type TaskShare struct {
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Role   ShareRole          `json:"role" bson:"role"`
+}
+
+// Task is a unit of work, optionally in a project and below a parent task.
 type Task struct {
-	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
-	Title       string              `json:"title" bson:"title"`
-	Description string              `json:"description" bson:"description"`
-	Priority    TaskPriority        `json:"priority" bson:"priority"`
-	Status      TaskStatus          `json:"status" bson:"status"`
-	DueDate     time.Time           `json:"due_date" bson:"due_date"`
-	CreatedBy   primitive.ObjectID  `json:"created_by" bson:"created_by"`
-	AssignedTo  *primitive.ObjectID `json:"assigned_to,omitempty" bson:"assigned_to,omitempty"`
-	Tags        []string            `json:"tags" bson:"tags"`
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Title       string             `json:"title" bson:"title"`
+	Description string             `json:"description" bson:"description"`
+	Priority    TaskPriority       `json:"priority" bson:"priority"`
+	Status      TaskStatus         `json:"status" bson:"status"`
+	// StatusCategory mirrors the category of Status in the project's
+	// workflow.
+	StatusCategory StatusCategory `json:"status_category" bson:"status_category,omitempty"`
+	// Resolution explains how a finished task was resolved.
+	Resolution string `json:"resolution,omitempty" bson:"resolution,omitempty"`
+	// Rank orders the task within its board column (see package rank).
+	// Tasks that were never placed have none and come last.
+	Rank string `json:"rank,omitempty" bson:"rank,omitempty"`
+	// Estimate is the expected effort in seconds.
+	Estimate   int64               `json:"estimate,omitempty" bson:"estimate,omitempty"`
+	DueDate    time.Time           `json:"due_date" bson:"due_date"`
+	CreatedBy  primitive.ObjectID  `json:"created_by" bson:"created_by"`
+	AssignedTo *primitive.ObjectID `json:"assigned_to,omitempty" bson:"assigned_to,omitempty"`
+	Tags       []string            `json:"tags" bson:"tags"`
+	SharedWith []TaskShare         `json:"shared_with,omitempty" bson:"shared_with,omitempty"`
+	ProjectID  *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	// Key is the task's human-readable key in its project, such as
+	// "OPS-142".
+	Key string `json:"key,omitempty" bson:"key,omitempty"`
+	// PreviousKeys are the keys the task had in earlier projects, so that
+	// old references still resolve after a move.
+	PreviousKeys []string `json:"previous_keys,omitempty" bson:"previous_keys,omitempty"`
+	// ParentID makes the task a subtask of another, to any depth.
+	ParentID *primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	// BlockedBy lists the tasks that must be finished before this one.
+	BlockedBy []primitive.ObjectID `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	// Recurrence is set on recurring tasks; completing one creates the
+	// next occurrence of its series.
+	Recurrence *Recurrence `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	// CustomFields holds the values of the project's custom fields, keyed
+	// by field ID (see CustomField).
+	CustomFields      map[string]interface{} `json:"custom_fields,omitempty" bson:"custom_fields,omitempty"`
+	Checklist         []ChecklistItem        `json:"checklist,omitempty" bson:"checklist,omitempty"`
+	ChecklistProgress *ChecklistProgress     `json:"checklist_progress,omitempty" bson:"checklist_progress,omitempty"`
+	// Version starts at 1 and is incremented by every update.
+	Version int64 `json:"version" bson:"version"`
+	// PriorityRank mirrors Priority as a number so that listings can sort
+	// by it.
+	PriorityRank int         `json:"-" bson:"priority_rank"`
+	Rollup       *TaskRollup `json:"rollup,omitempty" bson:"-"`
+	// Time sums up the work logged against the task.
+	Time *TaskTime `json:"time,omitempty" bson:"-"`
+	// DeletedAt is set while the task is in the trash, until it is
+	// restored or purged.
+	DeletedAt *time.Time          `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	// DeletedWith names the task whose deletion took this subtask with it.
+	DeletedWith *primitive.ObjectID `json:"deleted_with,omitempty" bson:"deleted_with,omitempty"`
 	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at"`
 }
 
+// TaskDeletion describes moving a task to the trash: who did it and, for a
+// subtask deleted along with its parent, the task that was deleted.
+// ExpectedVersion works as in TaskUpdate.
+type TaskDeletion struct {
+	By              primitive.ObjectID
+	With            *primitive.ObjectID
+	ExpectedVersion *int64
+}
+
+// Recurrence places a task in a repeating series. Rule is an RRULE (see
+// package recurrence) anchored at Start, the due date of the series' first
+// occurrence, and Index is the task's position in the series counting from 1.
+// NextID is set once the following occurrence has been created.
+type Recurrence struct {
+	Rule     string              `json:"rule" bson:"rule"`
+	SeriesID primitive.ObjectID  `json:"series_id" bson:"series_id"`
+	Start    time.Time           `json:"start" bson:"start"`
+	Index    int                 `json:"index" bson:"index"`
+	NextID   *primitive.ObjectID `json:"next_id,omitempty" bson:"next_id,omitempty"`
+}
+
+// TaskSummary identifies a task in responses that reference other tasks.
+type TaskSummary struct {
+	ID      primitive.ObjectID `json:"id"`
+	Key     string             `json:"key,omitempty"`
+	Title   string             `json:"title"`
+	Status  TaskStatus         `json:"status"`
+	DueDate time.Time          `json:"due_date"`
+}
+
+// TaskRollup summarises a task's descendants. It is computed on read and
+// never stored.
+type TaskRollup struct {
+	Total           int        `json:"total"`
+	Completed       int        `json:"completed"`
+	EarliestDueDate *time.Time `json:"earliest_due_date,omitempty"`
+}
+
+// TaskUpdate holds the fields of a partial task update. Fields tagged
+// json:"-" are maintained by the server and cannot be set by clients. An
+// empty AssignedTo unassigns the task, and an empty ProjectID or Key takes
+// it out of its project or clears its key.
 type TaskUpdate struct {
-	Title       *string              `json:"title,omitempty"`
-	Description *string              `json:"description,omitempty"`
-	Priority    *TaskPriority        `json:"priority,omitempty"`
-	Status      *TaskStatus          `json:"status,omitempty"`
-	DueDate     *time.Time           `json:"due_date,omitempty"`
-	AssignedTo  *primitive.ObjectID  `json:"assigned_to,omitempty"`
-	Tags        *[]string            `json:"tags,omitempty"`
+	Title        *string             `json:"title,omitempty"`
+	Description  *string             `json:"description,omitempty"`
+	Priority     *TaskPriority       `json:"priority,omitempty"`
+	Status       *TaskStatus         `json:"status,omitempty"`
+	Resolution   *string             `json:"resolution,omitempty"`
+	Estimate     *int64              `json:"estimate,omitempty"`
+	DueDate      *time.Time          `json:"due_date,omitempty"`
+	AssignedTo   *primitive.ObjectID `json:"assigned_to,omitempty"`
+	Tags         *[]string           `json:"tags,omitempty"`
+	SharedWith   *[]TaskShare        `json:"-"`
+	ProjectID    *primitive.ObjectID `json:"project_id,omitempty"`
+	Key          *string             `json:"-"`
+	PreviousKeys *[]string           `json:"-"`
+	// ParentID moves the task below another; an empty ID detaches it from
+	// its parent.
+	ParentID  *primitive.ObjectID   `json:"parent_id,omitempty"`
+	BlockedBy *[]primitive.ObjectID `json:"-"`
+	// Recurrence replaces the task's; one with an empty Rule stops the
+	// task from recurring.
+	Recurrence *Recurrence `json:"-"`
+	// CustomFields sets the values of the listed custom fields, by field
+	// ID, and removes those set to nil.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+
+	StatusCategory *StatusCategory `json:"-"`
+	Rank           *string         `json:"-"`
+	// ExpectedVersion, when set, makes the update apply only if the task
+	// is still at that version.
+	ExpectedVersion *int64 `json:"-"`
 }
 
+// TaskFilter selects tasks. Every field that is set must match. Fields
+// holding lists match any of the listed values, and the Exclude fields
+// reject tasks that have any of theirs. Unassigned matches tasks with no
+// assignee.
 type TaskFilter struct {
-	Status     *TaskStatus          `json:"status,omitempty"`
-	Priority   *TaskPriority        `json:"priority,omitempty"`
-	AssignedTo *primitive.ObjectID  `json:"assigned_to,omitempty"`
-	CreatedBy  *primitive.ObjectID  `json:"created_by,omitempty"`
-	Tags       []string             `json:"tags,omitempty"`
-	DueBefore  *time.Time           `json:"due_before,omitempty"`
-	DueAfter   *time.Time           `json:"due_after,omitempty"`
+	Status          []TaskStatus         `json:"status,omitempty" bson:"status,omitempty"`
+	ExcludeStatus   []TaskStatus         `json:"exclude_status,omitempty" bson:"exclude_status,omitempty"`
+	Category        []StatusCategory     `json:"category,omitempty" bson:"category,omitempty"`
+	ExcludeCategory []StatusCategory     `json:"exclude_category,omitempty" bson:"exclude_category,omitempty"`
+	Priority        []TaskPriority       `json:"priority,omitempty" bson:"priority,omitempty"`
+	ExcludePriority []TaskPriority       `json:"exclude_priority,omitempty" bson:"exclude_priority,omitempty"`
+	AssignedTo      *primitive.ObjectID  `json:"assigned_to,omitempty" bson:"assigned_to,omitempty"`
+	Unassigned      bool                 `json:"unassigned,omitempty" bson:"unassigned,omitempty"`
+	CreatedBy       *primitive.ObjectID  `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	Tags            []string             `json:"tags,omitempty" bson:"tags,omitempty"`
+	ExcludeTags     []string             `json:"exclude_tags,omitempty" bson:"exclude_tags,omitempty"`
+	DueBefore       *time.Time           `json:"due_before,omitempty" bson:"due_before,omitempty"`
+	DueAfter        *time.Time           `json:"due_after,omitempty" bson:"due_after,omitempty"`
+	ProjectID       *primitive.ObjectID  `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	ParentIDs       []primitive.ObjectID `json:"parent_ids,omitempty" bson:"parent_ids,omitempty"`
+	BlockedBy       *primitive.ObjectID  `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	SeriesID        *primitive.ObjectID  `json:"series_id,omitempty" bson:"series_id,omitempty"`
+
+	// CustomFields conditions name fields by key. They are resolved against
+	// the filtered project into CustomFieldMatches, which the stores apply.
+	CustomFields       []CustomFieldCondition `json:"custom_fields,omitempty" bson:"custom_fields,omitempty"`
+	CustomFieldMatches []CustomFieldMatch     `json:"-" bson:"-"`
+
+	// VisibleTo restricts results to tasks the given user may read, and
+	// VisibleProjects widens that to every task in the projects the user is
+	// a member of. Both are set by the handlers from the authenticated
+	// caller, never by clients.
+	VisibleTo       *primitive.ObjectID  `json:"-" bson:"-"`
+	VisibleProjects []primitive.ObjectID `json:"-" bson:"-"`
+
+	// IDs matches any of the listed tasks. Tasks in the trash are left out
+	// unless Trashed is set, which selects them instead; DeletedBefore and
+	// DeletedWith narrow that selection.
+	IDs           []primitive.ObjectID `json:"-" bson:"-"`
+	Trashed       bool                 `json:"-" bson:"-"`
+	DeletedBefore *time.Time           `json:"-" bson:"-"`
+	DeletedWith   *primitive.ObjectID  `json:"-" bson:"-"`
+}
+
+// CanView reports whether the user may read the task: its creator, its
+// assignee, or anyone it has been shared with.
+func (t *Task) CanView(userID primitive.ObjectID) bool {
+	if t.CreatedBy == userID {
+		return true
+	}
+	if t.AssignedTo != nil && *t.AssignedTo == userID {
+		return true
+	}
+	for _, share := range t.SharedWith {
+		if share.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanEdit reports whether the user may update or delete the task: its
+// creator or a user it has been shared with as an editor.
+func (t *Task) CanEdit(userID primitive.ObjectID) bool {
+	if t.CreatedBy == userID {
+		return true
+	}
+	for _, share := range t.SharedWith {
+		if share.UserID == userID && share.Role == ShareRoleEditor {
+			return true
+		}
+	}
+	return false
+}
+
+// Audience returns the users who should hear about changes to the task.
+func (t *Task) Audience() []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{t.CreatedBy: true}
+	audience := []primitive.ObjectID{t.CreatedBy}
+	add := func(id primitive.ObjectID) {
+		if !id.IsZero() && !seen[id] {
+			seen[id] = true
+			audience = append(audience, id)
+		}
+	}
+	if t.AssignedTo != nil {
+		add(*t.AssignedTo)
+	}
+	for _, share := range t.SharedWith {
+		add(share.UserID)
+	}
+	return audience
+}
+
+// Summary returns the short form of the task used when other responses
+// refer to it.
+func (t *Task) Summary() TaskSummary {
+	return TaskSummary{
+		ID:      t.ID,
+		Key:     t.Key,
+		Title:   t.Title,
+		Status:  t.Status,
+		DueDate: t.DueDate,
+	}
 }
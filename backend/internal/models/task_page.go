@@ -0,0 +1,192 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TaskSortField string
+
+const (
+	SortCreatedAt TaskSortField = "created_at"
+	SortDueDate   TaskSortField = "due_date"
+	SortPriority  TaskSortField = "priority"
+	SortUpdatedAt TaskSortField = "updated_at"
+	SortTitle     TaskSortField = "title"
+)
+
+// ValidTaskSortField reports whether tasks can be sorted by field.
+func ValidTaskSortField(field TaskSortField) bool {
+	switch field {
+	case SortCreatedAt, SortDueDate, SortPriority, SortUpdatedAt, SortTitle:
+		return true
+	}
+	return false
+}
+
+// TaskPage selects one page of a task listing. Tasks are ordered by Sort
+// and then by ID, in the same direction, so every task has a stable
+// position; After continues from the task it describes. A zero Limit returns
+// every remaining task. Fields, when set, limits the stored fields loaded
+// (the ID and sort field are always loaded).
+type TaskPage struct {
+	Sort       TaskSortField
+	Descending bool
+	Limit      int
+	After      *TaskCursor
+	Fields     []string
+	CountTotal bool
+}
+
+// TaskCursor is the position of a task in a sorted listing: the value of its
+// sort field and its ID.
+type TaskCursor struct {
+	Value interface{}
+	ID    primitive.ObjectID
+}
+
+// TaskPageResult is one page of tasks. Next is nil on the last page and
+// Total is only set when the page asked for it.
+type TaskPageResult struct {
+	Tasks []*Task
+	Next  *TaskCursor
+	Total *int64
+}
+
+// PriorityRank orders priorities from low to high for sorting. Unknown
+// priorities rank below low.
+func PriorityRank(priority TaskPriority) int {
+	switch priority {
+	case PriorityHigh:
+		return 3
+	case PriorityMedium:
+		return 2
+	case PriorityLow:
+		return 1
+	}
+	return 0
+}
+
+// SortValue returns the value of the task's sort field, as stored: a
+// time.Time, the priority rank, or the title. For custom fields it is the
+// task's CustomValue, which is nil when the task has none.
+func (t *Task) SortValue(field TaskSortField) interface{} {
+	if id, ok := field.CustomFieldID(); ok {
+		return t.CustomValue(id)
+	}
+	switch field {
+	case SortDueDate:
+		return t.DueDate
+	case SortPriority:
+		return PriorityRank(t.Priority)
+	case SortUpdatedAt:
+		return t.UpdatedAt
+	case SortTitle:
+		return t.Title
+	}
+	return t.CreatedAt
+}
+
+// SortKey is the name of the stored field a sort uses.
+func (f TaskSortField) SortKey() string {
+	if f == SortPriority {
+		return "priority_rank"
+	}
+	return string(f)
+}
+
+// CompareSortValues orders two values returned by SortValue for the same
+// field, returning -1, 0 or 1. As in MongoDB, a missing (nil) value sorts
+// before any other.
+func CompareSortValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch x := a.(type) {
+	case time.Time:
+		y := b.(time.Time)
+		switch {
+		case x.Before(y):
+			return -1
+		case x.After(y):
+			return 1
+		}
+	case int:
+		y := b.(int)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case bool:
+		y := b.(bool)
+		switch {
+		case !x && y:
+			return -1
+		case x && !y:
+			return 1
+		}
+	case primitive.ObjectID:
+		return strings.Compare(x.Hex(), b.(primitive.ObjectID).Hex())
+	case string:
+		return strings.Compare(x, b.(string))
+	}
+	return 0
+}
+
+var taskFieldNames = func() map[string]bool {
+	names := make(map[string]bool)
+	taskType := reflect.TypeOf(Task{})
+	for i := 0; i < taskType.NumField(); i++ {
+		field := taskType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || field.Tag.Get("bson") == "-" {
+			continue
+		}
+		names[name] = true
+	}
+	return names
+}()
+
+// IsTaskField reports whether name is the JSON name of a stored task field.
+// Stored fields use the same name in BSON, apart from id (_id).
+func IsTaskField(name string) bool {
+	return taskFieldNames[name]
+}
+
+// SortByRank puts tasks in board order: ranked tasks by rank, then the tasks
+// that were never placed, oldest first.
+func SortByRank(tasks []*Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if (a.Rank == "") != (b.Rank == "") {
+			return a.Rank != ""
+		}
+		if a.Rank != b.Rank {
+			return a.Rank < b.Rank
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.Hex() < b.ID.Hex()
+	})
+}
@@ -0,0 +1,26 @@
+package models
+
+// TextQuery is a parsed full-text search over task titles, descriptions and
+// tags. A task matches when it contains every word, phrase and prefix and
+// none of the excluded words. Matching ignores case.
+type TextQuery struct {
+	Words    []string `json:"words,omitempty"`
+	Phrases  []string `json:"phrases,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+	Excluded []string `json:"excluded,omitempty"`
+}
+
+// TaskSearchResult is a task found by a text search. Higher scores are more
+// relevant; scores are only comparable within one search.
+type TaskSearchResult struct {
+	Task  *Task
+	Score float64
+}
+
+// Search weights make a match in the title count for more than one in the
+// tags, and both for more than one in the description.
+const (
+	SearchWeightTitle       = 10
+	SearchWeightTags        = 5
+	SearchWeightDescription = 1
+)
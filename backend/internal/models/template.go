@@ -0,0 +1,160 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shrey258/task_management/internal/dueoffset"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxTemplateItems bounds the number of tasks a template creates.
+	MaxTemplateItems = 100
+	// MaxTemplateDepth bounds how deeply template items nest.
+	MaxTemplateDepth = 5
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// TemplateItem describes a task a template creates. Title and Description
+// may contain {{placeholders}} that are filled in when the template is
+// instantiated. DueOffset sets the due date relative to the instantiation
+// date (see package dueoffset); without one the task has no due date.
+// Children become subtasks of the task.
+type TemplateItem struct {
+	Title       string         `json:"title" bson:"title"`
+	Description string         `json:"description,omitempty" bson:"description,omitempty"`
+	Priority    TaskPriority   `json:"priority,omitempty" bson:"priority,omitempty"`
+	Tags        []string       `json:"tags,omitempty" bson:"tags,omitempty"`
+	DueOffset   string         `json:"due_offset,omitempty" bson:"due_offset,omitempty"`
+	Estimate    int64          `json:"estimate,omitempty" bson:"estimate,omitempty"`
+	Children    []TemplateItem `json:"children,omitempty" bson:"children,omitempty"`
+}
+
+// TaskTemplate is a reusable tree of tasks. Like saved views, templates
+// belong to their owner; a shared template with a ProjectID is also visible
+// to the project's members. Instantiating it creates tasks in ProjectID
+// unless told otherwise. Variables lists the placeholders used anywhere in
+// the template and is computed on read.
+type TaskTemplate struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name         string              `json:"name" bson:"name"`
+	OwnerID      primitive.ObjectID  `json:"owner_id" bson:"owner_id"`
+	ProjectID    *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Shared       bool                `json:"shared" bson:"shared"`
+	TemplateItem `bson:",inline"`
+	Variables    []string  `json:"variables" bson:"-"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// CanView reports whether the user may see and instantiate the template,
+// given the projects the user is a member of.
+func (t *TaskTemplate) CanView(userID primitive.ObjectID, projectIDs []primitive.ObjectID) bool {
+	if t.OwnerID == userID {
+		return true
+	}
+	if !t.Shared || t.ProjectID == nil {
+		return false
+	}
+	for _, id := range projectIDs {
+		if id == *t.ProjectID {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate normalises the template and checks its items.
+func (t *TaskTemplate) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return errors.New("name is required")
+	}
+	if t.Shared && t.ProjectID == nil {
+		return errors.New("shared templates must belong to a project")
+	}
+	count := 0
+	return t.TemplateItem.validate("", 1, &count)
+}
+
+func (i *TemplateItem) validate(path string, depth int, count *int) error {
+	fail := func(format string, args ...interface{}) error {
+		message := fmt.Sprintf(format, args...)
+		if path == "" {
+			return errors.New(message)
+		}
+		return fmt.Errorf("%s: %s", path, message)
+	}
+
+	*count++
+	if *count > MaxTemplateItems {
+		return fmt.Errorf("a template can create at most %d tasks", MaxTemplateItems)
+	}
+	if depth > MaxTemplateDepth {
+		return fail("items can be nested at most %d levels deep", MaxTemplateDepth)
+	}
+	i.Title = strings.TrimSpace(i.Title)
+	if i.Title == "" {
+		return fail("title is required")
+	}
+	if i.Priority != "" && !ValidTaskPriority(i.Priority) {
+		return fail("priority must be low, medium or high")
+	}
+	if i.DueOffset != "" {
+		if _, err := dueoffset.Parse(i.DueOffset); err != nil {
+			return fail("%v", err)
+		}
+	}
+	if i.Estimate < 0 {
+		return fail("estimate cannot be negative")
+	}
+	for n := range i.Children {
+		if err := i.Children[n].validate(fmt.Sprintf("%schildren[%d]", pathPrefix(path), n), depth+1, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pathPrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + "."
+}
+
+// FillVariables sets Variables from the placeholders in the template.
+func (t *TaskTemplate) FillVariables() {
+	seen := make(map[string]bool)
+	var walk func(item *TemplateItem)
+	walk = func(item *TemplateItem) {
+		for _, text := range []string{item.Title, item.Description} {
+			for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+				seen[match[1]] = true
+			}
+		}
+		for n := range item.Children {
+			walk(&item.Children[n])
+		}
+	}
+	walk(&t.TemplateItem)
+
+	t.Variables = make([]string, 0, len(seen))
+	for name := range seen {
+		t.Variables = append(t.Variables, name)
+	}
+	sort.Strings(t.Variables)
+}
+
+// FillPlaceholders replaces the {{placeholders}} in text with their values.
+func FillPlaceholders(text string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		return values[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+	})
+}
@@ -12,17 +12,57 @@ type User struct {
 	Email     string            `json:"email" bson:"email"`
 	Password  string            `json:"-" bson:"password"`
 	Name      string            `json:"name" bson:"name"`
+	Timezone  string            `json:"timezone,omitempty" bson:"timezone,omitempty"`
 	CreatedAt time.Time         `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at" bson:"updated_at"`
+
+	// MutedNotifications lists the notification types the user has turned
+	// off; every other type is delivered.
+	MutedNotifications []NotificationType `json:"-" bson:"muted_notifications,omitempty"`
+}
+
+// UserUpdate holds the profile fields a user may change. Timezone is an
+// IANA name such as "Europe/Berlin". MutedNotifications replaces the muted
+// types; it is changed through the notification preferences rather than
+// the profile.
+type UserUpdate struct {
+	Name               *string             `json:"name,omitempty"`
+	Timezone           *string             `json:"timezone,omitempty"`
+	MutedNotifications *[]NotificationType `json:"-"`
 }
 
 type UserResponse struct {
 	ID        primitive.ObjectID `json:"id"`
 	Email     string            `json:"email"`
 	Name      string            `json:"name"`
+	Timezone  string            `json:"timezone,omitempty"`
 	CreatedAt time.Time         `json:"created_at"`
 }
 
+// Location returns the user's timezone, or UTC if none is set or it is not
+// recognised.
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// WantsNotification reports whether the user receives notifications of
+// type t.
+func (u *User) WantsNotification(t NotificationType) bool {
+	for _, muted := range u.MutedNotifications {
+		if muted == t {
+			return false
+		}
+	}
+	return true
+}
+
 func (u *User) HashPassword() error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -41,6 +81,7 @@ func (u *User) ToResponse() UserResponse {
 		ID:        u.ID,
 		Email:     u.Email,
 		Name:      u.Name,
+		Timezone:  u.Timezone,
 		CreatedAt: u.CreatedAt,
 	}
 }
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ViewGroupBy string
+
+const (
+	GroupByNone     ViewGroupBy = ""
+	GroupByStatus   ViewGroupBy = "status"
+	GroupByPriority ViewGroupBy = "priority"
+	GroupByAssignee ViewGroupBy = "assignee"
+	GroupByProject  ViewGroupBy = "project"
+	GroupByTag      ViewGroupBy = "tag"
+)
+
+// ValidViewGroupBy reports whether tasks can be grouped by group.
+func ValidViewGroupBy(group ViewGroupBy) bool {
+	switch group {
+	case GroupByNone, GroupByStatus, GroupByPriority, GroupByAssignee, GroupByProject, GroupByTag:
+		return true
+	}
+	return false
+}
+
+// DueWindow is a due date range relative to when a view is run.
+type DueWindow string
+
+const (
+	DueToday    DueWindow = "today"
+	DueThisWeek DueWindow = "this_week"
+	DueOverdue  DueWindow = "overdue"
+)
+
+// ValidDueWindow reports whether window is empty or a known window.
+func ValidDueWindow(window DueWindow) bool {
+	switch window {
+	case "", DueToday, DueThisWeek, DueOverdue:
+		return true
+	}
+	return false
+}
+
+// ViewFilter is the filter of a saved view: a stored TaskFilter plus the
+// parts that depend on who runs the view and when. Due replaces the filter's
+// due date bounds, and AssignedToMe its assignee.
+type ViewFilter struct {
+	TaskFilter   `bson:",inline"`
+	Due          DueWindow `json:"due,omitempty" bson:"due,omitempty"`
+	AssignedToMe bool      `json:"assigned_to_me,omitempty" bson:"assigned_to_me,omitempty"`
+}
+
+// Resolve returns the filter to run for userID at now. Days and weeks are
+// those of loc; weeks start on Monday.
+func (f ViewFilter) Resolve(userID primitive.ObjectID, now time.Time, loc *time.Location) TaskFilter {
+	filter := f.TaskFilter
+	if f.AssignedToMe {
+		filter.AssignedTo, filter.Unassigned = &userID, false
+	}
+
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	// TaskFilter bounds are exclusive and due dates are stored to the
+	// millisecond, so a range starting at start begins just before it.
+	between := func(start, end time.Time) {
+		after := start.Add(-time.Millisecond)
+		filter.DueAfter, filter.DueBefore = &after, &end
+	}
+
+	switch f.Due {
+	case DueToday:
+		between(today, today.AddDate(0, 0, 1))
+	case DueThisWeek:
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		between(monday, monday.AddDate(0, 0, 7))
+	case DueOverdue:
+		// Tasks without a due date have the zero time, which is excluded.
+		var zero time.Time
+		before := now
+		filter.DueAfter, filter.DueBefore = &zero, &before
+		filter.ExcludeCategory = append(append([]StatusCategory(nil), filter.ExcludeCategory...), CategoryDone)
+	}
+	return filter
+}
+
+// SavedView is a named task filter with its sort order and grouping. Views
+// belong to their owner; a shared view with a ProjectID is also visible to
+// the project's members and only lists tasks in that project.
+type SavedView struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Name      string              `json:"name" bson:"name"`
+	OwnerID   primitive.ObjectID  `json:"owner_id" bson:"owner_id"`
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Shared    bool                `json:"shared" bson:"shared"`
+	Filter    ViewFilter          `json:"filter" bson:"filter"`
+	Sort      string              `json:"sort,omitempty" bson:"sort,omitempty"`
+	GroupBy   ViewGroupBy         `json:"group_by,omitempty" bson:"group_by,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// SavedViewUpdate holds the fields of a partial view update. An empty
+// project_id detaches a view from its project.
+type SavedViewUpdate struct {
+	Name      *string             `json:"name,omitempty"`
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty"`
+	Shared    *bool               `json:"shared,omitempty"`
+	Filter    *ViewFilter         `json:"filter,omitempty"`
+	Sort      *string             `json:"sort,omitempty"`
+	GroupBy   *ViewGroupBy        `json:"group_by,omitempty"`
+}
+
+// CanView reports whether the user may see and run the view, given the
+// projects the user is a member of.
+func (v *SavedView) CanView(userID primitive.ObjectID, projectIDs []primitive.ObjectID) bool {
+	if v.OwnerID == userID {
+		return true
+	}
+	if !v.Shared || v.ProjectID == nil {
+		return false
+	}
+	for _, id := range projectIDs {
+		if id == *v.ProjectID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WIPEnforcement decides what happens to a change that would take a status
+// over its WIP limit: strict limits refuse it, soft limits let it through
+// with a warning. Both record a WIPViolation.
+//
+// Strict limits are best-effort: tasks are counted before the change is
+// written, so concurrent changes to the same group can each pass the check
+// and together leave it over its limit.
+type WIPEnforcement string
+
+const (
+	WIPStrict WIPEnforcement = "strict"
+	WIPSoft   WIPEnforcement = "soft"
+)
+
+// WIPLimit caps how many tasks of a project may be in Status at once. With a
+// Tag only tasks carrying it count. PerAssignee applies Max to each
+// assignee's tasks separately, and unassigned tasks do not count.
+type WIPLimit struct {
+	ID          primitive.ObjectID `json:"id" bson:"id"`
+	Status      TaskStatus         `json:"status" bson:"status"`
+	Tag         string             `json:"tag,omitempty" bson:"tag,omitempty"`
+	PerAssignee bool               `json:"per_assignee" bson:"per_assignee"`
+	Max         int                `json:"max" bson:"max"`
+	Enforcement WIPEnforcement     `json:"enforcement" bson:"enforcement"`
+}
+
+// WIPViolation records a change that would have taken a status over one of
+// its WIP limits. Count is the number of tasks the change would have left
+// in the limited group, and Rejected tells whether the change was refused.
+type WIPViolation struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ProjectID  primitive.ObjectID  `json:"project_id" bson:"project_id"`
+	LimitID    primitive.ObjectID  `json:"limit_id" bson:"limit_id"`
+	TaskID     primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	ActorID    primitive.ObjectID  `json:"actor_id" bson:"actor_id"`
+	Status     TaskStatus          `json:"status" bson:"status"`
+	Tag        string              `json:"tag,omitempty" bson:"tag,omitempty"`
+	AssigneeID *primitive.ObjectID `json:"assignee_id,omitempty" bson:"assignee_id,omitempty"`
+	Max        int                 `json:"max" bson:"max"`
+	Count      int                 `json:"count" bson:"count"`
+	Rejected   bool                `json:"rejected" bson:"rejected"`
+	At         time.Time           `json:"at" bson:"at"`
+}
+
+// Validate normalises the limit and checks it against the project's
+// workflow.
+func (l *WIPLimit) Validate(workflow *Workflow) error {
+	l.Tag = strings.TrimSpace(l.Tag)
+	if workflow.Status(l.Status) == nil {
+		return fmt.Errorf("unknown status %q; the workflow has: %s", l.Status, workflow.StatusList())
+	}
+	if l.Max < 1 {
+		return errors.New("max must be at least 1")
+	}
+	switch l.Enforcement {
+	case "":
+		l.Enforcement = WIPSoft
+	case WIPStrict, WIPSoft:
+	default:
+		return errors.New("enforcement must be strict or soft")
+	}
+	return nil
+}
+
+// Applies reports whether the task counts towards the limit.
+func (l *WIPLimit) Applies(task *Task) bool {
+	if task.Status != l.Status {
+		return false
+	}
+	if l.Tag != "" && !containsString(task.Tags, l.Tag) {
+		return false
+	}
+	return !l.PerAssignee || task.AssignedTo != nil
+}
+
+// SameGroup reports whether two tasks the limit applies to are counted
+// together.
+func (l *WIPLimit) SameGroup(a, b *Task) bool {
+	return !l.PerAssignee || *a.AssignedTo == *b.AssignedTo
+}
+
+// Filter selects the tasks of the project counted together with task.
+func (l *WIPLimit) Filter(projectID primitive.ObjectID, task *Task) TaskFilter {
+	filter := TaskFilter{ProjectID: &projectID, Status: []TaskStatus{l.Status}}
+	if l.Tag != "" {
+		filter.Tags = []string{l.Tag}
+	}
+	if l.PerAssignee {
+		filter.AssignedTo = task.AssignedTo
+	}
+	return filter
+}
+
+// String describes the limit for messages, e.g. "in_progress tagged
+// backend, per assignee: 3".
+func (l *WIPLimit) String() string {
+	var b strings.Builder
+	b.WriteString(string(l.Status))
+	if l.Tag != "" {
+		fmt.Fprintf(&b, " tagged %s", l.Tag)
+	}
+	if l.PerAssignee {
+		b.WriteString(", per assignee")
+	}
+	fmt.Fprintf(&b, ": %d", l.Max)
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// StatusCategory groups workflow statuses by what they mean for the task:
+// not started, being worked on, or finished. Dependencies, subtask rollups,
+// recurrence and due date filters only look at the category, so they work
+// the same with any workflow.
+type StatusCategory string
+
+const (
+	CategoryTodo   StatusCategory = "todo"
+	CategoryActive StatusCategory = "active"
+	CategoryDone   StatusCategory = "done"
+)
+
+// Requirements a status can place on the tasks entering it, besides the keys
+// of custom fields.
+const (
+	RequireAssignee    = "assigned_to"
+	RequireDueDate     = "due_date"
+	RequireDescription = "description"
+	RequireResolution  = "resolution"
+)
+
+// statusKeyPattern matches status keys such as "todo" or "in_review".
+var statusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// WorkflowStatus is one status of a workflow. Requires lists what a task
+// must have before it can enter the status: one of the Require constants or
+// the key of a custom field of the project.
+type WorkflowStatus struct {
+	Key      TaskStatus     `json:"key" bson:"key"`
+	Name     string         `json:"name" bson:"name"`
+	Category StatusCategory `json:"category" bson:"category"`
+	Requires []string       `json:"requires,omitempty" bson:"requires,omitempty"`
+}
+
+// WorkflowTransition lists the statuses a task may move to from From.
+type WorkflowTransition struct {
+	From TaskStatus   `json:"from" bson:"from"`
+	To   []TaskStatus `json:"to" bson:"to"`
+}
+
+// Workflow defines the statuses tasks of a project go through. New tasks
+// start in Initial, and a task can only move along the listed transitions.
+type Workflow struct {
+	Initial     TaskStatus           `json:"initial" bson:"initial"`
+	Statuses    []WorkflowStatus     `json:"statuses" bson:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions" bson:"transitions"`
+}
+
+// DefaultWorkflow returns the workflow of personal tasks and of projects that
+// have not defined their own: todo, in_progress and completed, with every
+// transition allowed.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Initial: StatusTodo,
+		Statuses: []WorkflowStatus{
+			{Key: StatusTodo, Name: "To do", Category: CategoryTodo},
+			{Key: StatusInProgress, Name: "In progress", Category: CategoryActive},
+			{Key: StatusCompleted, Name: "Completed", Category: CategoryDone},
+		},
+		Transitions: []WorkflowTransition{
+			{From: StatusTodo, To: []TaskStatus{StatusInProgress, StatusCompleted}},
+			{From: StatusInProgress, To: []TaskStatus{StatusTodo, StatusCompleted}},
+			{From: StatusCompleted, To: []TaskStatus{StatusTodo, StatusInProgress}},
+		},
+	}
+}
+
+// DefaultStatusCategory returns the category of status in the default
+// workflow. Statuses it does not know count as not started.
+func DefaultStatusCategory(status TaskStatus) StatusCategory {
+	switch status {
+	case StatusInProgress:
+		return CategoryActive
+	case StatusCompleted:
+		return CategoryDone
+	}
+	return CategoryTodo
+}
+
+// ValidStatusCategory reports whether category is one of the known status
+// categories.
+func ValidStatusCategory(category StatusCategory) bool {
+	switch category {
+	case CategoryTodo, CategoryActive, CategoryDone:
+		return true
+	}
+	return false
+}
+
+// Status returns the workflow's status with the given key, or nil.
+func (w *Workflow) Status(key TaskStatus) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].Key == key {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// Next returns the statuses a task in from may move to.
+func (w *Workflow) Next(from TaskStatus) []TaskStatus {
+	var next []TaskStatus
+	for _, transition := range w.Transitions {
+		if transition.From == from {
+			next = append(next, transition.To...)
+		}
+	}
+	return next
+}
+
+// CanTransition reports whether a task may move from one status to another.
+// Staying in the same status is always allowed.
+func (w *Workflow) CanTransition(from, to TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range w.Next(from) {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusList returns the keys of the workflow's statuses, comma separated,
+// for error messages.
+func (w *Workflow) StatusList() string {
+	keys := make([]string, 0, len(w.Statuses))
+	for _, status := range w.Statuses {
+		keys = append(keys, string(status.Key))
+	}
+	return strings.Join(keys, ", ")
+}
+
+// Validate checks that the workflow is well formed. Custom field
+// requirements are checked against the project by the caller; isField
+// reports whether a key names one of its fields.
+func (w *Workflow) Validate(isField func(key string) bool) error {
+	if len(w.Statuses) == 0 {
+		return errors.New("a workflow needs at least one status")
+	}
+	seen := make(map[TaskStatus]bool, len(w.Statuses))
+	for i := range w.Statuses {
+		status := &w.Statuses[i]
+		status.Name = strings.TrimSpace(status.Name)
+		if !statusKeyPattern.MatchString(string(status.Key)) {
+			return fmt.Errorf("invalid status key %q: use lowercase letters, digits and underscores", status.Key)
+		}
+		if seen[status.Key] {
+			return fmt.Errorf("status %q is listed twice", status.Key)
+		}
+		seen[status.Key] = true
+		if status.Name == "" {
+			status.Name = string(status.Key)
+		}
+		if !ValidStatusCategory(status.Category) {
+			return fmt.Errorf("status %q: category must be one of todo, active, done", status.Key)
+		}
+		for _, required := range status.Requires {
+			switch required {
+			case RequireAssignee, RequireDueDate, RequireDescription, RequireResolution:
+			default:
+				if isField == nil || !isField(required) {
+					return fmt.Errorf("status %q requires unknown field %q", status.Key, required)
+				}
+			}
+		}
+	}
+
+	initial := w.Status(w.Initial)
+	if initial == nil {
+		return fmt.Errorf("initial status %q is not one of the workflow's statuses", w.Initial)
+	}
+	if len(initial.Requires) > 0 {
+		return fmt.Errorf("initial status %q cannot require fields", w.Initial)
+	}
+
+	for _, transition := range w.Transitions {
+		if !seen[transition.From] {
+			return fmt.Errorf("transition from unknown status %q", transition.From)
+		}
+		for _, to := range transition.To {
+			if !seen[to] {
+				return fmt.Errorf("transition from %q to unknown status %q", transition.From, to)
+			}
+		}
+	}
+	return nil
+}
+
+// TaskWorkflow returns the project's workflow, or the default workflow if the
+// project has none. A nil project has the default workflow.
+func (p *Project) TaskWorkflow() *Workflow {
+	if p == nil || p.Workflow == nil {
+		return DefaultWorkflow()
+	}
+	return p.Workflow
+}
+
+// Category returns the category of the task's status. Tasks stored before
+// workflows existed have none and fall back to the default workflow.
+func (t *Task) Category() StatusCategory {
+	if t.StatusCategory != "" {
+		return t.StatusCategory
+	}
+	return DefaultStatusCategory(t.Status)
+}
+
+// Done reports whether the task is in a finished status.
+func (t *Task) Done() bool {
+	return t.Category() == CategoryDone
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkLogSource tells how a work log was recorded.
+type WorkLogSource string
+
+const (
+	WorkLogTimer  WorkLogSource = "timer"
+	WorkLogManual WorkLogSource = "manual"
+)
+
+// WorkLog is time a user spent on a task, in seconds. Timers start out
+// Running with no EndedAt or Duration; a user has at most one running timer.
+// ProjectID is the project the task was in when the time was logged.
+type WorkLog struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	UserID    primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Source    WorkLogSource       `json:"source" bson:"source"`
+	Note      string              `json:"note,omitempty" bson:"note,omitempty"`
+	StartedAt time.Time           `json:"started_at" bson:"started_at"`
+	EndedAt   *time.Time          `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	Duration  int64               `json:"duration" bson:"duration"`
+	Running   bool                `json:"running" bson:"running"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// Elapsed returns the time logged so far, counting a running timer up to
+// now.
+func (l *WorkLog) Elapsed(now time.Time) int64 {
+	if l.Running {
+		return int64(now.Sub(l.StartedAt) / time.Second)
+	}
+	return l.Duration
+}
+
+// TaskTime compares the time logged on a task with its estimate, both in
+// seconds. Spent counts running timers up to now, and Running says how many
+// there are. Remaining is only set for tasks with an estimate. It is
+// computed on read and never stored.
+type TaskTime struct {
+	Estimate  int64  `json:"estimate"`
+	Spent     int64  `json:"spent"`
+	Remaining *int64 `json:"remaining,omitempty"`
+	Running   int    `json:"running"`
+}
+
+// SumWorkLogs totals the logs of a task against its estimate as of now.
+func SumWorkLogs(estimate int64, logs []*WorkLog, now time.Time) *TaskTime {
+	total := &TaskTime{Estimate: estimate}
+	for _, log := range logs {
+		if log.Running {
+			total.Running++
+		}
+		total.Spent += log.Elapsed(now)
+	}
+	if estimate > 0 {
+		remaining := estimate - total.Spent
+		if remaining < 0 {
+			remaining = 0
+		}
+		total.Remaining = &remaining
+	}
+	return total
+}
@@ -0,0 +1,94 @@
+// Package rank generates lexicographic ranks for manually ordered lists.
+// A rank is a string of base-36 digits (0-9, a-z) that never ends in "0";
+// items are ordered by comparing their ranks as plain strings. Between
+// finds a rank that falls between two others without touching them, so
+// moving an item only rewrites that item. Ranks grow as items are moved
+// into the same gap again and again; once one gets longer than MaxLength
+// the list should be given fresh ranks with Spread.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength is the longest rank Between should be trusted to return before
+// the list is rebalanced.
+const MaxLength = 12
+
+var (
+	ErrInvalid   = errors.New("invalid rank")
+	ErrNotBefore = errors.New("ranks are not in order")
+)
+
+// Valid reports whether rank is a well-formed rank.
+func Valid(rank string) bool {
+	if rank == "" || strings.HasSuffix(rank, "0") {
+		return false
+	}
+	for _, r := range rank {
+		if !strings.ContainsRune(digits, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a rank that sorts after prev and before next. An empty
+// prev stands for the start of the list and an empty next for its end.
+func Between(prev, next string) (string, error) {
+	if (prev != "" && !Valid(prev)) || (next != "" && !Valid(next)) {
+		return "", ErrInvalid
+	}
+	if prev != "" && next != "" && prev >= next {
+		return "", ErrNotBefore
+	}
+
+	var result []byte
+	bounded := next != ""
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(prev) {
+			low = strings.IndexByte(digits, prev[i])
+		}
+		high := base
+		if bounded && i < len(next) {
+			high = strings.IndexByte(digits, next[i])
+		}
+
+		if high-low > 1 {
+			return string(append(result, digits[(low+high)/2])), nil
+		}
+		result = append(result, digits[low])
+		if high-low == 1 {
+			// Anything that continues from low is now below next.
+			bounded = false
+		}
+	}
+}
+
+// Spread returns n ranks in ascending order, spaced evenly with room
+// between them for later moves.
+func Spread(n int) []string {
+	width, space := 1, base
+	for space < (n+1)*base {
+		width++
+		space *= base
+	}
+
+	ranks := make([]string, n)
+	buf := make([]byte, width)
+	for i := range ranks {
+		value := (i + 1) * space / (n + 1)
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = digits[value%base]
+			value /= base
+		}
+		ranks[i] = strings.TrimRight(string(buf), "0")
+	}
+	return ranks
+}
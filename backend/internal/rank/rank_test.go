@@ -0,0 +1,152 @@
+package rank
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		rank string
+		want bool
+	}{
+		{"i", true},
+		{"0i", true},
+		{"az9", true},
+		{"", false},
+		{"0", false},
+		{"a0", false},
+		{"A", false},
+		{"a-b", false},
+		{"é", false},
+	}
+	for _, tc := range tests {
+		if got := Valid(tc.rank); got != tc.want {
+			t.Errorf("Valid(%q) = %v, want %v", tc.rank, got, tc.want)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next string
+		want       string
+	}{
+		{name: "empty list", want: "i"},
+		{name: "before the first", next: "i", want: "9"},
+		{name: "after the last", prev: "i", want: "r"},
+		{name: "before the smallest digit", next: "1", want: "0i"},
+		{name: "after the largest digit", prev: "z", want: "zi"},
+		{name: "wide gap", prev: "a", next: "k", want: "f"},
+		{name: "adjacent digits", prev: "a", next: "b", want: "ai"},
+		{name: "next extends prev", prev: "a", next: "a1", want: "a0i"},
+		{name: "prev is longer", prev: "az", next: "b", want: "azi"},
+		{name: "next is longer", prev: "a", next: "b5", want: "ai"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Between(tc.prev, tc.next)
+			if err != nil {
+				t.Fatalf("Between(%q, %q): %v", tc.prev, tc.next, err)
+			}
+			if got != tc.want {
+				t.Errorf("Between(%q, %q) = %q, want %q", tc.prev, tc.next, got, tc.want)
+			}
+			if !Valid(got) || (tc.prev != "" && got <= tc.prev) || (tc.next != "" && got >= tc.next) {
+				t.Errorf("Between(%q, %q) = %q, which is not a rank between them", tc.prev, tc.next, got)
+			}
+		})
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	tests := []struct {
+		prev, next string
+		want       error
+	}{
+		{prev: "b", next: "a", want: ErrNotBefore},
+		{prev: "a", next: "a", want: ErrNotBefore},
+		{prev: "a0", want: ErrInvalid},
+		{next: "A", want: ErrInvalid},
+		{prev: "a", next: "?", want: ErrInvalid},
+	}
+	for _, tc := range tests {
+		if _, err := Between(tc.prev, tc.next); !errors.Is(err, tc.want) {
+			t.Errorf("Between(%q, %q) error = %v, want %v", tc.prev, tc.next, err, tc.want)
+		}
+	}
+}
+
+// Moving items into the same gap over and over makes ranks longer; they stay
+// in order, and eventually outgrow MaxLength, which calls for a rebalance.
+func TestBetweenSameGapGrows(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		front bool
+	}{
+		{name: "front", front: true},
+		{name: "back", front: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			prev, next := "a", "b"
+			outgrown := false
+			for i := 0; i < 200; i++ {
+				r, err := Between(prev, next)
+				if err != nil {
+					t.Fatalf("move %d: Between(%q, %q): %v", i, prev, next, err)
+				}
+				if !(prev < r && r < next) {
+					t.Fatalf("move %d: Between(%q, %q) = %q, out of order", i, prev, next, r)
+				}
+				if len(r) > MaxLength {
+					outgrown = true
+				}
+				if tc.front {
+					next = r
+				} else {
+					prev = r
+				}
+			}
+			if !outgrown {
+				t.Errorf("ranks never grew past MaxLength (%d)", MaxLength)
+			}
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	if got := Spread(0); len(got) != 0 {
+		t.Errorf("Spread(0) = %v, want none", got)
+	}
+	if got := Spread(1); len(got) != 1 || got[0] != "i" {
+		t.Errorf("Spread(1) = %v, want [i]", got)
+	}
+
+	for _, n := range []int{2, 35, 36, 1000, 50000} {
+		ranks := Spread(n)
+		if len(ranks) != n {
+			t.Fatalf("Spread(%d) returned %d ranks", n, len(ranks))
+		}
+		for i, r := range ranks {
+			if !Valid(r) {
+				t.Fatalf("Spread(%d)[%d] = %q, not a valid rank", n, i, r)
+			}
+			if len(r) > MaxLength {
+				t.Fatalf("Spread(%d)[%d] = %q, longer than MaxLength", n, i, r)
+			}
+			if i == 0 {
+				continue
+			}
+			if ranks[i-1] >= r {
+				t.Fatalf("Spread(%d) out of order at %d: %q >= %q", n, i, ranks[i-1], r)
+			}
+			// There is room for a move between neighbours without the
+			// rank growing by more than a digit.
+			between, err := Between(ranks[i-1], r)
+			if err != nil || len(between) > max(len(ranks[i-1]), len(r))+1 {
+				t.Fatalf("Spread(%d): Between(%q, %q) = %q, %v", n, ranks[i-1], r, between, err)
+			}
+		}
+	}
+}
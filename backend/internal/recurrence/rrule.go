@@ -0,0 +1,391 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used
+// for repeating tasks: FREQ=DAILY/WEEKLY/MONTHLY/YEARLY with INTERVAL, BYDAY,
+// BYMONTHDAY, COUNT and UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxEmptyPeriods bounds how many consecutive periods may produce no
+// occurrence before iteration gives up, so rules such as BYMONTHDAY=31 on a
+// short interval cannot loop forever.
+const maxEmptyPeriods = 1000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Day is a BYDAY entry. Ordinal is 0 for "every such weekday", otherwise the
+// nth (or, when negative, nth from last) weekday in the month or year.
+type Day struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []Day
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE". A leading
+// "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return nil, errors.New("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if seen[name] {
+			return nil, fmt.Errorf("%s given more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = Frequency(value)
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive integer, got %q", value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive integer, got %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				day, err := parseDay(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY values must be between 1 and 31 or -31 and -1, got %q", item)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be combined")
+	}
+	if rule.Freq == Daily || rule.Freq == Weekly {
+		for _, day := range rule.ByDay {
+			if day.Ordinal != 0 {
+				return nil, fmt.Errorf("BYDAY ordinals are only allowed with MONTHLY or YEARLY rules")
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+// String renders the rule in canonical RRULE form, without the "RRULE:"
+// prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d Day) String() string {
+	code := ""
+	for name, weekday := range weekdayCodes {
+		if weekday == d.Weekday {
+			code = name
+		}
+	}
+	if d.Ordinal == 0 {
+		return code
+	}
+	return strconv.Itoa(d.Ordinal) + code
+}
+
+// Occurrences returns up to n occurrences of the rule anchored at dtstart,
+// starting with occurrence number from (1 is dtstart itself).
+func (r *Rule) Occurrences(dtstart time.Time, from, n int) []time.Time {
+	var out []time.Time
+	r.each(dtstart, func(index int, t time.Time) bool {
+		if index >= from {
+			out = append(out, t)
+		}
+		return len(out) < n
+	})
+	return out
+}
+
+// Nth returns occurrence number index (1 is dtstart itself), or false if the
+// series ends before it.
+func (r *Rule) Nth(dtstart time.Time, index int) (time.Time, bool) {
+	occurrences := r.Occurrences(dtstart, index, 1)
+	if len(occurrences) == 0 {
+		return time.Time{}, false
+	}
+	return occurrences[0], true
+}
+
+// each calls fn with every occurrence in order until fn returns false or the
+// series ends. As in RFC 5545, dtstart is always the first occurrence.
+func (r *Rule) each(dtstart time.Time, fn func(index int, t time.Time) bool) {
+	index := 1
+	if !r.within(index, dtstart) || !fn(index, dtstart) {
+		return
+	}
+
+	empty := 0
+	for period := 0; empty < maxEmptyPeriods; period++ {
+		candidates := r.expand(dtstart, period)
+		if len(candidates) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			index++
+			if !r.within(index, t) || !fn(index, t) {
+				return
+			}
+		}
+	}
+}
+
+func (r *Rule) within(index int, t time.Time) bool {
+	if r.Count > 0 && index > r.Count {
+		return false
+	}
+	if r.Until != nil && t.After(*r.Until) {
+		return false
+	}
+	return true
+}
+
+// expand lists the occurrences that fall in the given period (counted in
+// units of INTERVAL from the period containing dtstart), in order.
+func (r *Rule) expand(dtstart time.Time, period int) []time.Time {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	step := period * r.Interval
+
+	var first, last time.Time
+	switch r.Freq {
+	case Daily:
+		first = time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+		last = first
+	case Weekly:
+		// Weeks start on Monday (WKST=MO).
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		first = time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+		last = first.AddDate(0, 0, 6)
+	case Monthly:
+		first = time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+		last = first.AddDate(0, 1, -1)
+	case Yearly:
+		first = time.Date(y+step, time.January, 1, 0, 0, 0, 0, loc)
+		last = time.Date(y+step, time.December, 31, 0, 0, 0, 0, loc)
+	}
+
+	hour, min, sec := dtstart.Clock()
+	var out []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if r.matches(dtstart, day) {
+			dy, dm, dd := day.Date()
+			out = append(out, time.Date(dy, dm, dd, hour, min, sec, dtstart.Nanosecond(), loc))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// matches reports whether day is selected by the rule's BYxxx parts, falling
+// back to the day implied by dtstart when none are given.
+func (r *Rule) matches(dtstart, day time.Time) bool {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		switch r.Freq {
+		case Weekly:
+			return day.Weekday() == dtstart.Weekday()
+		case Monthly:
+			return day.Day() == dtstart.Day()
+		case Yearly:
+			return day.Month() == dtstart.Month() && day.Day() == dtstart.Day()
+		}
+		return true
+	}
+
+	if len(r.ByMonthDay) > 0 && !matchesMonthDay(r.ByMonthDay, day) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !r.matchesDay(day) {
+		return false
+	}
+	return true
+}
+
+func matchesMonthDay(monthDays []int, day time.Time) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, n := range monthDays {
+		if n > 0 && day.Day() == n {
+			return true
+		}
+		if n < 0 && day.Day() == daysInMonth+n+1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesDay(day time.Time) bool {
+	for _, byDay := range r.ByDay {
+		if byDay.Weekday != day.Weekday() {
+			continue
+		}
+		if byDay.Ordinal == 0 {
+			return true
+		}
+
+		// Ordinals count within the month for MONTHLY rules and within the
+		// year for YEARLY rules.
+		var position, total int
+		if r.Freq == Monthly {
+			daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+			position = (day.Day()-1)/7 + 1
+			total = position + (daysInMonth-day.Day())/7
+		} else {
+			daysInYear := time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, day.Location()).YearDay()
+			position = (day.YearDay()-1)/7 + 1
+			total = position + (daysInYear-day.YearDay())/7
+		}
+
+		if byDay.Ordinal > 0 && position == byDay.Ordinal {
+			return true
+		}
+		if byDay.Ordinal < 0 && position == total+byDay.Ordinal+1 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDay(s string) (Day, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return Day{}, fmt.Errorf("invalid BYDAY value %q", s)
+	}
+	code := s[len(s)-2:]
+	weekday, ok := weekdayCodes[code]
+	if !ok {
+		return Day{}, fmt.Errorf("invalid BYDAY weekday %q", code)
+	}
+
+	day := Day{Weekday: weekday}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return Day{}, fmt.Errorf("invalid BYDAY ordinal in %q", s)
+		}
+		day.Ordinal = n
+	}
+	return day, nil
+}
+
+func parseUntil(s string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102T150405", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102", s); err == nil {
+		// A date-only UNTIL includes the whole day.
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q: use YYYYMMDD or YYYYMMDDTHHMMSSZ", s)
+}
@@ -0,0 +1,214 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "RRULE:freq=weekly;byday=mo,we", want: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{rule: "FREQ=MONTHLY;INTERVAL=1;BYDAY=-1FR", want: "FREQ=MONTHLY;BYDAY=-1FR"},
+		{rule: "FREQ=YEARLY;INTERVAL=2;COUNT=3", want: "FREQ=YEARLY;INTERVAL=2;COUNT=3"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20241231T235959Z", want: "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20241231T235959Z"},
+		{rule: "", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=HOURLY", wantErr: true},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=-1", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20240101", wantErr: true},
+		{rule: "FREQ=DAILY;UNTIL=2024-01-01", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYDAY=0MO", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=0", wantErr: true},
+		{rule: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+		{rule: "FREQ", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.rule, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want an error", tc.rule, rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tc.rule, err)
+			}
+			if got := rule.String(); got != tc.want {
+				t.Errorf("Parse(%q).String() = %q, want %q", tc.rule, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(loc *time.Location, year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		n       int
+		want    []string
+	}{
+		{
+			name:    "daily interval",
+			rule:    "FREQ=DAILY;INTERVAL=2",
+			dtstart: at(time.UTC, 2024, time.January, 1, 9),
+			n:       3,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-03T09:00:00Z", "2024-01-05T09:00:00Z"},
+		},
+		{
+			name:    "weekly on several days",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			dtstart: at(time.UTC, 2024, time.January, 3, 9),
+			n:       4,
+			want:    []string{"2024-01-03T09:00:00Z", "2024-01-05T09:00:00Z", "2024-01-08T09:00:00Z", "2024-01-10T09:00:00Z"},
+		},
+		{
+			name:    "fortnightly skips a week",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			dtstart: at(time.UTC, 2024, time.January, 2, 9),
+			n:       4,
+			want:    []string{"2024-01-02T09:00:00Z", "2024-01-04T09:00:00Z", "2024-01-16T09:00:00Z", "2024-01-18T09:00:00Z"},
+		},
+		{
+			name:    "second tuesday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=2TU",
+			dtstart: at(time.UTC, 2024, time.January, 9, 9),
+			n:       3,
+			want:    []string{"2024-01-09T09:00:00Z", "2024-02-13T09:00:00Z", "2024-03-12T09:00:00Z"},
+		},
+		{
+			name:    "last friday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: at(time.UTC, 2024, time.January, 26, 9),
+			n:       3,
+			want:    []string{"2024-01-26T09:00:00Z", "2024-02-23T09:00:00Z", "2024-03-29T09:00:00Z"},
+		},
+		{
+			name:    "last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: at(time.UTC, 2024, time.January, 31, 9),
+			n:       4,
+			want:    []string{"2024-01-31T09:00:00Z", "2024-02-29T09:00:00Z", "2024-03-31T09:00:00Z", "2024-04-30T09:00:00Z"},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rule:    "FREQ=MONTHLY",
+			dtstart: at(time.UTC, 2024, time.January, 31, 9),
+			n:       3,
+			want:    []string{"2024-01-31T09:00:00Z", "2024-03-31T09:00:00Z", "2024-05-31T09:00:00Z"},
+		},
+		{
+			name:    "yearly on a leap day",
+			rule:    "FREQ=YEARLY",
+			dtstart: at(time.UTC, 2024, time.February, 29, 9),
+			n:       2,
+			want:    []string{"2024-02-29T09:00:00Z", "2028-02-29T09:00:00Z"},
+		},
+		{
+			name:    "count ends the series",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: at(time.UTC, 2024, time.January, 1, 9),
+			n:       5,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-02T09:00:00Z", "2024-01-03T09:00:00Z"},
+		},
+		{
+			name:    "until is inclusive",
+			rule:    "FREQ=DAILY;UNTIL=20240103T090000Z",
+			dtstart: at(time.UTC, 2024, time.January, 1, 9),
+			n:       5,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-02T09:00:00Z", "2024-01-03T09:00:00Z"},
+		},
+		{
+			name:    "date-only until covers the whole day",
+			rule:    "FREQ=WEEKLY;UNTIL=20240115",
+			dtstart: at(time.UTC, 2024, time.January, 1, 23),
+			n:       5,
+			want:    []string{"2024-01-01T23:00:00Z", "2024-01-08T23:00:00Z", "2024-01-15T23:00:00Z"},
+		},
+		{
+			name:    "until before dtstart yields nothing",
+			rule:    "FREQ=DAILY;UNTIL=20231231",
+			dtstart: at(time.UTC, 2024, time.January, 1, 9),
+			n:       2,
+			want:    nil,
+		},
+		{
+			name:    "wall clock kept when DST starts",
+			rule:    "FREQ=WEEKLY",
+			dtstart: at(newYork, 2024, time.March, 4, 9),
+			n:       2,
+			want:    []string{"2024-03-04T09:00:00-05:00", "2024-03-11T09:00:00-04:00"},
+		},
+		{
+			name:    "wall clock kept when DST ends",
+			rule:    "FREQ=DAILY",
+			dtstart: at(newYork, 2024, time.November, 2, 9),
+			n:       3,
+			want:    []string{"2024-11-02T09:00:00-04:00", "2024-11-03T09:00:00-05:00", "2024-11-04T09:00:00-05:00"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tc.rule, err)
+			}
+			var got []string
+			for _, occurrence := range rule.Occurrences(tc.dtstart, 1, tc.n) {
+				got = append(got, occurrence.Format(time.RFC3339))
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Occurrences = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("Occurrences = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNth(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;COUNT=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtstart := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		index int
+		want  time.Time
+		ok    bool
+	}{
+		{index: 1, want: dtstart, ok: true},
+		{index: 3, want: dtstart.AddDate(0, 0, 14), ok: true},
+		{index: 4, ok: false},
+	}
+	for _, tc := range tests {
+		got, ok := rule.Nth(dtstart, tc.index)
+		if ok != tc.ok || !got.Equal(tc.want) {
+			t.Errorf("Nth(%d) = %v, %v; want %v, %v", tc.index, got, ok, tc.want, tc.ok)
+		}
+	}
+}
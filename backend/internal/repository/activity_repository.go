@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ActivityRepository stores task activity in an insert-only collection.
+type ActivityRepository struct {
+	collection *mongo.Collection
+}
+
+func NewActivityRepository() *ActivityRepository {
+	return &ActivityRepository{
+		collection: database.GetDB().Collection("task_activity"),
+	}
+}
+
+// EnsureIndexes creates the index used to page through a task's history.
+func (r *ActivityRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func (r *ActivityRepository) Append(ctx context.Context, activity *models.Activity) error {
+	if activity.At.IsZero() {
+		activity.At = time.Now()
+	}
+	if activity.ID.IsZero() {
+		activity.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, activity)
+	return err
+}
+
+func (r *ActivityRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID, cursor *primitive.ObjectID, limit int) ([]*models.Activity, error) {
+	filter := bson.M{"task_id": taskID}
+	if cursor != nil {
+		filter["_id"] = bson.M{"$lt": *cursor}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	return r.find(ctx, filter, opts)
+}
+
+func (r *ActivityRepository) FindSince(ctx context.Context, taskID primitive.ObjectID, since time.Time) ([]*models.Activity, error) {
+	filter := bson.M{"task_id": taskID, "at": bson.M{"$gt": since}}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	return r.find(ctx, filter, opts)
+}
+
+func (r *ActivityRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Activity, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var activities []*models.Activity
+	if err = cursor.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
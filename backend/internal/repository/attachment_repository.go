@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttachmentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAttachmentRepository() *AttachmentRepository {
+	return &AttachmentRepository{
+		collection: database.GetDB().Collection("attachments"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to list a task's attachments and
+// to find the attachments waiting for their blobs to be deleted.
+func (r *AttachmentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	attachment.CreatedAt = time.Now()
+	if attachment.ID.IsZero() {
+		attachment.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, attachment)
+	return err
+}
+
+func (r *AttachmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&attachment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.Attachment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	return r.find(ctx, bson.M{"task_id": taskID, "deleted_at": nil}, opts)
+}
+
+func (r *AttachmentRepository) FindDeleted(ctx context.Context, limit int) ([]*models.Attachment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: 1}}).SetLimit(int64(limit))
+	return r.find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}}, opts)
+}
+
+func (r *AttachmentRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Attachment, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attachments []*models.Attachment
+	if err = cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) MarkDeleted(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": at}},
+	)
+	return err
+}
+
+func (r *AttachmentRepository) MarkDeletedByTask(ctx context.Context, taskID primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"task_id": taskID, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": at}},
+	)
+	return err
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCommentRepository() *CommentRepository {
+	return &CommentRepository{
+		collection: database.GetDB().Collection("comments"),
+	}
+}
+
+// EnsureIndexes creates the index used to list a task's thread in order.
+func (r *CommentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, comment)
+	if err != nil {
+		return err
+	}
+
+	comment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CommentRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.CommentUpdate) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"body":       update.Body,
+				"mentions":   update.Mentions,
+				"edited_at":  now,
+				"updated_at": now,
+			},
+			"$push": bson.M{"edits": update.Previous},
+		},
+	)
+	return err
+}
+
+func (r *CommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.Comment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []*models.Comment
+	if err = cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *CommentRepository) DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
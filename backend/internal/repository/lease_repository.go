@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LeaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLeaseRepository() *LeaseRepository {
+	return &LeaseRepository{
+		collection: database.GetDB().Collection("leases"),
+	}
+}
+
+// Acquire takes the lease when it is free or expired, or renews it for its
+// holder, in one atomic update. When another holder has the lease, the
+// filter matches nothing and the upsert collides with the existing document
+// on _id.
+func (r *LeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*models.Lease, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"holder": holder, "expires_at": now.Add(ttl)}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var lease models.Lease
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&lease); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func (r *LeaseRepository) Advance(ctx context.Context, name, holder string, cursor time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "holder": holder, "expires_at": bson.M{"$gt": time.Now()}},
+		bson.M{"$set": bson.M{"cursor": cursor}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *LeaseRepository) Release(ctx context.Context, name, holder string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "holder": holder},
+		bson.M{"$set": bson.M{"expires_at": time.Now()}},
+	)
+	return err
+}
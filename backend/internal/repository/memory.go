@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// cloneDocument deep-copies a document by round-tripping it through BSON, so
+// the in-memory stores hand out values with the same shape (and the same
+// millisecond time precision) as documents read back from MongoDB.
+func cloneDocument[T any](src *T) (*T, error) {
+	data, err := bson.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+	var dst T
+	if err := bson.Unmarshal(data, &dst); err != nil {
+		return nil, err
+	}
+	return &dst, nil
+}
+
+// duplicateKeyError builds the error MongoDB reports for a unique index
+// violation, so callers can use mongo.IsDuplicateKeyError with either backend.
+func duplicateKeyError(field string) error {
+	return mongo.WriteException{
+		WriteErrors: mongo.WriteErrors{{
+			Code:    11000,
+			Message: fmt.Sprintf("E11000 duplicate key error: %s", field),
+		}},
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryActivityRepository is a thread-safe, in-memory ActivityStore.
+type MemoryActivityRepository struct {
+	mu         sync.RWMutex
+	activities []*models.Activity
+}
+
+func NewMemoryActivityRepository() *MemoryActivityRepository {
+	return &MemoryActivityRepository{}
+}
+
+func (r *MemoryActivityRepository) Append(ctx context.Context, activity *models.Activity) error {
+	if activity.At.IsZero() {
+		activity.At = time.Now()
+	}
+	if activity.ID.IsZero() {
+		activity.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(activity)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activities = append(r.activities, stored)
+	return nil
+}
+
+func (r *MemoryActivityRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID, cursor *primitive.ObjectID, limit int) ([]*models.Activity, error) {
+	return r.find(limit, func(activity *models.Activity) bool {
+		return activity.TaskID == taskID && (cursor == nil || activity.ID.Hex() < cursor.Hex())
+	})
+}
+
+func (r *MemoryActivityRepository) FindSince(ctx context.Context, taskID primitive.ObjectID, since time.Time) ([]*models.Activity, error) {
+	return r.find(0, func(activity *models.Activity) bool {
+		return activity.TaskID == taskID && activity.At.After(since)
+	})
+}
+
+// find returns the matching activities newest first. Activities are
+// appended in ID order, so walking the log backwards is enough.
+func (r *MemoryActivityRepository) find(limit int, match func(*models.Activity) bool) ([]*models.Activity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var activities []*models.Activity
+	for i := len(r.activities) - 1; i >= 0; i-- {
+		if limit > 0 && len(activities) == limit {
+			break
+		}
+		if !match(r.activities[i]) {
+			continue
+		}
+		clone, err := cloneDocument(r.activities[i])
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, clone)
+	}
+	return activities, nil
+}
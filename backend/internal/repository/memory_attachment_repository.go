@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAttachmentRepository is a thread-safe, in-memory AttachmentStore.
+type MemoryAttachmentRepository struct {
+	mu          sync.RWMutex
+	attachments map[primitive.ObjectID]*models.Attachment
+}
+
+func NewMemoryAttachmentRepository() *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{
+		attachments: make(map[primitive.ObjectID]*models.Attachment),
+	}
+}
+
+func (r *MemoryAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	attachment.CreatedAt = time.Now()
+	if attachment.ID.IsZero() {
+		attachment.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(attachment)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attachments[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachment, ok := r.attachments[id]
+	if !ok || attachment.DeletedAt != nil {
+		return nil, nil
+	}
+	return cloneDocument(attachment)
+}
+
+func (r *MemoryAttachmentRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.Attachment, error) {
+	attachments, err := r.find(func(attachment *models.Attachment) bool {
+		return attachment.TaskID == taskID && attachment.DeletedAt == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return attachments[i].ID.Hex() < attachments[j].ID.Hex()
+	})
+	return attachments, nil
+}
+
+func (r *MemoryAttachmentRepository) FindDeleted(ctx context.Context, limit int) ([]*models.Attachment, error) {
+	attachments, err := r.find(func(attachment *models.Attachment) bool {
+		return attachment.DeletedAt != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].DeletedAt.Before(*attachments[j].DeletedAt)
+	})
+	if len(attachments) > limit {
+		attachments = attachments[:limit]
+	}
+	return attachments, nil
+}
+
+func (r *MemoryAttachmentRepository) find(match func(*models.Attachment) bool) ([]*models.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var attachments []*models.Attachment
+	for _, attachment := range r.attachments {
+		if !match(attachment) {
+			continue
+		}
+		clone, err := cloneDocument(attachment)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, clone)
+	}
+	return attachments, nil
+}
+
+func (r *MemoryAttachmentRepository) MarkDeleted(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attachment, ok := r.attachments[id]; ok && attachment.DeletedAt == nil {
+		attachment.DeletedAt = &at
+	}
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) MarkDeletedByTask(ctx context.Context, taskID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, attachment := range r.attachments {
+		if attachment.TaskID == taskID && attachment.DeletedAt == nil {
+			deletedAt := at
+			attachment.DeletedAt = &deletedAt
+		}
+	}
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attachments, id)
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCommentRepository is a thread-safe, in-memory CommentStore.
+type MemoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments map[primitive.ObjectID]*models.Comment
+}
+
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{
+		comments: make(map[primitive.ObjectID]*models.Comment),
+	}
+}
+
+func (r *MemoryCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = time.Now()
+	if comment.ID.IsZero() {
+		comment.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(comment)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comments[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryCommentRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.CommentUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	comment.Body = update.Body
+	comment.Mentions = append([]primitive.ObjectID(nil), update.Mentions...)
+	comment.Edits = append(comment.Edits, update.Previous)
+	comment.EditedAt = &now
+	comment.UpdatedAt = now
+
+	stored, err := cloneDocument(comment)
+	if err != nil {
+		return err
+	}
+	r.comments[id] = stored
+	return nil
+}
+
+func (r *MemoryCommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDocument(comment)
+}
+
+func (r *MemoryCommentRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var comments []*models.Comment
+	for _, comment := range r.comments {
+		if comment.TaskID != taskID {
+			continue
+		}
+		clone, err := cloneDocument(comment)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, clone)
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID.Hex() < comments[j].ID.Hex()
+	})
+	return comments, nil
+}
+
+func (r *MemoryCommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.comments, id)
+	return nil
+}
+
+func (r *MemoryCommentRepository) DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, comment := range r.comments {
+		if comment.TaskID == taskID {
+			delete(r.comments, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+)
+
+// MemoryLeaseRepository is a thread-safe, in-memory LeaseStore.
+type MemoryLeaseRepository struct {
+	mu     sync.Mutex
+	leases map[string]*models.Lease
+}
+
+func NewMemoryLeaseRepository() *MemoryLeaseRepository {
+	return &MemoryLeaseRepository{
+		leases: make(map[string]*models.Lease),
+	}
+}
+
+func (r *MemoryLeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*models.Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	lease, ok := r.leases[name]
+	if !ok {
+		lease = &models.Lease{Name: name}
+		r.leases[name] = lease
+	} else if lease.Holder != holder && lease.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	lease.Holder = holder
+	lease.ExpiresAt = now.Add(ttl)
+	return cloneDocument(lease)
+}
+
+func (r *MemoryLeaseRepository) Advance(ctx context.Context, name, holder string, cursor time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lease, ok := r.leases[name]
+	if !ok || lease.Holder != holder || !lease.ExpiresAt.After(time.Now()) {
+		return ErrLeaseLost
+	}
+	lease.Cursor = &cursor
+	return nil
+}
+
+func (r *MemoryLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lease, ok := r.leases[name]; ok && lease.Holder == holder {
+		lease.ExpiresAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryNotificationRepository is a thread-safe, in-memory NotificationStore.
+type MemoryNotificationRepository struct {
+	mu            sync.RWMutex
+	notifications map[primitive.ObjectID]*models.Notification
+}
+
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{
+		notifications: make(map[primitive.ObjectID]*models.Notification),
+	}
+}
+
+func (r *MemoryNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	notification.CreatedAt = time.Now()
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(notification)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored.Key != "" {
+		for _, existing := range r.notifications {
+			if existing.Key == stored.Key {
+				return duplicateKeyError("key")
+			}
+		}
+	}
+	r.notifications[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryNotificationRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, cursor *primitive.ObjectID, limit int) ([]*models.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notifications []*models.Notification
+	for _, notification := range r.notifications {
+		if notification.UserID != userID || (unreadOnly && notification.Read) {
+			continue
+		}
+		if cursor != nil && notification.ID.Hex() >= cursor.Hex() {
+			continue
+		}
+		clone, err := cloneDocument(notification)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, clone)
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].ID.Hex() > notifications[j].ID.Hex()
+	})
+	if limit > 0 && len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (r *MemoryNotificationRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && !notification.Read {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemoryNotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, ok := r.notifications[id]
+	if !ok || notification.UserID != userID {
+		return ErrNotificationNotFound
+	}
+	markRead(notification, at)
+	return nil
+}
+
+func (r *MemoryNotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && !notification.Read {
+			markRead(notification, at)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func markRead(notification *models.Notification, at time.Time) {
+	notification.Read = true
+	if notification.ReadAt == nil {
+		at = at.Truncate(time.Millisecond)
+		notification.ReadAt = &at
+	}
+}
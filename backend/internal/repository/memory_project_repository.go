@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryProjectRepository is a thread-safe, in-memory ProjectStore.
+type MemoryProjectRepository struct {
+	mu       sync.RWMutex
+	projects map[primitive.ObjectID]*models.Project
+}
+
+func NewMemoryProjectRepository() *MemoryProjectRepository {
+	return &MemoryProjectRepository{
+		projects: make(map[primitive.ObjectID]*models.Project),
+	}
+}
+
+func (r *MemoryProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+	project.Version = 1
+	if project.ID.IsZero() {
+		project.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(project)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.projects {
+		if existing.Key == project.Key {
+			return duplicateKeyError("key")
+		}
+	}
+	r.projects[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryProjectRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.ProjectUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return nil
+	}
+	if update.ExpectedVersion != nil && project.Version != *update.ExpectedVersion {
+		return ErrVersionConflict
+	}
+
+	project.UpdatedAt = time.Now()
+	project.Version++
+	if update.Name != nil {
+		project.Name = *update.Name
+	}
+	if update.Description != nil {
+		project.Description = *update.Description
+	}
+	if update.Archived != nil {
+		project.Archived = *update.Archived
+	}
+	if update.Members != nil {
+		project.Members = append([]models.ProjectMember(nil), (*update.Members)...)
+	}
+	if update.CustomFields != nil {
+		project.CustomFields = append([]models.CustomField(nil), (*update.CustomFields)...)
+	}
+	if update.Workflow != nil {
+		project.Workflow = update.Workflow
+	}
+	if update.WIPLimits != nil {
+		project.WIPLimits = append([]models.WIPLimit(nil), (*update.WIPLimits)...)
+	}
+
+	stored, err := cloneDocument(project)
+	if err != nil {
+		return err
+	}
+	r.projects[id] = stored
+	return nil
+}
+
+func (r *MemoryProjectRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDocument(project)
+}
+
+func (r *MemoryProjectRepository) FindByKey(ctx context.Context, key string) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, project := range r.projects {
+		if project.Key == key {
+			return cloneDocument(project)
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryProjectRepository) FindByMember(ctx context.Context, userID primitive.ObjectID, includeArchived bool) ([]*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*models.Project
+	for _, project := range r.projects {
+		if !project.CanView(userID) || (project.Archived && !includeArchived) {
+			continue
+		}
+		found, err := cloneDocument(project)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, found)
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+
+	return projects, nil
+}
+
+func (r *MemoryProjectRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.projects, id)
+	return nil
+}
+
+func (r *MemoryProjectRepository) NextTaskNumber(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return 0, mongo.ErrNoDocuments
+	}
+	project.TaskCounter++
+	return project.TaskCounter, nil
+}
@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/repository/storetest"
+)
+
+// TestMemoryStores runs the conformance suites against the in-memory
+// stores, each starting out empty.
+func TestMemoryStores(t *testing.T) {
+	suites := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"TaskStore", func(t *testing.T) {
+			storetest.TestTaskStore(t, func(*testing.T) repository.TaskStore {
+				return repository.NewMemoryTaskRepository()
+			})
+		}},
+		{"UserStore", func(t *testing.T) {
+			storetest.TestUserStore(t, func(*testing.T) repository.UserStore {
+				return repository.NewMemoryUserRepository()
+			})
+		}},
+		{"ProjectStore", func(t *testing.T) {
+			storetest.TestProjectStore(t, func(*testing.T) repository.ProjectStore {
+				return repository.NewMemoryProjectRepository()
+			})
+		}},
+		{"CommentStore", func(t *testing.T) {
+			storetest.TestCommentStore(t, func(*testing.T) repository.CommentStore {
+				return repository.NewMemoryCommentRepository()
+			})
+		}},
+		{"ActivityStore", func(t *testing.T) {
+			storetest.TestActivityStore(t, func(*testing.T) repository.ActivityStore {
+				return repository.NewMemoryActivityRepository()
+			})
+		}},
+		{"SavedViewStore", func(t *testing.T) {
+			storetest.TestSavedViewStore(t, func(*testing.T) repository.SavedViewStore {
+				return repository.NewMemorySavedViewRepository()
+			})
+		}},
+		{"WIPViolationStore", func(t *testing.T) {
+			storetest.TestWIPViolationStore(t, func(*testing.T) repository.WIPViolationStore {
+				return repository.NewMemoryWIPViolationRepository()
+			})
+		}},
+		{"WorkLogStore", func(t *testing.T) {
+			storetest.TestWorkLogStore(t, func(*testing.T) repository.WorkLogStore {
+				return repository.NewMemoryWorkLogRepository()
+			})
+		}},
+		{"TaskTemplateStore", func(t *testing.T) {
+			storetest.TestTaskTemplateStore(t, func(*testing.T) repository.TaskTemplateStore {
+				return repository.NewMemoryTaskTemplateRepository()
+			})
+		}},
+		{"AttachmentStore", func(t *testing.T) {
+			storetest.TestAttachmentStore(t, func(*testing.T) repository.AttachmentStore {
+				return repository.NewMemoryAttachmentRepository()
+			})
+		}},
+		{"NotificationStore", func(t *testing.T) {
+			storetest.TestNotificationStore(t, func(*testing.T) repository.NotificationStore {
+				return repository.NewMemoryNotificationRepository()
+			})
+		}},
+		{"LeaseStore", func(t *testing.T) {
+			storetest.TestLeaseStore(t, func(*testing.T) repository.LeaseStore {
+				return repository.NewMemoryLeaseRepository()
+			})
+		}},
+	}
+	for _, suite := range suites {
+		t.Run(suite.name, suite.run)
+	}
+}
@@ -0,0 +1,630 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/taskquery"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryTaskRepository is a thread-safe, in-memory TaskStore. It follows the
+// same filter and ordering rules as TaskRepository and is intended for tests
+// and local development without MongoDB.
+type MemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[primitive.ObjectID]*models.Task
+}
+
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks: make(map[primitive.ObjectID]*models.Task),
+	}
+}
+
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+	if task.Status == "" {
+		task.Status = models.StatusTodo
+	}
+	if task.StatusCategory == "" {
+		task.StatusCategory = models.DefaultStatusCategory(task.Status)
+	}
+	task.Version = 1
+	task.PriorityRank = models.PriorityRank(task.Priority)
+	task.ChecklistProgress = models.CountChecklist(task.Checklist)
+	if task.ID.IsZero() {
+		task.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(task)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryTaskRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.TaskUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil
+	}
+	if update.ExpectedVersion != nil && task.Version != *update.ExpectedVersion {
+		return ErrVersionConflict
+	}
+
+	task.UpdatedAt = time.Now()
+	task.Version++
+	if update.Title != nil {
+		task.Title = *update.Title
+	}
+	if update.Description != nil {
+		task.Description = *update.Description
+	}
+	if update.Priority != nil {
+		task.Priority = *update.Priority
+		task.PriorityRank = models.PriorityRank(task.Priority)
+	}
+	if update.Status != nil {
+		task.Status = *update.Status
+	}
+	if update.StatusCategory != nil {
+		task.StatusCategory = *update.StatusCategory
+	}
+	if update.Resolution != nil {
+		task.Resolution = *update.Resolution
+	}
+	if update.Rank != nil {
+		task.Rank = *update.Rank
+	}
+	if update.Estimate != nil {
+		task.Estimate = *update.Estimate
+	}
+	if update.DueDate != nil {
+		task.DueDate = *update.DueDate
+	}
+	if update.AssignedTo != nil {
+		task.AssignedTo = nil
+		if !update.AssignedTo.IsZero() {
+			assignedTo := *update.AssignedTo
+			task.AssignedTo = &assignedTo
+		}
+	}
+	if update.Tags != nil {
+		task.Tags = append([]string(nil), (*update.Tags)...)
+	}
+	if update.SharedWith != nil {
+		task.SharedWith = append([]models.TaskShare(nil), (*update.SharedWith)...)
+	}
+	if update.ProjectID != nil {
+		task.ProjectID = nil
+		if !update.ProjectID.IsZero() {
+			projectID := *update.ProjectID
+			task.ProjectID = &projectID
+		}
+	}
+	if update.Key != nil {
+		task.Key = *update.Key
+	}
+	if update.PreviousKeys != nil {
+		task.PreviousKeys = append([]string(nil), (*update.PreviousKeys)...)
+	}
+	if update.BlockedBy != nil {
+		task.BlockedBy = append([]primitive.ObjectID(nil), (*update.BlockedBy)...)
+	}
+	if update.ParentID != nil {
+		if update.ParentID.IsZero() {
+			task.ParentID = nil
+		} else {
+			parentID := *update.ParentID
+			task.ParentID = &parentID
+		}
+	}
+	if update.Recurrence != nil {
+		if update.Recurrence.Rule == "" {
+			task.Recurrence = nil
+		} else {
+			recurrence := *update.Recurrence
+			task.Recurrence = &recurrence
+		}
+	}
+	for fieldID, value := range update.CustomFields {
+		if value == nil {
+			delete(task.CustomFields, fieldID)
+			continue
+		}
+		if task.CustomFields == nil {
+			task.CustomFields = make(map[string]interface{})
+		}
+		task.CustomFields[fieldID] = value
+	}
+	if len(task.CustomFields) == 0 {
+		task.CustomFields = nil
+	}
+
+	stored, err := cloneDocument(task)
+	if err != nil {
+		return err
+	}
+	r.tasks[id] = stored
+	return nil
+}
+
+func (r *MemoryTaskRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return nil, nil
+	}
+	return cloneDocument(task)
+}
+
+func (r *MemoryTaskRepository) FindByKey(ctx context.Context, key string) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, task := range r.tasks {
+		if task.DeletedAt == nil && (task.Key == key || containsAny(task.PreviousKeys, []string{key})) {
+			return cloneDocument(task)
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryTaskRepository) Find(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*models.Task
+	for _, task := range r.tasks {
+		if !matchesTaskFilter(task, filter) {
+			continue
+		}
+		found, err := cloneDocument(task)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, found)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID.Hex() > tasks[j].ID.Hex()
+	})
+
+	return tasks, nil
+}
+
+func (r *MemoryTaskRepository) FindPage(ctx context.Context, filter models.TaskFilter, page models.TaskPage) (*models.TaskPageResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// compare orders a before b in ascending order, as MongoDB does for the
+	// compound sort on the sort field and _id.
+	compare := func(a *models.Task, value interface{}, id primitive.ObjectID) int {
+		if c := models.CompareSortValues(a.SortValue(page.Sort), value); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.Hex(), id.Hex())
+	}
+
+	result := &models.TaskPageResult{}
+	var matched []*models.Task
+	for _, task := range r.tasks {
+		if !matchesTaskFilter(task, filter) {
+			continue
+		}
+		matched = append(matched, task)
+	}
+	if page.CountTotal {
+		total := int64(len(matched))
+		result.Total = &total
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		c := compare(matched[i], matched[j].SortValue(page.Sort), matched[j].ID)
+		if page.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	for _, task := range matched {
+		if page.After != nil {
+			c := compare(task, page.After.Value, page.After.ID)
+			if (page.Descending && c >= 0) || (!page.Descending && c <= 0) {
+				continue
+			}
+		}
+		if page.Limit > 0 && len(result.Tasks) > page.Limit {
+			break
+		}
+		found, err := cloneDocument(task)
+		if err != nil {
+			return nil, err
+		}
+		result.Tasks = append(result.Tasks, found)
+	}
+
+	trimTaskPage(result, page)
+	return result, nil
+}
+
+// Search ranks every matching task with the Go text matcher, then breaks
+// ties by most recently updated.
+func (r *MemoryTaskRepository) Search(ctx context.Context, text models.TextQuery, filter models.TaskFilter, limit int) ([]*models.TaskSearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matcher := taskquery.NewTextMatcher(text)
+	var results []*models.TaskSearchResult
+	for _, task := range r.tasks {
+		if !matchesTaskFilter(task, filter) {
+			continue
+		}
+		score, ok := matcher.Score(task)
+		if !ok {
+			continue
+		}
+		found, err := cloneDocument(task)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &models.TaskSearchResult{Task: found, Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if !results[i].Task.UpdatedAt.Equal(results[j].Task.UpdatedAt) {
+			return results[i].Task.UpdatedAt.After(results[j].Task.UpdatedAt)
+		}
+		return results[i].Task.ID.Hex() > results[j].Task.ID.Hex()
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (r *MemoryTaskRepository) Delete(ctx context.Context, id primitive.ObjectID, deletion models.TaskDeletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return nil
+	}
+	if deletion.ExpectedVersion != nil && task.Version != *deletion.ExpectedVersion {
+		return ErrVersionConflict
+	}
+	now := time.Now()
+	deletedBy := deletion.By
+	task.DeletedAt, task.DeletedBy = &now, &deletedBy
+	if deletion.With != nil {
+		with := *deletion.With
+		task.DeletedWith = &with
+	}
+	task.UpdatedAt = now
+	task.Version++
+	return nil
+}
+
+func (r *MemoryTaskRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return nil
+	}
+	task.DeletedAt, task.DeletedBy, task.DeletedWith = nil, nil, nil
+	task.UpdatedAt = time.Now()
+	task.Version++
+	return nil
+}
+
+func (r *MemoryTaskRepository) Purge(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tasks, id)
+	return nil
+}
+
+func (r *MemoryTaskRepository) AddChecklistItem(ctx context.Context, taskID primitive.ObjectID, item *models.ChecklistItem, position int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[taskID]
+	if !ok || task.DeletedAt != nil {
+		return nil
+	}
+	if len(task.Checklist) >= models.MaxChecklistItems {
+		return ErrChecklistFull
+	}
+	position = clampPosition(position, len(task.Checklist))
+	items := append([]models.ChecklistItem(nil), task.Checklist[:position]...)
+	items = append(items, *item)
+	task.Checklist = append(items, task.Checklist[position:]...)
+	r.touchChecklist(task)
+	return nil
+}
+
+func (r *MemoryTaskRepository) UpdateChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID, update *models.ChecklistItemUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, i := r.checklistItem(taskID, itemID)
+	if task == nil {
+		return ErrChecklistItemNotFound
+	}
+	item := &task.Checklist[i]
+	changed := false
+	if update.Text != nil {
+		item.Text = *update.Text
+		changed = true
+	}
+	if update.Done != nil && *update.Done != item.Done {
+		item.Done = *update.Done
+		item.DoneBy, item.DoneAt = nil, nil
+		if item.Done {
+			by, at := update.By, update.At
+			item.DoneBy, item.DoneAt = &by, &at
+		}
+		changed = true
+	}
+	if changed {
+		r.touchChecklist(task)
+	}
+	return nil
+}
+
+func (r *MemoryTaskRepository) MoveChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID, position int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, i := r.checklistItem(taskID, itemID)
+	if task == nil {
+		return ErrChecklistItemNotFound
+	}
+	item := task.Checklist[i]
+	rest := append(append([]models.ChecklistItem(nil), task.Checklist[:i]...), task.Checklist[i+1:]...)
+	position = clampPosition(position, len(rest))
+	items := append(append([]models.ChecklistItem(nil), rest[:position]...), item)
+	task.Checklist = append(items, rest[position:]...)
+	r.touchChecklist(task)
+	return nil
+}
+
+func (r *MemoryTaskRepository) DeleteChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, i := r.checklistItem(taskID, itemID)
+	if task == nil {
+		return ErrChecklistItemNotFound
+	}
+	task.Checklist = append(append([]models.ChecklistItem(nil), task.Checklist[:i]...), task.Checklist[i+1:]...)
+	r.touchChecklist(task)
+	return nil
+}
+
+// checklistItem finds an item of a task that is not in the trash. The
+// caller must hold the lock.
+func (r *MemoryTaskRepository) checklistItem(taskID, itemID primitive.ObjectID) (*models.Task, int) {
+	task, ok := r.tasks[taskID]
+	if !ok || task.DeletedAt != nil {
+		return nil, 0
+	}
+	for i, item := range task.Checklist {
+		if item.ID == itemID {
+			return task, i
+		}
+	}
+	return nil, 0
+}
+
+// touchChecklist records a change to a task's checklist. Like the MongoDB
+// store, it keeps the progress of an emptied checklist rather than dropping
+// it.
+func (r *MemoryTaskRepository) touchChecklist(task *models.Task) {
+	progress := models.CountChecklist(task.Checklist)
+	if progress == nil {
+		progress = &models.ChecklistProgress{}
+	}
+	if len(task.Checklist) == 0 {
+		task.Checklist = nil
+	}
+	task.ChecklistProgress = progress
+	task.UpdatedAt = time.Now()
+	task.Version++
+}
+
+// clampPosition turns an insert position into an index into a list of n
+// items; negative positions append.
+func clampPosition(position, n int) int {
+	if position < 0 || position > n {
+		return n
+	}
+	return position
+}
+
+// WithTransaction is not supported by the in-memory store.
+func (r *MemoryTaskRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return ErrTransactionsUnsupported
+}
+
+// matchesTaskFilter is the in-memory counterpart of taskFilterDoc.
+func matchesTaskFilter(task *models.Task, filter models.TaskFilter) bool {
+	if len(filter.IDs) > 0 && !containsID(filter.IDs, task.ID) {
+		return false
+	}
+	if filter.Trashed != (task.DeletedAt != nil) {
+		return false
+	}
+	if filter.Trashed && filter.DeletedBefore != nil && !task.DeletedAt.Before(*filter.DeletedBefore) {
+		return false
+	}
+	if filter.Trashed && filter.DeletedWith != nil && (task.DeletedWith == nil || *task.DeletedWith != *filter.DeletedWith) {
+		return false
+	}
+	if !matchesIn(task.Status, filter.Status, filter.ExcludeStatus) {
+		return false
+	}
+	if !matchesIn(task.Category(), filter.Category, filter.ExcludeCategory) {
+		return false
+	}
+	if !matchesIn(task.Priority, filter.Priority, filter.ExcludePriority) {
+		return false
+	}
+	if filter.AssignedTo != nil && (task.AssignedTo == nil || *task.AssignedTo != *filter.AssignedTo) {
+		return false
+	}
+	if filter.Unassigned && task.AssignedTo != nil && !task.AssignedTo.IsZero() {
+		return false
+	}
+	if filter.CreatedBy != nil && task.CreatedBy != *filter.CreatedBy {
+		return false
+	}
+	if len(filter.Tags) > 0 && !containsAny(task.Tags, filter.Tags) {
+		return false
+	}
+	if containsAny(task.Tags, filter.ExcludeTags) {
+		return false
+	}
+	if filter.DueBefore != nil && !task.DueDate.Before(*filter.DueBefore) {
+		return false
+	}
+	if filter.DueAfter != nil && !task.DueDate.After(*filter.DueAfter) {
+		return false
+	}
+	if filter.ProjectID != nil && (task.ProjectID == nil || *task.ProjectID != *filter.ProjectID) {
+		return false
+	}
+	if len(filter.ParentIDs) > 0 && (task.ParentID == nil || !containsID(filter.ParentIDs, *task.ParentID)) {
+		return false
+	}
+	if filter.BlockedBy != nil && !containsID(task.BlockedBy, *filter.BlockedBy) {
+		return false
+	}
+	if filter.SeriesID != nil && (task.Recurrence == nil || task.Recurrence.SeriesID != *filter.SeriesID) {
+		return false
+	}
+	for _, match := range filter.CustomFieldMatches {
+		if !matchesCustomField(task.CustomValue(match.FieldID), match) {
+			return false
+		}
+	}
+	if filter.VisibleTo != nil && !task.CanView(*filter.VisibleTo) && !inProjects(task, filter.VisibleProjects) {
+		return false
+	}
+	return true
+}
+
+// matchesCustomField is the in-memory counterpart of the conditions
+// taskFilterDoc builds for a custom field. A list value matches when any of
+// its elements does, and a missing value matches only exclusions.
+func matchesCustomField(value interface{}, match models.CustomFieldMatch) bool {
+	var values []interface{}
+	switch v := value.(type) {
+	case nil:
+	case []string:
+		for _, item := range v {
+			values = append(values, item)
+		}
+	default:
+		values = append(values, v)
+	}
+
+	if len(match.Include) > 0 && !anyCustomValue(values, match.Include) {
+		return false
+	}
+	if anyCustomValue(values, match.Exclude) {
+		return false
+	}
+	for op, bound := range match.Bounds {
+		if value == nil || reflect.TypeOf(value) != reflect.TypeOf(bound) {
+			return false
+		}
+		c := models.CompareSortValues(value, bound)
+		switch {
+		case op == "$lt" && c >= 0, op == "$lte" && c > 0, op == "$gt" && c <= 0, op == "$gte" && c < 0:
+			return false
+		}
+	}
+	return true
+}
+
+func anyCustomValue(values, candidates []interface{}) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if reflect.TypeOf(value) == reflect.TypeOf(candidate) && models.CompareSortValues(value, candidate) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesIn is the in-memory counterpart of inCondition for a single-valued
+// field.
+func matchesIn[T comparable](value T, include, exclude []T) bool {
+	if len(include) > 0 && !containsValue(include, value) {
+		return false
+	}
+	return !containsValue(exclude, value)
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether values and candidates share at least one
+// element, matching MongoDB's $in semantics against an array field.
+func containsAny(values, candidates []string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inProjects(task *models.Task, projectIDs []primitive.ObjectID) bool {
+	return task.ProjectID != nil && containsID(projectIDs, *task.ProjectID)
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryTaskTemplateRepository is a thread-safe, in-memory
+// TaskTemplateStore.
+type MemoryTaskTemplateRepository struct {
+	mu        sync.RWMutex
+	templates map[primitive.ObjectID]*models.TaskTemplate
+}
+
+func NewMemoryTaskTemplateRepository() *MemoryTaskTemplateRepository {
+	return &MemoryTaskTemplateRepository{
+		templates: make(map[primitive.ObjectID]*models.TaskTemplate),
+	}
+}
+
+func (r *MemoryTaskTemplateRepository) Create(ctx context.Context, template *models.TaskTemplate) error {
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+	if template.ID.IsZero() {
+		template.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(template)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryTaskTemplateRepository) Replace(ctx context.Context, template *models.TaskTemplate) error {
+	template.UpdatedAt = time.Now()
+	stored, err := cloneDocument(template)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.templates[stored.ID]; ok {
+		r.templates[stored.ID] = stored
+	}
+	return nil
+}
+
+func (r *MemoryTaskTemplateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.TaskTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDocument(template)
+}
+
+func (r *MemoryTaskTemplateRepository) FindVisible(ctx context.Context, userID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*models.TaskTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var templates []*models.TaskTemplate
+	for _, template := range r.templates {
+		if !template.CanView(userID, projectIDs) {
+			continue
+		}
+		clone, err := cloneDocument(template)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, clone)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].ID.Hex() < templates[j].ID.Hex()
+	})
+	return templates, nil
+}
+
+func (r *MemoryTaskTemplateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.templates, id)
+	return nil
+}
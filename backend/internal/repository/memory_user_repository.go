@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository is a thread-safe, in-memory UserStore.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]*models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: make(map[primitive.ObjectID]*models.User),
+	}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(user)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.UserUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	user.UpdatedAt = time.Now()
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	if update.Timezone != nil {
+		user.Timezone = *update.Timezone
+	}
+	if update.MutedNotifications != nil {
+		user.MutedNotifications = append([]models.NotificationType(nil), *update.MutedNotifications...)
+	}
+	return nil
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return cloneDocument(user)
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDocument(user)
+}
+
+func (r *MemoryUserRepository) FindByName(ctx context.Context, name string) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []*models.User
+	for _, user := range r.users {
+		if strings.EqualFold(user.Name, name) {
+			clone, err := cloneDocument(user)
+			if err != nil {
+				return nil, err
+			}
+			users = append(users, clone)
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySavedViewRepository is a thread-safe, in-memory SavedViewStore.
+type MemorySavedViewRepository struct {
+	mu    sync.RWMutex
+	views map[primitive.ObjectID]*models.SavedView
+}
+
+func NewMemorySavedViewRepository() *MemorySavedViewRepository {
+	return &MemorySavedViewRepository{
+		views: make(map[primitive.ObjectID]*models.SavedView),
+	}
+}
+
+func (r *MemorySavedViewRepository) Create(ctx context.Context, view *models.SavedView) error {
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = time.Now()
+	if view.ID.IsZero() {
+		view.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(view)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.views[stored.ID] = stored
+	return nil
+}
+
+func (r *MemorySavedViewRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.SavedViewUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	view, ok := r.views[id]
+	if !ok {
+		return nil
+	}
+
+	view.UpdatedAt = time.Now()
+	if update.Name != nil {
+		view.Name = *update.Name
+	}
+	if update.Shared != nil {
+		view.Shared = *update.Shared
+	}
+	if update.Filter != nil {
+		view.Filter = *update.Filter
+	}
+	if update.Sort != nil {
+		view.Sort = *update.Sort
+	}
+	if update.GroupBy != nil {
+		view.GroupBy = *update.GroupBy
+	}
+	if update.ProjectID != nil {
+		if update.ProjectID.IsZero() {
+			view.ProjectID = nil
+		} else {
+			projectID := *update.ProjectID
+			view.ProjectID = &projectID
+		}
+	}
+
+	stored, err := cloneDocument(view)
+	if err != nil {
+		return err
+	}
+	r.views[id] = stored
+	return nil
+}
+
+func (r *MemorySavedViewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	view, ok := r.views[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDocument(view)
+}
+
+func (r *MemorySavedViewRepository) FindVisible(ctx context.Context, userID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*models.SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var views []*models.SavedView
+	for _, view := range r.views {
+		if !view.CanView(userID, projectIDs) {
+			continue
+		}
+		clone, err := cloneDocument(view)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, clone)
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Name != views[j].Name {
+			return views[i].Name < views[j].Name
+		}
+		return views[i].ID.Hex() < views[j].ID.Hex()
+	})
+	return views, nil
+}
+
+func (r *MemorySavedViewRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.views, id)
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryWIPViolationRepository is a thread-safe, in-memory
+// WIPViolationStore.
+type MemoryWIPViolationRepository struct {
+	mu         sync.RWMutex
+	violations []*models.WIPViolation
+}
+
+func NewMemoryWIPViolationRepository() *MemoryWIPViolationRepository {
+	return &MemoryWIPViolationRepository{}
+}
+
+func (r *MemoryWIPViolationRepository) Append(ctx context.Context, violation *models.WIPViolation) error {
+	if violation.At.IsZero() {
+		violation.At = time.Now()
+	}
+	if violation.ID.IsZero() {
+		violation.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(violation)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.violations = append(r.violations, stored)
+	return nil
+}
+
+// FindByProject walks the log backwards; violations are appended as they
+// happen, so that is newest first.
+func (r *MemoryWIPViolationRepository) FindByProject(ctx context.Context, projectID primitive.ObjectID, since time.Time, limit int) ([]*models.WIPViolation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var violations []*models.WIPViolation
+	for i := len(r.violations) - 1; i >= 0; i-- {
+		if limit > 0 && len(violations) == limit {
+			break
+		}
+		violation := r.violations[i]
+		if violation.ProjectID != projectID || violation.At.Before(since) {
+			continue
+		}
+		clone, err := cloneDocument(violation)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, clone)
+	}
+	return violations, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryWorkLogRepository is a thread-safe, in-memory WorkLogStore.
+type MemoryWorkLogRepository struct {
+	mu   sync.RWMutex
+	logs map[primitive.ObjectID]*models.WorkLog
+}
+
+func NewMemoryWorkLogRepository() *MemoryWorkLogRepository {
+	return &MemoryWorkLogRepository{
+		logs: make(map[primitive.ObjectID]*models.WorkLog),
+	}
+}
+
+func (r *MemoryWorkLogRepository) Create(ctx context.Context, log *models.WorkLog) error {
+	log.CreatedAt = time.Now()
+	if log.ID.IsZero() {
+		log.ID = primitive.NewObjectID()
+	}
+
+	stored, err := cloneDocument(log)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored.Running {
+		for _, other := range r.logs {
+			if other.Running && other.UserID == stored.UserID {
+				return duplicateKeyError("user_id")
+			}
+		}
+	}
+	r.logs[stored.ID] = stored
+	return nil
+}
+
+func (r *MemoryWorkLogRepository) Stop(ctx context.Context, id primitive.ObjectID, end time.Time, duration int64, note string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log, ok := r.logs[id]
+	if !ok || !log.Running {
+		return ErrTimerNotRunning
+	}
+	log.Running = false
+	log.EndedAt = &end
+	log.Duration = duration
+	log.Note = note
+
+	stored, err := cloneDocument(log)
+	if err != nil {
+		return err
+	}
+	r.logs[id] = stored
+	return nil
+}
+
+func (r *MemoryWorkLogRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.WorkLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	log, ok := r.logs[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDocument(log)
+}
+
+func (r *MemoryWorkLogRepository) FindRunning(ctx context.Context, userID primitive.ObjectID) (*models.WorkLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, log := range r.logs {
+		if log.Running && log.UserID == userID {
+			return cloneDocument(log)
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryWorkLogRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.WorkLog, error) {
+	return r.find(func(log *models.WorkLog) bool {
+		return log.TaskID == taskID
+	})
+}
+
+func (r *MemoryWorkLogRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*models.WorkLog, error) {
+	return r.find(func(log *models.WorkLog) bool {
+		return log.UserID == userID && !log.StartedAt.Before(from) && log.StartedAt.Before(to)
+	})
+}
+
+func (r *MemoryWorkLogRepository) find(match func(*models.WorkLog) bool) ([]*models.WorkLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var logs []*models.WorkLog
+	for _, log := range r.logs {
+		if !match(log) {
+			continue
+		}
+		clone, err := cloneDocument(log)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, clone)
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].StartedAt.Equal(logs[j].StartedAt) {
+			return logs[i].StartedAt.Before(logs[j].StartedAt)
+		}
+		return logs[i].ID.Hex() < logs[j].ID.Hex()
+	})
+	return logs, nil
+}
+
+func (r *MemoryWorkLogRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.logs, id)
+	return nil
+}
+
+func (r *MemoryWorkLogRepository) DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, log := range r.logs {
+		if log.TaskID == taskID {
+			delete(r.logs, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/repository"
+	"github.com/shrey258/task_management/internal/repository/storetest"
+)
+
+// The MongoDB stores run the conformance suites only when
+// MONGODB_TEST_URI points at a server, which must be a replica set for the
+// transaction tests. Every store starts from empty collections in the
+// task_management_test database, or MONGODB_TEST_DATABASE if set, so never
+// point it at data worth keeping.
+
+var (
+	connectOnce sync.Once
+	connectErr  error
+)
+
+// requireMongo skips the test unless MONGODB_TEST_URI is set, and connects
+// to the test database the first time it is called.
+func requireMongo(t *testing.T) {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	connectOnce.Do(func() {
+		name := os.Getenv("MONGODB_TEST_DATABASE")
+		if name == "" {
+			name = "task_management_test"
+		}
+		os.Setenv("MONGODB_URI", uri)
+		os.Setenv("MONGODB_DATABASE", name)
+		connectErr = database.Connect()
+	})
+	if connectErr != nil {
+		t.Fatalf("connect to %s: %v", uri, connectErr)
+	}
+}
+
+// mongoStore empties the given collections and returns the store built by
+// newStore, with its indexes in place.
+func mongoStore[S any](t *testing.T, newStore func() S, collections ...string) S {
+	t.Helper()
+	ctx := context.Background()
+	for _, name := range collections {
+		if err := database.GetDB().Collection(name).Drop(ctx); err != nil {
+			t.Fatalf("drop %s: %v", name, err)
+		}
+	}
+	store := newStore()
+	if indexed, ok := any(store).(interface{ EnsureIndexes(context.Context) error }); ok {
+		if err := indexed.EnsureIndexes(ctx); err != nil {
+			t.Fatalf("ensure indexes: %v", err)
+		}
+	}
+	return store
+}
+
+func TestMongoTaskStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestTaskStore(t, func(t *testing.T) repository.TaskStore {
+		return mongoStore(t, repository.NewTaskRepository, "tasks")
+	})
+}
+
+func TestMongoUserStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestUserStore(t, func(t *testing.T) repository.UserStore {
+		return mongoStore(t, repository.NewUserRepository, "users")
+	})
+}
+
+func TestMongoProjectStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestProjectStore(t, func(t *testing.T) repository.ProjectStore {
+		return mongoStore(t, repository.NewProjectRepository, "projects")
+	})
+}
+
+func TestMongoCommentStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestCommentStore(t, func(t *testing.T) repository.CommentStore {
+		return mongoStore(t, repository.NewCommentRepository, "comments")
+	})
+}
+
+func TestMongoActivityStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestActivityStore(t, func(t *testing.T) repository.ActivityStore {
+		return mongoStore(t, repository.NewActivityRepository, "task_activity")
+	})
+}
+
+func TestMongoSavedViewStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestSavedViewStore(t, func(t *testing.T) repository.SavedViewStore {
+		return mongoStore(t, repository.NewSavedViewRepository, "saved_views")
+	})
+}
+
+func TestMongoWIPViolationStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestWIPViolationStore(t, func(t *testing.T) repository.WIPViolationStore {
+		return mongoStore(t, repository.NewWIPViolationRepository, "wip_violations")
+	})
+}
+
+func TestMongoWorkLogStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestWorkLogStore(t, func(t *testing.T) repository.WorkLogStore {
+		return mongoStore(t, repository.NewWorkLogRepository, "worklogs")
+	})
+}
+
+func TestMongoTaskTemplateStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestTaskTemplateStore(t, func(t *testing.T) repository.TaskTemplateStore {
+		return mongoStore(t, repository.NewTaskTemplateRepository, "task_templates")
+	})
+}
+
+func TestMongoAttachmentStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestAttachmentStore(t, func(t *testing.T) repository.AttachmentStore {
+		return mongoStore(t, repository.NewAttachmentRepository, "attachments")
+	})
+}
+
+func TestMongoNotificationStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestNotificationStore(t, func(t *testing.T) repository.NotificationStore {
+		return mongoStore(t, repository.NewNotificationRepository, "notifications")
+	})
+}
+
+func TestMongoLeaseStore(t *testing.T) {
+	requireMongo(t)
+	storetest.TestLeaseStore(t, func(t *testing.T) repository.LeaseStore {
+		return mongoStore(t, repository.NewLeaseRepository, "leases")
+	})
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{
+		collection: database.GetDB().Collection("notifications"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to page through and count a user's
+// notifications, and the unique index that keeps each event from being
+// notified twice.
+func (r *NotificationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	})
+	return err
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	notification.CreatedAt = time.Now()
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, notification)
+	return err
+}
+
+func (r *NotificationRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, cursor *primitive.ObjectID, limit int) ([]*models.Notification, error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	if cursor != nil {
+		filter["_id"] = bson.M{"$lt": *cursor}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	found, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer found.Close(ctx)
+
+	var notifications []*models.Notification
+	if err = found.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.A{bson.M{"$set": bson.M{
+			"read":    true,
+			"read_at": bson.M{"$ifNull": bson.A{"$read_at", at}},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": at}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProjectRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProjectRepository() *ProjectRepository {
+	return &ProjectRepository{
+		collection: database.GetDB().Collection("projects"),
+	}
+}
+
+// EnsureIndexes creates the unique project key index and the membership
+// index used to list a user's projects.
+func (r *ProjectRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "members.user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+	project.Version = 1
+
+	result, err := r.collection.InsertOne(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	project.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.ProjectUpdate) error {
+	updateDoc := bson.M{"updated_at": time.Now()}
+
+	if update.Name != nil {
+		updateDoc["name"] = *update.Name
+	}
+	if update.Description != nil {
+		updateDoc["description"] = *update.Description
+	}
+	if update.Archived != nil {
+		updateDoc["archived"] = *update.Archived
+	}
+	if update.Members != nil {
+		updateDoc["members"] = *update.Members
+	}
+	if update.CustomFields != nil {
+		updateDoc["custom_fields"] = *update.CustomFields
+	}
+	if update.Workflow != nil {
+		updateDoc["workflow"] = *update.Workflow
+	}
+	if update.WIPLimits != nil {
+		updateDoc["wip_limits"] = *update.WIPLimits
+	}
+
+	filter := bson.M{"_id": id}
+	if update.ExpectedVersion != nil {
+		// Projects stored before versioning have no version field and count
+		// as version 0.
+		if *update.ExpectedVersion == 0 {
+			filter["version"] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter["version"] = *update.ExpectedVersion
+		}
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{"$set": updateDoc, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 && update.ExpectedVersion != nil {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVersionConflict
+		}
+	}
+	return nil
+}
+
+func (r *ProjectRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *ProjectRepository) FindByKey(ctx context.Context, key string) (*models.Project, error) {
+	return r.findOne(ctx, bson.M{"key": key})
+}
+
+func (r *ProjectRepository) FindByMember(ctx context.Context, userID primitive.ObjectID, includeArchived bool) ([]*models.Project, error) {
+	filter := bson.M{"members.user_id": userID}
+	if !includeArchived {
+		filter["archived"] = false
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var projects []*models.Project
+	if err = cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (r *ProjectRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *ProjectRepository) NextTaskNumber(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	var project models.Project
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"task_counter": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&project)
+	if err != nil {
+		return 0, err
+	}
+	return project.TaskCounter, nil
+}
+
+func (r *ProjectRepository) findOne(ctx context.Context, filter bson.M) (*models.Project, error) {
+	var project models.Project
+	err := r.collection.FindOne(ctx, filter).Decode(&project)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &project, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrVersionConflict is returned by TaskStore.Update and ProjectStore.Update
+// when the update names an ExpectedVersion and the stored document has moved
+// past it.
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrTransactionsUnsupported is returned by TaskStore.WithTransaction when the
+// backend cannot run transactions.
+var ErrTransactionsUnsupported = errors.New("transactions are not supported")
+
+// ErrChecklistItemNotFound is returned by the checklist methods of TaskStore
+// when the task has no item with the given ID.
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+
+// ErrChecklistFull is returned by TaskStore.AddChecklistItem when the task
+// already has models.MaxChecklistItems items.
+var ErrChecklistFull = errors.New("checklist is full")
+
+// TaskStore is the persistence contract the handlers depend on for tasks.
+// TaskRepository (MongoDB) and MemoryTaskRepository both implement it with
+// the same filter and ordering semantics.
+type TaskStore interface {
+	Create(ctx context.Context, task *models.Task) error
+	Update(ctx context.Context, id primitive.ObjectID, update *models.TaskUpdate) error
+	// FindByID and FindByKey do not return tasks in the trash; use Find with
+	// TaskFilter.Trashed for those.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error)
+	FindByKey(ctx context.Context, key string) (*models.Task, error)
+	Find(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error)
+	// FindPage returns the tasks matching filter in the order and window
+	// described by page.
+	FindPage(ctx context.Context, filter models.TaskFilter, page models.TaskPage) (*models.TaskPageResult, error)
+	// Search returns up to limit tasks matching both text and filter, most
+	// relevant first.
+	Search(ctx context.Context, text models.TextQuery, filter models.TaskFilter, limit int) ([]*models.TaskSearchResult, error)
+	// Delete moves a task to the trash and Restore takes it back out; both
+	// count as updates of the task. Purge removes a task for good, whether
+	// or not it is in the trash.
+	Delete(ctx context.Context, id primitive.ObjectID, deletion models.TaskDeletion) error
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	Purge(ctx context.Context, id primitive.ObjectID) error
+	// The checklist methods change one item of a task's checklist in a
+	// single atomic update that also keeps ChecklistProgress in step and
+	// counts as an update of the task. Items are inserted and moved to
+	// position, a 0-based index clamped to the list, or appended when
+	// position is negative. Updating an item to the state it is in still
+	// succeeds.
+	AddChecklistItem(ctx context.Context, taskID primitive.ObjectID, item *models.ChecklistItem, position int) error
+	UpdateChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID, update *models.ChecklistItemUpdate) error
+	MoveChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID, position int) error
+	DeleteChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID) error
+	// WithTransaction runs fn in a transaction that commits if fn returns
+	// nil. Store calls made with the context fn is given take part in it,
+	// on this store or any other store of the same backend. Backends that
+	// cannot run transactions return ErrTransactionsUnsupported without
+	// calling fn.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UserStore is the persistence contract the handlers depend on for users.
+type UserStore interface {
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, id primitive.ObjectID, update *models.UserUpdate) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// FindByName returns the users whose name matches exactly, ignoring
+	// case.
+	FindByName(ctx context.Context, name string) ([]*models.User, error)
+}
+
+// ProjectStore persists projects and allocates their task numbers.
+type ProjectStore interface {
+	Create(ctx context.Context, project *models.Project) error
+	Update(ctx context.Context, id primitive.ObjectID, update *models.ProjectUpdate) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error)
+	FindByKey(ctx context.Context, key string) (*models.Project, error)
+	FindByMember(ctx context.Context, userID primitive.ObjectID, includeArchived bool) ([]*models.Project, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// NextTaskNumber atomically increments and returns the project's task
+	// counter.
+	NextTaskNumber(ctx context.Context, id primitive.ObjectID) (int64, error)
+}
+
+// CommentStore persists task comments. FindByTask returns a thread oldest
+// first.
+type CommentStore interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	Update(ctx context.Context, id primitive.ObjectID, update *models.CommentUpdate) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Comment, error)
+	FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.Comment, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error
+}
+
+// SavedViewStore persists saved task views. FindVisible returns the views a
+// user owns plus those shared in the given projects, ordered by name.
+type SavedViewStore interface {
+	Create(ctx context.Context, view *models.SavedView) error
+	Update(ctx context.Context, id primitive.ObjectID, update *models.SavedViewUpdate) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.SavedView, error)
+	FindVisible(ctx context.Context, userID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*models.SavedView, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// TaskTemplateStore persists task templates. Replace overwrites a stored
+// template as a whole. FindVisible returns the templates a user owns plus
+// those shared in the given projects, ordered by name.
+type TaskTemplateStore interface {
+	Create(ctx context.Context, template *models.TaskTemplate) error
+	Replace(ctx context.Context, template *models.TaskTemplate) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.TaskTemplate, error)
+	FindVisible(ctx context.Context, userID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*models.TaskTemplate, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// AttachmentStore persists attachment metadata. Removing an attachment
+// takes two steps: MarkDeleted hides it, and Delete drops the record once
+// its blob is gone. FindDeleted returns the attachments in between, the
+// longest waiting first, so their blobs can be collected. FindByTask returns
+// a task's attachments oldest first.
+type AttachmentStore interface {
+	Create(ctx context.Context, attachment *models.Attachment) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Attachment, error)
+	FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.Attachment, error)
+	FindDeleted(ctx context.Context, limit int) ([]*models.Attachment, error)
+	MarkDeleted(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	MarkDeletedByTask(ctx context.Context, taskID primitive.ObjectID, at time.Time) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// ActivityStore is the append-only log of task changes. Activities are
+// returned newest first.
+type ActivityStore interface {
+	Append(ctx context.Context, activity *models.Activity) error
+	// FindByTask returns up to limit activities of a task, continuing after
+	// the activity with ID cursor when cursor is not nil.
+	FindByTask(ctx context.Context, taskID primitive.ObjectID, cursor *primitive.ObjectID, limit int) ([]*models.Activity, error)
+	// FindSince returns every activity of a task recorded after since.
+	FindSince(ctx context.Context, taskID primitive.ObjectID, since time.Time) ([]*models.Activity, error)
+}
+
+// WIPViolationStore is the append-only log of WIP limit violations.
+// FindByProject returns a project's violations recorded at or after since,
+// newest first, up to limit (0 for all of them).
+type WIPViolationStore interface {
+	Append(ctx context.Context, violation *models.WIPViolation) error
+	FindByProject(ctx context.Context, projectID primitive.ObjectID, since time.Time, limit int) ([]*models.WIPViolation, error)
+}
+
+// ErrTimerNotRunning is returned by WorkLogStore.Stop when the work log is
+// not a running timer.
+var ErrTimerNotRunning = errors.New("timer is not running")
+
+// WorkLogStore persists the time users log on tasks. Create refuses, with a
+// duplicate key error, a running timer for a user who already has one.
+// FindByTask returns a task's logs oldest first and FindByUser those of a
+// user started in [from, to), also oldest first.
+type WorkLogStore interface {
+	Create(ctx context.Context, log *models.WorkLog) error
+	// Stop ends a running timer at end, recording duration seconds and the
+	// note it ends with.
+	Stop(ctx context.Context, id primitive.ObjectID, end time.Time, duration int64, note string) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.WorkLog, error)
+	FindRunning(ctx context.Context, userID primitive.ObjectID) (*models.WorkLog, error)
+	FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.WorkLog, error)
+	FindByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*models.WorkLog, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error
+}
+
+// ErrNotificationNotFound is returned by NotificationStore.MarkRead when the
+// user has no notification with the given ID.
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationStore persists notifications. Create refuses, with a
+// duplicate key error, a notification whose Key is already taken.
+// FindByUser returns a user's notifications newest first, up to limit,
+// continuing after the notification with ID cursor when cursor is not nil.
+type NotificationStore interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	FindByUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, cursor *primitive.ObjectID, limit int) ([]*models.Notification, error)
+	CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// MarkRead marks one of the user's notifications read at at; marking
+	// a read notification again keeps its ReadAt. MarkAllRead does so for
+	// all of them and returns how many were unread.
+	MarkRead(ctx context.Context, userID, id primitive.ObjectID, at time.Time) error
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error)
+}
+
+// ErrLeaseLost is returned by LeaseStore.Advance when the caller no longer
+// holds the lease.
+var ErrLeaseLost = errors.New("lease lost")
+
+// LeaseStore hands out named leases to one holder at a time. Acquire takes
+// or renews the lease for ttl and returns it, or returns nil while another
+// holder's lease has not expired. Advance moves the cursor of a lease the
+// caller holds. Release lets the lease expire now if the caller holds it,
+// keeping its cursor for the next holder.
+type LeaseStore interface {
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*models.Lease, error)
+	Advance(ctx context.Context, name, holder string, cursor time.Time) error
+	Release(ctx context.Context, name, holder string) error
+}
+
+var (
+	_ TaskStore = (*TaskRepository)(nil)
+	_ TaskStore = (*MemoryTaskRepository)(nil)
+	_ UserStore = (*UserRepository)(nil)
+	_ UserStore = (*MemoryUserRepository)(nil)
+
+	_ ProjectStore = (*ProjectRepository)(nil)
+	_ ProjectStore = (*MemoryProjectRepository)(nil)
+	_ CommentStore = (*CommentRepository)(nil)
+	_ CommentStore = (*MemoryCommentRepository)(nil)
+
+	_ ActivityStore = (*ActivityRepository)(nil)
+	_ ActivityStore = (*MemoryActivityRepository)(nil)
+
+	_ SavedViewStore = (*SavedViewRepository)(nil)
+	_ SavedViewStore = (*MemorySavedViewRepository)(nil)
+
+	_ WIPViolationStore = (*WIPViolationRepository)(nil)
+	_ WIPViolationStore = (*MemoryWIPViolationRepository)(nil)
+
+	_ WorkLogStore = (*WorkLogRepository)(nil)
+	_ WorkLogStore = (*MemoryWorkLogRepository)(nil)
+
+	_ TaskTemplateStore = (*TaskTemplateRepository)(nil)
+	_ TaskTemplateStore = (*MemoryTaskTemplateRepository)(nil)
+
+	_ AttachmentStore = (*AttachmentRepository)(nil)
+	_ AttachmentStore = (*MemoryAttachmentRepository)(nil)
+
+	_ NotificationStore = (*NotificationRepository)(nil)
+	_ NotificationStore = (*MemoryNotificationRepository)(nil)
+
+	_ LeaseStore = (*LeaseRepository)(nil)
+	_ LeaseStore = (*MemoryLeaseRepository)(nil)
+)
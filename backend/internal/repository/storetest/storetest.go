@@ -0,0 +1,1767 @@
+// Package storetest provides a conformance suite for the repository store
+// interfaces. Every store backend is expected to pass it, so
+// handlers behave the same against MongoDB and the in-memory stores.
+//
+// A backend's own test file wires the suite to a constructor, for example:
+//
+//	func TestMemoryTaskStore(t *testing.T) {
+//		storetest.TestTaskStore(t, func(t *testing.T) repository.TaskStore {
+//			return repository.NewMemoryTaskRepository()
+//		})
+//	}
+//
+// The MongoDB backend is exercised the same way after database.Connect has
+// been pointed at a disposable database.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestTaskStore runs the TaskStore conformance suite. newStore must return
+// an empty store for every call.
+func TestTaskStore(t *testing.T, newStore func(t *testing.T) repository.TaskStore) {
+	t.Run("CreateAssignsIDAndDefaults", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "write docs", CreatedBy: primitive.NewObjectID()}
+		mustCreateTask(t, store, task)
+
+		if task.ID.IsZero() {
+			t.Fatal("Create did not assign an ID")
+		}
+		if task.Status != models.StatusTodo {
+			t.Fatalf("Create status = %q, want %q", task.Status, models.StatusTodo)
+		}
+		if task.CreatedAt.IsZero() || task.UpdatedAt.IsZero() {
+			t.Fatal("Create did not set timestamps")
+		}
+
+		found := mustFindTask(t, store, task.ID)
+		if found == nil || found.Title != task.Title {
+			t.Fatalf("FindByID = %+v, want title %q", found, task.Title)
+		}
+	})
+
+	t.Run("FindByIDMissing", func(t *testing.T) {
+		store := newStore(t)
+		found, err := store.FindByID(context.Background(), primitive.NewObjectID())
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found != nil {
+			t.Fatalf("FindByID = %+v, want nil", found)
+		}
+	})
+
+	t.Run("UpdateSetsOnlyProvidedFields", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{
+			Title:       "original",
+			Description: "keep me",
+			Priority:    models.PriorityLow,
+			Tags:        []string{"a"},
+		}
+		mustCreateTask(t, store, task)
+
+		title := "renamed"
+		status := models.StatusInProgress
+		assignee := primitive.NewObjectID()
+		tags := []string{"b", "c"}
+		err := store.Update(context.Background(), task.ID, &models.TaskUpdate{
+			Title:      &title,
+			Status:     &status,
+			AssignedTo: &assignee,
+			Tags:       &tags,
+		})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		found := mustFindTask(t, store, task.ID)
+		if found.Title != title || found.Status != status {
+			t.Fatalf("Update title/status = %q/%q", found.Title, found.Status)
+		}
+		if found.Description != "keep me" || found.Priority != models.PriorityLow {
+			t.Fatalf("Update touched unrelated fields: %+v", found)
+		}
+		if found.AssignedTo == nil || *found.AssignedTo != assignee {
+			t.Fatalf("Update assigned_to = %v, want %v", found.AssignedTo, assignee)
+		}
+		if len(found.Tags) != 2 || found.Tags[0] != "b" || found.Tags[1] != "c" {
+			t.Fatalf("Update tags = %v", found.Tags)
+		}
+		if found.UpdatedAt.Before(found.CreatedAt) {
+			t.Fatal("Update did not advance updated_at")
+		}
+	})
+
+	t.Run("UpdateMissingIsNoop", func(t *testing.T) {
+		store := newStore(t)
+		title := "ghost"
+		if err := store.Update(context.Background(), primitive.NewObjectID(), &models.TaskUpdate{Title: &title}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	})
+
+	t.Run("ReturnedTasksAreCopies", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "stable", Tags: []string{"x"}}
+		mustCreateTask(t, store, task)
+
+		found := mustFindTask(t, store, task.ID)
+		found.Title = "mutated"
+		found.Tags[0] = "mutated"
+
+		again := mustFindTask(t, store, task.ID)
+		if again.Title != "stable" || again.Tags[0] != "x" {
+			t.Fatalf("stored task was mutated through a returned copy: %+v", again)
+		}
+	})
+
+	t.Run("FindFilters", func(t *testing.T) {
+		store := newStore(t)
+		alice := primitive.NewObjectID()
+		bob := primitive.NewObjectID()
+		base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+		early := &models.Task{Title: "early", Priority: models.PriorityHigh, CreatedBy: alice, AssignedTo: &bob, Tags: []string{"backend", "api"}, DueDate: base.AddDate(0, 0, -5)}
+		middle := &models.Task{Title: "middle", Priority: models.PriorityLow, CreatedBy: bob, Tags: []string{"frontend"}, DueDate: base}
+		series := primitive.NewObjectID()
+		late := &models.Task{Title: "late", Priority: models.PriorityHigh, CreatedBy: alice, Tags: []string{"api"}, DueDate: base.AddDate(0, 0, 5),
+			Recurrence: &models.Recurrence{Rule: "FREQ=WEEKLY", SeriesID: series, Start: base.AddDate(0, 0, 5), Index: 1}}
+		for _, task := range []*models.Task{early, middle, late} {
+			mustCreateTask(t, store, task)
+		}
+		inProgress := models.StatusInProgress
+		if err := store.Update(context.Background(), middle.ID, &models.TaskUpdate{Status: &inProgress}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		high := models.PriorityHigh
+		before := base.AddDate(0, 0, 1)
+		after := base.AddDate(0, 0, -1)
+		cases := []struct {
+			name   string
+			filter models.TaskFilter
+			want   []string
+		}{
+			{"All", models.TaskFilter{}, []string{"late", "middle", "early"}},
+			{"Status", models.TaskFilter{Status: []models.TaskStatus{inProgress}}, []string{"middle"}},
+			{"StatusAnyOf", models.TaskFilter{Status: []models.TaskStatus{inProgress, models.StatusTodo}}, []string{"late", "middle", "early"}},
+			{"ExcludeStatus", models.TaskFilter{ExcludeStatus: []models.TaskStatus{inProgress}}, []string{"late", "early"}},
+			{"Priority", models.TaskFilter{Priority: []models.TaskPriority{high}}, []string{"late", "early"}},
+			{"ExcludePriority", models.TaskFilter{ExcludePriority: []models.TaskPriority{high}}, []string{"middle"}},
+			{"AssignedTo", models.TaskFilter{AssignedTo: &bob}, []string{"early"}},
+			{"Unassigned", models.TaskFilter{Unassigned: true}, []string{"late", "middle"}},
+			{"CreatedBy", models.TaskFilter{CreatedBy: &alice}, []string{"late", "early"}},
+			{"TagsIn", models.TaskFilter{Tags: []string{"frontend", "backend"}}, []string{"middle", "early"}},
+			{"ExcludeTags", models.TaskFilter{Tags: []string{"frontend", "backend"}, ExcludeTags: []string{"api"}}, []string{"middle"}},
+			{"DueBefore", models.TaskFilter{DueBefore: &base}, []string{"early"}},
+			{"DueAfter", models.TaskFilter{DueAfter: &base}, []string{"late"}},
+			{"DueRange", models.TaskFilter{DueAfter: &after, DueBefore: &before}, []string{"middle"}},
+			{"Combined", models.TaskFilter{Priority: []models.TaskPriority{high}, Tags: []string{"api"}, CreatedBy: &alice, DueAfter: &base}, []string{"late"}},
+			{"Series", models.TaskFilter{SeriesID: &series}, []string{"late"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				tasks, err := store.Find(context.Background(), tc.filter)
+				if err != nil {
+					t.Fatalf("Find: %v", err)
+				}
+				assertTitles(t, tasks, tc.want)
+			})
+		}
+	})
+
+	t.Run("UpdateComparesVersion", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "draft", CreatedBy: primitive.NewObjectID()}
+		mustCreateTask(t, store, task)
+		if task.Version != 1 {
+			t.Fatalf("Version after Create = %d, want 1", task.Version)
+		}
+
+		title := "first"
+		version := int64(1)
+		if err := store.Update(context.Background(), task.ID, &models.TaskUpdate{Title: &title, ExpectedVersion: &version}); err != nil {
+			t.Fatalf("Update at current version: %v", err)
+		}
+		if got := mustFindTask(t, store, task.ID); got.Version != 2 || got.Title != "first" {
+			t.Fatalf("after Update: version %d, title %q; want 2, first", got.Version, got.Title)
+		}
+
+		stale := "stale"
+		err := store.Update(context.Background(), task.ID, &models.TaskUpdate{Title: &stale, ExpectedVersion: &version})
+		if !errors.Is(err, repository.ErrVersionConflict) {
+			t.Fatalf("Update at stale version: err = %v, want ErrVersionConflict", err)
+		}
+		if got := mustFindTask(t, store, task.ID); got.Version != 2 || got.Title != "first" {
+			t.Fatalf("stale Update changed the task: version %d, title %q", got.Version, got.Title)
+		}
+
+		if err := store.Update(context.Background(), task.ID, &models.TaskUpdate{Title: &stale}); err != nil {
+			t.Fatalf("unconditional Update: %v", err)
+		}
+		if got := mustFindTask(t, store, task.ID); got.Version != 3 {
+			t.Fatalf("Version after unconditional Update = %d, want 3", got.Version)
+		}
+
+		if err := store.Update(context.Background(), primitive.NewObjectID(), &models.TaskUpdate{Title: &title, ExpectedVersion: &version}); err != nil {
+			t.Fatalf("conditional Update of a missing task: %v, want nil", err)
+		}
+	})
+
+	t.Run("UpdateClearsAssignee", func(t *testing.T) {
+		store := newStore(t)
+		assignee := primitive.NewObjectID()
+		cleared := &models.Task{Title: "cleared", AssignedTo: &assignee}
+		mustCreateTask(t, store, cleared)
+		// Tasks cleared before the field was unset hold an empty ID.
+		legacy := &models.Task{Title: "legacy", AssignedTo: &primitive.NilObjectID}
+		mustCreateTask(t, store, legacy)
+		mustCreateTask(t, store, &models.Task{Title: "assigned", AssignedTo: &assignee})
+
+		none := primitive.NilObjectID
+		if err := store.Update(context.Background(), cleared.ID, &models.TaskUpdate{AssignedTo: &none}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got := mustFindTask(t, store, cleared.ID); got.AssignedTo != nil {
+			t.Fatalf("AssignedTo after clearing = %v, want nil", got.AssignedTo)
+		}
+		tasks, err := store.Find(context.Background(), models.TaskFilter{Unassigned: true})
+		if err != nil {
+			t.Fatalf("Find(Unassigned): %v", err)
+		}
+		assertTitles(t, tasks, []string{"legacy", "cleared"})
+	})
+
+	t.Run("UpdateDetachesProject", func(t *testing.T) {
+		store := newStore(t)
+		projectID := primitive.NewObjectID()
+		task := &models.Task{Title: "moved out", ProjectID: &projectID, Key: "OPS-1"}
+		mustCreateTask(t, store, task)
+
+		detach, noKey, previousKeys := primitive.NilObjectID, "", []string{"OPS-1"}
+		if err := store.Update(context.Background(), task.ID, &models.TaskUpdate{ProjectID: &detach, Key: &noKey, PreviousKeys: &previousKeys}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got := mustFindTask(t, store, task.ID)
+		if got.ProjectID != nil || got.Key != "" {
+			t.Fatalf("after detaching: project %v, key %q; want neither", got.ProjectID, got.Key)
+		}
+		if found, err := store.FindByKey(context.Background(), "OPS-1"); err != nil || found == nil || found.ID != task.ID {
+			t.Fatalf("FindByKey(previous key) = %+v, %v", found, err)
+		}
+		if tasks, err := store.Find(context.Background(), models.TaskFilter{ProjectID: &projectID}); err != nil || len(tasks) != 0 {
+			t.Fatalf("Find(ProjectID) = %v, %v; want none", titles(tasks), err)
+		}
+	})
+
+	t.Run("UpdateRecurrence", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "chore", CreatedBy: primitive.NewObjectID()}
+		mustCreateTask(t, store, task)
+
+		nextID := primitive.NewObjectID()
+		recurrence := models.Recurrence{Rule: "FREQ=DAILY", SeriesID: primitive.NewObjectID(), Index: 2, NextID: &nextID}
+		if err := store.Update(context.Background(), task.ID, &models.TaskUpdate{Recurrence: &recurrence}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got := mustFindTask(t, store, task.ID)
+		if got.Recurrence == nil || got.Recurrence.Rule != "FREQ=DAILY" || got.Recurrence.Index != 2 ||
+			got.Recurrence.NextID == nil || *got.Recurrence.NextID != nextID {
+			t.Fatalf("Recurrence = %+v, want %+v", got.Recurrence, recurrence)
+		}
+
+		if err := store.Update(context.Background(), task.ID, &models.TaskUpdate{Recurrence: &models.Recurrence{}}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got := mustFindTask(t, store, task.ID); got.Recurrence != nil {
+			t.Fatalf("Recurrence = %+v after clearing, want nil", got.Recurrence)
+		}
+	})
+
+	t.Run("CustomFields", func(t *testing.T) {
+		store := newStore(t)
+		owner := primitive.NewObjectID()
+		points, env := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+		tasks := []*models.Task{
+			{Title: "a", CreatedBy: owner, CustomFields: map[string]interface{}{points: 5.0, env: []string{"prod", "staging"}}},
+			{Title: "b", CreatedBy: owner, CustomFields: map[string]interface{}{points: 2.0, env: []string{"dev"}}},
+			{Title: "c", CreatedBy: owner, CustomFields: map[string]interface{}{points: 8.0}},
+			{Title: "d", CreatedBy: owner},
+		}
+		for _, task := range tasks {
+			mustCreateTask(t, store, task)
+		}
+
+		cases := []struct {
+			name  string
+			match models.CustomFieldMatch
+			want  []string
+		}{
+			{"Include", models.CustomFieldMatch{FieldID: points, Include: []interface{}{5.0, 8.0}}, []string{"a", "c"}},
+			{"IncludeListElement", models.CustomFieldMatch{FieldID: env, Include: []interface{}{"prod"}}, []string{"a"}},
+			{"ExcludeKeepsMissing", models.CustomFieldMatch{FieldID: env, Exclude: []interface{}{"dev"}}, []string{"a", "c", "d"}},
+			{"Bounds", models.CustomFieldMatch{FieldID: points, Bounds: map[string]interface{}{"$gt": 2.0, "$lte": 8.0}}, []string{"a", "c"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				filter := models.TaskFilter{CreatedBy: &owner, CustomFieldMatches: []models.CustomFieldMatch{tc.match}}
+				result, err := store.FindPage(context.Background(), filter, models.TaskPage{Sort: models.SortTitle})
+				if err != nil {
+					t.Fatalf("FindPage: %v", err)
+				}
+				assertTitles(t, result.Tasks, tc.want)
+			})
+		}
+
+		// Missing values sort first, and pages continue across them.
+		sort := models.TaskSortField(models.CustomFieldPath(points))
+		for _, descending := range []bool{false, true} {
+			want := []string{"d", "b", "a", "c"}
+			if descending {
+				want = []string{"c", "a", "b", "d"}
+			}
+			filter := models.TaskFilter{CreatedBy: &owner}
+			page := models.TaskPage{Sort: sort, Descending: descending, Limit: 1}
+			var got []*models.Task
+			for {
+				result, err := store.FindPage(context.Background(), filter, page)
+				if err != nil {
+					t.Fatalf("FindPage: %v", err)
+				}
+				got = append(got, result.Tasks...)
+				if result.Next == nil || len(got) > len(want) {
+					break
+				}
+				page.After = result.Next
+			}
+			assertTitles(t, got, want)
+		}
+
+		update := &models.TaskUpdate{CustomFields: map[string]interface{}{points: 3.0, env: nil}}
+		if err := store.Update(context.Background(), tasks[0].ID, update); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got := mustFindTask(t, store, tasks[0].ID)
+		if got.CustomValue(points) != 3.0 || got.CustomValue(env) != nil {
+			t.Fatalf("CustomFields = %v, want points 3 and no env", got.CustomFields)
+		}
+	})
+
+	t.Run("FindPageWalksSortOrder", func(t *testing.T) {
+		store := newStore(t)
+		owner := primitive.NewObjectID()
+		base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		for _, task := range []*models.Task{
+			{Title: "b", Priority: models.PriorityLow, DueDate: base.AddDate(0, 0, 2), CreatedBy: owner},
+			{Title: "a", Priority: models.PriorityHigh, DueDate: base.AddDate(0, 0, 1), CreatedBy: owner},
+			{Title: "d", Priority: models.PriorityMedium, DueDate: base.AddDate(0, 0, 1), CreatedBy: owner},
+			{Title: "c", Priority: models.PriorityHigh, DueDate: base.AddDate(0, 0, 3), CreatedBy: owner},
+			{Title: "other", Priority: models.PriorityHigh, CreatedBy: primitive.NewObjectID()},
+		} {
+			mustCreateTask(t, store, task)
+		}
+
+		cases := []struct {
+			name       string
+			sort       models.TaskSortField
+			descending bool
+		}{
+			{"Title", models.SortTitle, false},
+			{"DueDate", models.SortDueDate, false},
+			{"PriorityDescending", models.SortPriority, true},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				filter := models.TaskFilter{CreatedBy: &owner}
+				all, err := store.FindPage(context.Background(), filter, models.TaskPage{Sort: tc.sort, Descending: tc.descending})
+				if err != nil {
+					t.Fatalf("FindPage: %v", err)
+				}
+				if len(all.Tasks) != 4 || all.Next != nil {
+					t.Fatalf("unpaged FindPage = %v (next %v), want 4 tasks", titles(all.Tasks), all.Next)
+				}
+				for i := 1; i < len(all.Tasks); i++ {
+					c := models.CompareSortValues(all.Tasks[i-1].SortValue(tc.sort), all.Tasks[i].SortValue(tc.sort))
+					if (tc.descending && c < 0) || (!tc.descending && c > 0) {
+						t.Fatalf("FindPage order = %v, not sorted by %s", titles(all.Tasks), tc.sort)
+					}
+				}
+
+				page := models.TaskPage{Sort: tc.sort, Descending: tc.descending, Limit: 3, CountTotal: true}
+				first, err := store.FindPage(context.Background(), filter, page)
+				if err != nil {
+					t.Fatalf("FindPage first page: %v", err)
+				}
+				if first.Total == nil || *first.Total != 4 {
+					t.Fatalf("Total = %v, want 4", first.Total)
+				}
+				if first.Next == nil {
+					t.Fatal("first page has no Next")
+				}
+				page.After, page.CountTotal = first.Next, false
+				second, err := store.FindPage(context.Background(), filter, page)
+				if err != nil {
+					t.Fatalf("FindPage second page: %v", err)
+				}
+				if second.Next != nil || second.Total != nil {
+					t.Fatalf("last page Next = %v, Total = %v; want nil", second.Next, second.Total)
+				}
+				assertTitles(t, append(first.Tasks, second.Tasks...), titles(all.Tasks))
+			})
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		store := newStore(t)
+		owner := primitive.NewObjectID()
+		for _, task := range []*models.Task{
+			{Title: "Fix login bug", Description: "The session timeout breaks login", Priority: models.PriorityHigh, CreatedBy: owner},
+			{Title: "Deployment pipeline", Description: "Deploy the login service", Tags: []string{"flaky"}, CreatedBy: owner},
+			{Title: "Write docs", Description: "Nothing to see", Tags: []string{"login"}, CreatedBy: owner},
+		} {
+			mustCreateTask(t, store, task)
+		}
+
+		cases := []struct {
+			name   string
+			text   models.TextQuery
+			filter models.TaskFilter
+			want   []string
+		}{
+			{"RankedByWeight", models.TextQuery{Words: []string{"login"}}, models.TaskFilter{}, []string{"Fix login bug", "Write docs", "Deployment pipeline"}},
+			{"AllWordsRequired", models.TextQuery{Words: []string{"login", "service"}}, models.TaskFilter{}, []string{"Deployment pipeline"}},
+			{"Phrase", models.TextQuery{Phrases: []string{"session timeout"}}, models.TaskFilter{}, []string{"Fix login bug"}},
+			{"Prefix", models.TextQuery{Prefixes: []string{"deploy"}}, models.TaskFilter{}, []string{"Deployment pipeline"}},
+			{"Excluded", models.TextQuery{Words: []string{"login"}, Excluded: []string{"flaky"}}, models.TaskFilter{}, []string{"Fix login bug", "Write docs"}},
+			{"Filtered", models.TextQuery{Words: []string{"login"}}, models.TaskFilter{Priority: []models.TaskPriority{models.PriorityHigh}}, []string{"Fix login bug"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				results, err := store.Search(context.Background(), tc.text, tc.filter, 10)
+				if err != nil {
+					t.Fatalf("Search: %v", err)
+				}
+				var tasks []*models.Task
+				for _, result := range results {
+					tasks = append(tasks, result.Task)
+				}
+				assertTitles(t, tasks, tc.want)
+			})
+		}
+	})
+
+	t.Run("FindVisibleTo", func(t *testing.T) {
+		store := newStore(t)
+		owner := primitive.NewObjectID()
+		assignee := primitive.NewObjectID()
+		viewer := primitive.NewObjectID()
+		stranger := primitive.NewObjectID()
+
+		owned := &models.Task{Title: "owned", CreatedBy: owner}
+		assigned := &models.Task{Title: "assigned", CreatedBy: owner, AssignedTo: &assignee}
+		shared := &models.Task{Title: "shared", CreatedBy: owner, SharedWith: []models.TaskShare{{UserID: viewer, Role: models.ShareRoleViewer}}}
+		for _, task := range []*models.Task{owned, assigned, shared} {
+			mustCreateTask(t, store, task)
+		}
+
+		cases := []struct {
+			name string
+			user primitive.ObjectID
+			want []string
+		}{
+			{"Creator", owner, []string{"shared", "assigned", "owned"}},
+			{"Assignee", assignee, []string{"assigned"}},
+			{"SharedUser", viewer, []string{"shared"}},
+			{"Stranger", stranger, nil},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				user := tc.user
+				tasks, err := store.Find(context.Background(), models.TaskFilter{VisibleTo: &user})
+				if err != nil {
+					t.Fatalf("Find: %v", err)
+				}
+				assertTitles(t, tasks, tc.want)
+			})
+		}
+	})
+
+	t.Run("FindByKeyIncludesPreviousKeys", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "moved", Key: "WEB-3", PreviousKeys: []string{"OPS-142"}}
+		mustCreateTask(t, store, task)
+
+		for _, key := range []string{"WEB-3", "OPS-142"} {
+			found, err := store.FindByKey(context.Background(), key)
+			if err != nil || found == nil || found.ID != task.ID {
+				t.Fatalf("FindByKey(%q) = %+v, %v", key, found, err)
+			}
+		}
+		if found, err := store.FindByKey(context.Background(), "OPS-1"); err != nil || found != nil {
+			t.Fatalf("FindByKey(missing) = %+v, %v", found, err)
+		}
+	})
+
+	t.Run("DeleteMovesToTrash", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "doomed", Key: "OPS-1"}
+		mustCreateTask(t, store, task)
+		child := &models.Task{Title: "child", ParentID: &task.ID}
+		mustCreateTask(t, store, child)
+
+		deletedBy := primitive.NewObjectID()
+		if err := store.Delete(context.Background(), task.ID, models.TaskDeletion{By: deletedBy}); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := store.Delete(context.Background(), child.ID, models.TaskDeletion{By: deletedBy, With: &task.ID}); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if found := mustFindTask(t, store, task.ID); found != nil {
+			t.Fatalf("FindByID after Delete = %+v, want nil", found)
+		}
+		if found, err := store.FindByKey(context.Background(), "OPS-1"); err != nil || found != nil {
+			t.Fatalf("FindByKey after Delete = %+v, %v", found, err)
+		}
+		if tasks, err := store.Find(context.Background(), models.TaskFilter{}); err != nil || len(tasks) != 0 {
+			t.Fatalf("Find after Delete = %v, %v; want none", titles(tasks), err)
+		}
+
+		trashed, err := store.Find(context.Background(), models.TaskFilter{Trashed: true})
+		if err != nil {
+			t.Fatalf("Find(Trashed): %v", err)
+		}
+		assertTitles(t, trashed, []string{"child", "doomed"})
+		got := trashed[1]
+		if got.DeletedAt == nil || got.DeletedBy == nil || *got.DeletedBy != deletedBy || got.DeletedWith != nil {
+			t.Fatalf("trashed task = %+v, want deleted_at and deleted_by set", got)
+		}
+		if got.Version != 2 {
+			t.Fatalf("Version after Delete = %d, want 2", got.Version)
+		}
+
+		with, err := store.Find(context.Background(), models.TaskFilter{Trashed: true, DeletedWith: &task.ID})
+		if err != nil {
+			t.Fatalf("Find(DeletedWith): %v", err)
+		}
+		assertTitles(t, with, []string{"child"})
+
+		past := got.DeletedAt.Add(-time.Hour)
+		if old, err := store.Find(context.Background(), models.TaskFilter{Trashed: true, DeletedBefore: &past}); err != nil || len(old) != 0 {
+			t.Fatalf("Find(DeletedBefore) = %v, %v; want none", titles(old), err)
+		}
+
+		if err := store.Delete(context.Background(), primitive.NewObjectID(), models.TaskDeletion{By: deletedBy}); err != nil {
+			t.Fatalf("Delete of missing task: %v", err)
+		}
+	})
+
+	t.Run("DeleteChecksVersion", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "doomed"}
+		mustCreateTask(t, store, task)
+		title := "changed"
+		if err := store.Update(context.Background(), task.ID, &models.TaskUpdate{Title: &title}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		deletedBy := primitive.NewObjectID()
+		stale := int64(1)
+		err := store.Delete(context.Background(), task.ID, models.TaskDeletion{By: deletedBy, ExpectedVersion: &stale})
+		if !errors.Is(err, repository.ErrVersionConflict) {
+			t.Fatalf("Delete at stale version: err = %v, want ErrVersionConflict", err)
+		}
+		if got := mustFindTask(t, store, task.ID); got == nil || got.Version != 2 {
+			t.Fatalf("stale Delete changed the task: %+v", got)
+		}
+
+		current := int64(2)
+		if err := store.Delete(context.Background(), task.ID, models.TaskDeletion{By: deletedBy, ExpectedVersion: &current}); err != nil {
+			t.Fatalf("Delete at current version: %v", err)
+		}
+		if got := mustFindTask(t, store, task.ID); got != nil {
+			t.Fatalf("FindByID after Delete = %+v, want nil", got)
+		}
+		// Tasks already in the trash, and missing ones, are left alone.
+		if err := store.Delete(context.Background(), task.ID, models.TaskDeletion{By: deletedBy, ExpectedVersion: &current}); err != nil {
+			t.Fatalf("Delete of a trashed task: %v, want nil", err)
+		}
+		if err := store.Delete(context.Background(), primitive.NewObjectID(), models.TaskDeletion{By: deletedBy, ExpectedVersion: &current}); err != nil {
+			t.Fatalf("conditional Delete of a missing task: %v, want nil", err)
+		}
+	})
+
+	t.Run("WithTransaction", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "before"}
+		mustCreateTask(t, store, task)
+
+		errRollback := errors.New("roll back")
+		called := false
+		err := store.WithTransaction(context.Background(), func(ctx context.Context) error {
+			called = true
+			title := "aborted"
+			if err := store.Update(ctx, task.ID, &models.TaskUpdate{Title: &title}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if errors.Is(err, repository.ErrTransactionsUnsupported) {
+			if called {
+				t.Fatal("WithTransaction called fn although transactions are unsupported")
+			}
+			t.Skip("store does not support transactions")
+		}
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("WithTransaction = %v, want %v", err, errRollback)
+		}
+		if found := mustFindTask(t, store, task.ID); found.Title != "before" {
+			t.Fatalf("title after aborted transaction = %q, want %q", found.Title, "before")
+		}
+
+		err = store.WithTransaction(context.Background(), func(ctx context.Context) error {
+			title := "committed"
+			return store.Update(ctx, task.ID, &models.TaskUpdate{Title: &title})
+		})
+		if err != nil {
+			t.Fatalf("WithTransaction: %v", err)
+		}
+		if found := mustFindTask(t, store, task.ID); found.Title != "committed" {
+			t.Fatalf("title after committed transaction = %q, want %q", found.Title, "committed")
+		}
+	})
+
+	t.Run("RestoreAndPurge", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "back again"}
+		mustCreateTask(t, store, task)
+		other := &models.Task{Title: "gone"}
+		mustCreateTask(t, store, other)
+
+		deletion := models.TaskDeletion{By: primitive.NewObjectID()}
+		for _, id := range []primitive.ObjectID{task.ID, other.ID} {
+			if err := store.Delete(context.Background(), id, deletion); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}
+
+		if err := store.Restore(context.Background(), task.ID); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		found := mustFindTask(t, store, task.ID)
+		if found == nil || found.DeletedAt != nil || found.DeletedBy != nil {
+			t.Fatalf("FindByID after Restore = %+v, want a live task", found)
+		}
+		if found.Version != 3 {
+			t.Fatalf("Version after Restore = %d, want 3", found.Version)
+		}
+
+		if err := store.Purge(context.Background(), other.ID); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if trashed, err := store.Find(context.Background(), models.TaskFilter{Trashed: true}); err != nil || len(trashed) != 0 {
+			t.Fatalf("Find(Trashed) after Purge = %v, %v; want none", titles(trashed), err)
+		}
+		if err := store.Purge(context.Background(), other.ID); err != nil {
+			t.Fatalf("Purge of missing task: %v", err)
+		}
+	})
+
+	t.Run("Checklist", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "with a checklist"}
+		mustCreateTask(t, store, task)
+
+		ids := make([]primitive.ObjectID, 3)
+		for i, position := range []int{-1, -1, 0} {
+			ids[i] = primitive.NewObjectID()
+			item := &models.ChecklistItem{ID: ids[i], Text: fmt.Sprintf("item %d", i)}
+			if err := store.AddChecklistItem(context.Background(), task.ID, item, position); err != nil {
+				t.Fatalf("AddChecklistItem: %v", err)
+			}
+		}
+		order := func() []primitive.ObjectID {
+			t.Helper()
+			var got []primitive.ObjectID
+			for _, item := range mustFindTask(t, store, task.ID).Checklist {
+				got = append(got, item.ID)
+			}
+			return got
+		}
+		if got := order(); len(got) != 3 || got[0] != ids[2] || got[1] != ids[0] || got[2] != ids[1] {
+			t.Fatalf("Checklist after AddChecklistItem = %v, want [2 0 1]", got)
+		}
+
+		done, by, at := true, primitive.NewObjectID(), time.Now().Truncate(time.Millisecond)
+		for i := 0; i < 2; i++ {
+			if err := store.UpdateChecklistItem(context.Background(), task.ID, ids[0], &models.ChecklistItemUpdate{Done: &done, By: by, At: at}); err != nil {
+				t.Fatalf("UpdateChecklistItem: %v", err)
+			}
+		}
+		found := mustFindTask(t, store, task.ID)
+		item := found.Checklist[1]
+		if !item.Done || item.DoneBy == nil || *item.DoneBy != by || item.DoneAt == nil || !item.DoneAt.Equal(at) {
+			t.Fatalf("item after UpdateChecklistItem = %+v, want done by %s", item, by.Hex())
+		}
+		if p := found.ChecklistProgress; p == nil || p.Total != 3 || p.Done != 1 {
+			t.Fatalf("ChecklistProgress = %+v, want 1 of 3 done", p)
+		}
+		if found.Version != 5 {
+			t.Fatalf("Version = %d, want 5", found.Version)
+		}
+
+		if err := store.MoveChecklistItem(context.Background(), task.ID, ids[2], -1); err != nil {
+			t.Fatalf("MoveChecklistItem: %v", err)
+		}
+		if err := store.MoveChecklistItem(context.Background(), task.ID, ids[1], 0); err != nil {
+			t.Fatalf("MoveChecklistItem: %v", err)
+		}
+		if got := order(); len(got) != 3 || got[0] != ids[1] || got[1] != ids[0] || got[2] != ids[2] {
+			t.Fatalf("Checklist after MoveChecklistItem = %v, want [1 0 2]", got)
+		}
+
+		if err := store.DeleteChecklistItem(context.Background(), task.ID, ids[0]); err != nil {
+			t.Fatalf("DeleteChecklistItem: %v", err)
+		}
+		found = mustFindTask(t, store, task.ID)
+		if p := found.ChecklistProgress; len(found.Checklist) != 2 || p == nil || p.Total != 2 || p.Done != 0 {
+			t.Fatalf("after DeleteChecklistItem: %d items, progress %+v; want 2 items, none done", len(found.Checklist), p)
+		}
+
+		missing := primitive.NewObjectID()
+		if err := store.DeleteChecklistItem(context.Background(), task.ID, missing); !errors.Is(err, repository.ErrChecklistItemNotFound) {
+			t.Fatalf("DeleteChecklistItem of missing item = %v, want ErrChecklistItemNotFound", err)
+		}
+		if err := store.UpdateChecklistItem(context.Background(), task.ID, missing, &models.ChecklistItemUpdate{Done: &done}); !errors.Is(err, repository.ErrChecklistItemNotFound) {
+			t.Fatalf("UpdateChecklistItem of missing item = %v, want ErrChecklistItemNotFound", err)
+		}
+		if err := store.MoveChecklistItem(context.Background(), task.ID, missing, 0); !errors.Is(err, repository.ErrChecklistItemNotFound) {
+			t.Fatalf("MoveChecklistItem of missing item = %v, want ErrChecklistItemNotFound", err)
+		}
+	})
+
+	t.Run("ChecklistFull", func(t *testing.T) {
+		store := newStore(t)
+		task := &models.Task{Title: "full"}
+		for i := 0; i < models.MaxChecklistItems; i++ {
+			task.Checklist = append(task.Checklist, models.ChecklistItem{ID: primitive.NewObjectID(), Text: "x"})
+		}
+		mustCreateTask(t, store, task)
+		if p := task.ChecklistProgress; p == nil || p.Total != models.MaxChecklistItems {
+			t.Fatalf("ChecklistProgress after Create = %+v, want %d items", p, models.MaxChecklistItems)
+		}
+		err := store.AddChecklistItem(context.Background(), task.ID, &models.ChecklistItem{ID: primitive.NewObjectID(), Text: "one more"}, -1)
+		if !errors.Is(err, repository.ErrChecklistFull) {
+			t.Fatalf("AddChecklistItem to a full checklist = %v, want ErrChecklistFull", err)
+		}
+	})
+}
+
+// TestUserStore runs the UserStore conformance suite. newStore must return
+// an empty store for every call.
+func TestUserStore(t *testing.T, newStore func(t *testing.T) repository.UserStore) {
+	t.Run("CreateAndFind", func(t *testing.T) {
+		store := newStore(t)
+		user := &models.User{Email: "ada@example.com", Name: "Ada", Password: "hash"}
+		if err := store.Create(context.Background(), user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if user.ID.IsZero() {
+			t.Fatal("Create did not assign an ID")
+		}
+
+		byEmail, err := store.FindByEmail(context.Background(), "ada@example.com")
+		if err != nil {
+			t.Fatalf("FindByEmail: %v", err)
+		}
+		if byEmail == nil || byEmail.ID != user.ID || byEmail.Password != "hash" {
+			t.Fatalf("FindByEmail = %+v", byEmail)
+		}
+
+		byID, err := store.FindByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if byID == nil || byID.Email != user.Email {
+			t.Fatalf("FindByID = %+v", byID)
+		}
+	})
+
+	t.Run("UpdateSetsOnlyProvidedFields", func(t *testing.T) {
+		store := newStore(t)
+		user := &models.User{Email: "ada@example.com", Name: "Ada"}
+		if err := store.Create(context.Background(), user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		timezone := "Europe/London"
+		if err := store.Update(context.Background(), user.ID, &models.UserUpdate{Timezone: &timezone}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		found, err := store.FindByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.Timezone != timezone || found.Name != "Ada" {
+			t.Fatalf("after Update: timezone %q, name %q; want %q, Ada", found.Timezone, found.Name, timezone)
+		}
+	})
+
+	t.Run("FindByNameIgnoresCase", func(t *testing.T) {
+		store := newStore(t)
+		for _, user := range []*models.User{
+			{Email: "ada@example.com", Name: "Ada Lovelace"},
+			{Email: "ada2@example.com", Name: "ada lovelace"},
+			{Email: "grace@example.com", Name: "Grace"},
+		} {
+			if err := store.Create(context.Background(), user); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		users, err := store.FindByName(context.Background(), "ADA LOVELACE")
+		if err != nil {
+			t.Fatalf("FindByName: %v", err)
+		}
+		if len(users) != 2 {
+			t.Fatalf("FindByName returned %d users, want 2", len(users))
+		}
+		if users, err := store.FindByName(context.Background(), "Ada"); err != nil || len(users) != 0 {
+			t.Fatalf("FindByName(prefix) = %d users, %v; want an exact match only", len(users), err)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		store := newStore(t)
+		byEmail, err := store.FindByEmail(context.Background(), "nobody@example.com")
+		if err != nil || byEmail != nil {
+			t.Fatalf("FindByEmail = %+v, %v; want nil, nil", byEmail, err)
+		}
+		byID, err := store.FindByID(context.Background(), primitive.NewObjectID())
+		if err != nil || byID != nil {
+			t.Fatalf("FindByID = %+v, %v; want nil, nil", byID, err)
+		}
+	})
+}
+
+// TestProjectStore runs the ProjectStore conformance suite. newStore must
+// return an empty store for every call.
+func TestProjectStore(t *testing.T, newStore func(t *testing.T) repository.ProjectStore) {
+	t.Run("CreateRejectsDuplicateKey", func(t *testing.T) {
+		store := newStore(t)
+		owner := primitive.NewObjectID()
+		first := &models.Project{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{{UserID: owner, Role: models.ProjectRoleOwner}}}
+		if err := store.Create(context.Background(), first); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		err := store.Create(context.Background(), &models.Project{Name: "Ops again", Key: "OPS"})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("Create duplicate key = %v, want duplicate key error", err)
+		}
+
+		found, err := store.FindByKey(context.Background(), "OPS")
+		if err != nil || found == nil || found.ID != first.ID {
+			t.Fatalf("FindByKey = %+v, %v", found, err)
+		}
+	})
+
+	t.Run("UpdateComparesVersion", func(t *testing.T) {
+		store := newStore(t)
+		owner := models.ProjectMember{UserID: primitive.NewObjectID(), Role: models.ProjectRoleOwner}
+		project := &models.Project{Name: "Ops", Key: "OPS", Members: []models.ProjectMember{owner}}
+		if err := store.Create(context.Background(), project); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if project.Version != 1 {
+			t.Fatalf("Version after Create = %d, want 1", project.Version)
+		}
+		find := func(t *testing.T) *models.Project {
+			t.Helper()
+			found, err := store.FindByID(context.Background(), project.ID)
+			if err != nil || found == nil {
+				t.Fatalf("FindByID = %v, %v", found, err)
+			}
+			return found
+		}
+
+		// Two requests add a member to the project as they read it; the
+		// second must not drop the first one's member.
+		version := project.Version
+		first := append(append([]models.ProjectMember{}, project.Members...), models.ProjectMember{UserID: primitive.NewObjectID(), Role: models.ProjectRoleMember})
+		second := append(append([]models.ProjectMember{}, project.Members...), models.ProjectMember{UserID: primitive.NewObjectID(), Role: models.ProjectRoleViewer})
+		if err := store.Update(context.Background(), project.ID, &models.ProjectUpdate{Members: &first, ExpectedVersion: &version}); err != nil {
+			t.Fatalf("Update at current version: %v", err)
+		}
+		err := store.Update(context.Background(), project.ID, &models.ProjectUpdate{Members: &second, ExpectedVersion: &version})
+		if !errors.Is(err, repository.ErrVersionConflict) {
+			t.Fatalf("Update at stale version: err = %v, want ErrVersionConflict", err)
+		}
+		if got := find(t); got.Version != 2 || len(got.Members) != 2 || got.Members[1] != first[1] {
+			t.Fatalf("after Updates: version %d, members %+v; want 2, %+v", got.Version, got.Members, first)
+		}
+
+		name := "Operations"
+		if err := store.Update(context.Background(), project.ID, &models.ProjectUpdate{Name: &name}); err != nil {
+			t.Fatalf("unconditional Update: %v", err)
+		}
+		if got := find(t); got.Version != 3 || got.Name != name {
+			t.Fatalf("after unconditional Update: version %d, name %q; want 3, %s", got.Version, got.Name, name)
+		}
+
+		if err := store.Update(context.Background(), primitive.NewObjectID(), &models.ProjectUpdate{Name: &name, ExpectedVersion: &version}); err != nil {
+			t.Fatalf("conditional Update of a missing project: %v, want nil", err)
+		}
+	})
+
+	t.Run("FindByMember", func(t *testing.T) {
+		store := newStore(t)
+		member := primitive.NewObjectID()
+		archived := true
+		for _, project := range []*models.Project{
+			{Name: "Beta", Key: "BETA", Members: []models.ProjectMember{{UserID: member, Role: models.ProjectRoleMember}}},
+			{Name: "Alpha", Key: "ALPHA", Members: []models.ProjectMember{{UserID: member, Role: models.ProjectRoleViewer}}},
+			{Name: "Other", Key: "OTHER", Members: []models.ProjectMember{{UserID: primitive.NewObjectID(), Role: models.ProjectRoleOwner}}},
+			{Name: "Gamma", Key: "GAMMA", Members: []models.ProjectMember{{UserID: member, Role: models.ProjectRoleOwner}}},
+		} {
+			if err := store.Create(context.Background(), project); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if project.Key == "GAMMA" {
+				if err := store.Update(context.Background(), project.ID, &models.ProjectUpdate{Archived: &archived}); err != nil {
+					t.Fatalf("Update: %v", err)
+				}
+			}
+		}
+
+		active, err := store.FindByMember(context.Background(), member, false)
+		if err != nil {
+			t.Fatalf("FindByMember: %v", err)
+		}
+		if len(active) != 2 || active[0].Name != "Alpha" || active[1].Name != "Beta" {
+			t.Fatalf("FindByMember(active) = %d projects", len(active))
+		}
+
+		all, err := store.FindByMember(context.Background(), member, true)
+		if err != nil {
+			t.Fatalf("FindByMember: %v", err)
+		}
+		if len(all) != 3 {
+			t.Fatalf("FindByMember(all) = %d projects, want 3", len(all))
+		}
+	})
+
+	t.Run("NextTaskNumberIsAtomic", func(t *testing.T) {
+		store := newStore(t)
+		project := &models.Project{Name: "Ops", Key: "OPS"}
+		if err := store.Create(context.Background(), project); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		const workers = 20
+		numbers := make(chan int64, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				n, err := store.NextTaskNumber(context.Background(), project.ID)
+				if err != nil {
+					t.Errorf("NextTaskNumber: %v", err)
+				}
+				numbers <- n
+			}()
+		}
+		wg.Wait()
+		close(numbers)
+
+		seen := make(map[int64]bool)
+		for n := range numbers {
+			if n < 1 || n > workers || seen[n] {
+				t.Fatalf("NextTaskNumber returned %d twice or out of range", n)
+			}
+			seen[n] = true
+		}
+	})
+}
+
+// TestCommentStore runs the CommentStore conformance suite. newStore must
+// return an empty store for every call.
+func TestCommentStore(t *testing.T, newStore func(t *testing.T) repository.CommentStore) {
+	t.Run("FindByTaskOldestFirst", func(t *testing.T) {
+		store := newStore(t)
+		taskID := primitive.NewObjectID()
+		for _, body := range []string{"first", "second", "third"} {
+			comment := &models.Comment{TaskID: taskID, AuthorID: primitive.NewObjectID(), Body: body}
+			if err := store.Create(context.Background(), comment); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if comment.ID.IsZero() {
+				t.Fatal("Create did not assign an ID")
+			}
+		}
+		other := &models.Comment{TaskID: primitive.NewObjectID(), Body: "elsewhere"}
+		if err := store.Create(context.Background(), other); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		comments, err := store.FindByTask(context.Background(), taskID)
+		if err != nil {
+			t.Fatalf("FindByTask: %v", err)
+		}
+		var bodies []string
+		for _, comment := range comments {
+			bodies = append(bodies, comment.Body)
+		}
+		if len(bodies) != 3 || bodies[0] != "first" || bodies[1] != "second" || bodies[2] != "third" {
+			t.Fatalf("FindByTask bodies = %v, want [first second third]", bodies)
+		}
+	})
+
+	t.Run("UpdateKeepsHistory", func(t *testing.T) {
+		store := newStore(t)
+		comment := &models.Comment{TaskID: primitive.NewObjectID(), AuthorID: primitive.NewObjectID(), Body: "v1"}
+		if err := store.Create(context.Background(), comment); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		mention := primitive.NewObjectID()
+		edit := func(previous, body string) {
+			update := &models.CommentUpdate{
+				Body:     body,
+				Mentions: []primitive.ObjectID{mention},
+				Previous: models.CommentEdit{Body: previous, ReplacedAt: time.Now()},
+			}
+			if err := store.Update(context.Background(), comment.ID, update); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+		}
+		edit("v1", "v2")
+		edit("v2", "v3")
+
+		got, err := store.FindByID(context.Background(), comment.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID = %+v, %v", got, err)
+		}
+		if got.Body != "v3" || got.EditedAt == nil || len(got.Mentions) != 1 || got.Mentions[0] != mention {
+			t.Fatalf("updated comment = %+v", got)
+		}
+		if len(got.Edits) != 2 || got.Edits[0].Body != "v1" || got.Edits[1].Body != "v2" {
+			t.Fatalf("Edits = %+v, want v1 then v2", got.Edits)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		taskID := primitive.NewObjectID()
+		var ids []primitive.ObjectID
+		for i := 0; i < 3; i++ {
+			comment := &models.Comment{TaskID: taskID, Body: "body"}
+			if err := store.Create(context.Background(), comment); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			ids = append(ids, comment.ID)
+		}
+
+		if err := store.Delete(context.Background(), ids[0]); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if got, err := store.FindByID(context.Background(), ids[0]); err != nil || got != nil {
+			t.Fatalf("FindByID after Delete = %+v, %v; want nil, nil", got, err)
+		}
+		if err := store.DeleteByTask(context.Background(), taskID); err != nil {
+			t.Fatalf("DeleteByTask: %v", err)
+		}
+		if comments, err := store.FindByTask(context.Background(), taskID); err != nil || len(comments) != 0 {
+			t.Fatalf("FindByTask after DeleteByTask = %d comments, %v", len(comments), err)
+		}
+	})
+}
+
+// TestActivityStore runs the ActivityStore conformance suite. newStore must
+// return an empty store for every call.
+func TestActivityStore(t *testing.T, newStore func(t *testing.T) repository.ActivityStore) {
+	t.Run("FindByTaskPagesNewestFirst", func(t *testing.T) {
+		store := newStore(t)
+		taskID := primitive.NewObjectID()
+		var ids []primitive.ObjectID
+		for i := 0; i < 5; i++ {
+			activity := &models.Activity{TaskID: taskID, Action: models.ActivityUpdated}
+			if err := store.Append(context.Background(), activity); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if activity.ID.IsZero() || activity.At.IsZero() {
+				t.Fatalf("Append did not assign an ID and time: %+v", activity)
+			}
+			ids = append(ids, activity.ID)
+		}
+		if err := store.Append(context.Background(), &models.Activity{TaskID: primitive.NewObjectID()}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+
+		first, err := store.FindByTask(context.Background(), taskID, nil, 3)
+		if err != nil {
+			t.Fatalf("FindByTask: %v", err)
+		}
+		if len(first) != 3 || first[0].ID != ids[4] || first[2].ID != ids[2] {
+			t.Fatalf("first page = %v, want the three newest", activityIDs(first))
+		}
+		second, err := store.FindByTask(context.Background(), taskID, &first[2].ID, 3)
+		if err != nil {
+			t.Fatalf("FindByTask: %v", err)
+		}
+		if len(second) != 2 || second[0].ID != ids[1] || second[1].ID != ids[0] {
+			t.Fatalf("second page = %v, want the two oldest", activityIDs(second))
+		}
+	})
+
+	t.Run("FindSince", func(t *testing.T) {
+		store := newStore(t)
+		taskID := primitive.NewObjectID()
+		base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		for i := 0; i < 3; i++ {
+			activity := &models.Activity{TaskID: taskID, At: base.Add(time.Duration(i) * time.Hour)}
+			if err := store.Append(context.Background(), activity); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+		}
+
+		since, err := store.FindSince(context.Background(), taskID, base)
+		if err != nil {
+			t.Fatalf("FindSince: %v", err)
+		}
+		if len(since) != 2 || !since[0].At.After(since[1].At) {
+			t.Fatalf("FindSince returned %d activities, want the 2 later ones newest first", len(since))
+		}
+	})
+
+	t.Run("ChangesRoundTrip", func(t *testing.T) {
+		store := newStore(t)
+		before := &models.Task{ID: primitive.NewObjectID(), Title: "old", Tags: []string{"a"}}
+		after := &models.Task{ID: before.ID, Title: "new", Tags: []string{"a", "b"}}
+		changes, err := models.DiffTasks(before, after)
+		if err != nil {
+			t.Fatalf("DiffTasks: %v", err)
+		}
+		if err := store.Append(context.Background(), &models.Activity{TaskID: before.ID, Changes: changes}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+
+		stored, err := store.FindByTask(context.Background(), before.ID, nil, 1)
+		if err != nil || len(stored) != 1 {
+			t.Fatalf("FindByTask = %d activities, %v", len(stored), err)
+		}
+		reverted, err := models.RevertChanges(after, stored[0].Changes)
+		if err != nil {
+			t.Fatalf("RevertChanges: %v", err)
+		}
+		if reverted.Title != "old" || len(reverted.Tags) != 1 {
+			t.Fatalf("reverted task = %+v, want the original", reverted)
+		}
+	})
+}
+
+// TestSavedViewStore runs the SavedViewStore conformance suite. newStore
+// must return an empty store for every call.
+func TestSavedViewStore(t *testing.T, newStore func(t *testing.T) repository.SavedViewStore) {
+	t.Run("FindVisible", func(t *testing.T) {
+		store := newStore(t)
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		project, otherProject := primitive.NewObjectID(), primitive.NewObjectID()
+		for _, view := range []*models.SavedView{
+			{Name: "mine", OwnerID: alice},
+			{Name: "bob private", OwnerID: bob, ProjectID: &project},
+			{Name: "bob shared", OwnerID: bob, ProjectID: &project, Shared: true},
+			{Name: "elsewhere", OwnerID: bob, ProjectID: &otherProject, Shared: true},
+		} {
+			if err := store.Create(context.Background(), view); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		views, err := store.FindVisible(context.Background(), alice, []primitive.ObjectID{project})
+		if err != nil {
+			t.Fatalf("FindVisible: %v", err)
+		}
+		var names []string
+		for _, view := range views {
+			names = append(names, view.Name)
+		}
+		if len(names) != 2 || names[0] != "bob shared" || names[1] != "mine" {
+			t.Fatalf("FindVisible = %v, want [bob shared mine]", names)
+		}
+	})
+
+	t.Run("UpdateRoundTripsFilter", func(t *testing.T) {
+		store := newStore(t)
+		project := primitive.NewObjectID()
+		view := &models.SavedView{Name: "v", OwnerID: primitive.NewObjectID(), ProjectID: &project}
+		if err := store.Create(context.Background(), view); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		filter := models.ViewFilter{
+			TaskFilter:   models.TaskFilter{Status: []models.TaskStatus{models.StatusTodo}, Tags: []string{"api"}},
+			Due:          models.DueThisWeek,
+			AssignedToMe: true,
+		}
+		detach := primitive.NilObjectID
+		if err := store.Update(context.Background(), view.ID, &models.SavedViewUpdate{Filter: &filter, ProjectID: &detach}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		found, err := store.FindByID(context.Background(), view.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.ProjectID != nil {
+			t.Fatalf("ProjectID = %v, want nil", found.ProjectID)
+		}
+		got := found.Filter
+		if len(got.Status) != 1 || got.Status[0] != models.StatusTodo || len(got.Tags) != 1 || got.Due != models.DueThisWeek || !got.AssignedToMe {
+			t.Fatalf("Filter = %+v, want %+v", got, filter)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		view := &models.SavedView{Name: "gone", OwnerID: primitive.NewObjectID()}
+		if err := store.Create(context.Background(), view); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := store.Delete(context.Background(), view.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		found, err := store.FindByID(context.Background(), view.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found != nil {
+			t.Fatalf("FindByID after Delete = %+v, want nil", found)
+		}
+	})
+}
+
+// TestTaskTemplateStore runs the TaskTemplateStore conformance suite.
+func TestTaskTemplateStore(t *testing.T, newStore func(t *testing.T) repository.TaskTemplateStore) {
+	t.Run("FindVisible", func(t *testing.T) {
+		store := newStore(t)
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		project, otherProject := primitive.NewObjectID(), primitive.NewObjectID()
+		for _, template := range []*models.TaskTemplate{
+			{Name: "mine", OwnerID: alice},
+			{Name: "bob private", OwnerID: bob, ProjectID: &project},
+			{Name: "bob shared", OwnerID: bob, ProjectID: &project, Shared: true},
+			{Name: "elsewhere", OwnerID: bob, ProjectID: &otherProject, Shared: true},
+		} {
+			template.Title = template.Name
+			if err := store.Create(context.Background(), template); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		templates, err := store.FindVisible(context.Background(), alice, []primitive.ObjectID{project})
+		if err != nil {
+			t.Fatalf("FindVisible: %v", err)
+		}
+		var names []string
+		for _, template := range templates {
+			names = append(names, template.Name)
+		}
+		if len(names) != 2 || names[0] != "bob shared" || names[1] != "mine" {
+			t.Fatalf("FindVisible = %v, want [bob shared mine]", names)
+		}
+	})
+
+	t.Run("ReplaceRoundTripsItems", func(t *testing.T) {
+		store := newStore(t)
+		template := &models.TaskTemplate{Name: "release", OwnerID: primitive.NewObjectID()}
+		template.Title = "Release {{version}}"
+		if err := store.Create(context.Background(), template); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		template.Name = "release v2"
+		template.Tags = []string{"release"}
+		template.Children = []models.TemplateItem{
+			{Title: "Changelog", DueOffset: "+2 business days", Children: []models.TemplateItem{{Title: "Review"}}},
+		}
+		if err := store.Replace(context.Background(), template); err != nil {
+			t.Fatalf("Replace: %v", err)
+		}
+
+		found, err := store.FindByID(context.Background(), template.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.Name != "release v2" || found.Title != "Release {{version}}" || len(found.Tags) != 1 {
+			t.Fatalf("FindByID = %+v, want the replaced template", found)
+		}
+		if len(found.Children) != 1 || found.Children[0].DueOffset != "+2 business days" ||
+			len(found.Children[0].Children) != 1 || found.Children[0].Children[0].Title != "Review" {
+			t.Fatalf("Children = %+v, want the nested items", found.Children)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		template := &models.TaskTemplate{Name: "gone", OwnerID: primitive.NewObjectID()}
+		template.Title = "gone"
+		if err := store.Create(context.Background(), template); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := store.Delete(context.Background(), template.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		found, err := store.FindByID(context.Background(), template.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found != nil {
+			t.Fatalf("FindByID after Delete = %+v, want nil", found)
+		}
+	})
+}
+
+// TestWIPViolationStore runs the WIPViolationStore conformance suite.
+// newStore must return an empty store for every call.
+func TestWIPViolationStore(t *testing.T, newStore func(t *testing.T) repository.WIPViolationStore) {
+	t.Run("FindByProjectNewestFirst", func(t *testing.T) {
+		store := newStore(t)
+		projectID := primitive.NewObjectID()
+		base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		for i := 0; i < 4; i++ {
+			violation := &models.WIPViolation{ProjectID: projectID, Count: i, At: base.Add(time.Duration(i) * time.Hour)}
+			if err := store.Append(context.Background(), violation); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if violation.ID.IsZero() {
+				t.Fatalf("Append did not assign an ID")
+			}
+		}
+		if err := store.Append(context.Background(), &models.WIPViolation{ProjectID: primitive.NewObjectID(), At: base}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+
+		found, err := store.FindByProject(context.Background(), projectID, base.Add(time.Hour), 0)
+		if err != nil {
+			t.Fatalf("FindByProject: %v", err)
+		}
+		if len(found) != 3 || found[0].Count != 3 || found[2].Count != 1 {
+			t.Fatalf("FindByProject returned %d violations, want the 3 since the second hour newest first", len(found))
+		}
+		limited, err := store.FindByProject(context.Background(), projectID, base, 2)
+		if err != nil {
+			t.Fatalf("FindByProject: %v", err)
+		}
+		if len(limited) != 2 || limited[0].Count != 3 {
+			t.Fatalf("FindByProject with a limit returned %d violations, want the 2 newest", len(limited))
+		}
+	})
+}
+
+// TestWorkLogStore runs the WorkLogStore conformance suite. newStore must
+// return an empty store for every call.
+func TestWorkLogStore(t *testing.T, newStore func(t *testing.T) repository.WorkLogStore) {
+	t.Run("OneRunningTimerPerUser", func(t *testing.T) {
+		store := newStore(t)
+		userID := primitive.NewObjectID()
+		timer := &models.WorkLog{TaskID: primitive.NewObjectID(), UserID: userID, Source: models.WorkLogTimer, StartedAt: time.Now(), Running: true}
+		if err := store.Create(context.Background(), timer); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		err := store.Create(context.Background(), &models.WorkLog{TaskID: primitive.NewObjectID(), UserID: userID, Source: models.WorkLogTimer, StartedAt: time.Now(), Running: true})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("second running timer: got %v, want a duplicate key error", err)
+		}
+		if err := store.Create(context.Background(), &models.WorkLog{TaskID: timer.TaskID, UserID: userID, Source: models.WorkLogManual, StartedAt: time.Now(), Duration: 60}); err != nil {
+			t.Fatalf("Create manual log beside a running timer: %v", err)
+		}
+
+		running, err := store.FindRunning(context.Background(), userID)
+		if err != nil || running == nil || running.ID != timer.ID {
+			t.Fatalf("FindRunning = %v, %v; want the timer", running, err)
+		}
+		if err := store.Stop(context.Background(), timer.ID, time.Now(), 90, "done"); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		if err := store.Stop(context.Background(), timer.ID, time.Now(), 90, "done"); !errors.Is(err, repository.ErrTimerNotRunning) {
+			t.Fatalf("second Stop: got %v, want ErrTimerNotRunning", err)
+		}
+		stopped, err := store.FindByID(context.Background(), timer.ID)
+		if err != nil || stopped == nil || stopped.Running || stopped.Duration != 90 || stopped.Note != "done" || stopped.EndedAt == nil {
+			t.Fatalf("FindByID after Stop = %+v, %v", stopped, err)
+		}
+		if running, _ := store.FindRunning(context.Background(), userID); running != nil {
+			t.Fatalf("FindRunning after Stop returned a timer")
+		}
+		if err := store.Create(context.Background(), &models.WorkLog{TaskID: timer.TaskID, UserID: userID, Source: models.WorkLogTimer, StartedAt: time.Now(), Running: true}); err != nil {
+			t.Fatalf("Create after Stop: %v", err)
+		}
+	})
+
+	t.Run("FindByTaskAndUser", func(t *testing.T) {
+		store := newStore(t)
+		taskID, userID := primitive.NewObjectID(), primitive.NewObjectID()
+		base := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+		for i := 3; i >= 0; i-- {
+			log := &models.WorkLog{TaskID: taskID, UserID: userID, Source: models.WorkLogManual, StartedAt: base.Add(time.Duration(i) * 24 * time.Hour), Duration: int64(i + 1)}
+			if err := store.Create(context.Background(), log); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		if err := store.Create(context.Background(), &models.WorkLog{TaskID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), StartedAt: base}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		logs, err := store.FindByTask(context.Background(), taskID)
+		if err != nil {
+			t.Fatalf("FindByTask: %v", err)
+		}
+		if len(logs) != 4 || logs[0].Duration != 1 || logs[3].Duration != 4 {
+			t.Fatalf("FindByTask returned %d logs, want 4 oldest first", len(logs))
+		}
+		logs, err = store.FindByUser(context.Background(), userID, base.Add(24*time.Hour), base.Add(3*24*time.Hour))
+		if err != nil {
+			t.Fatalf("FindByUser: %v", err)
+		}
+		if len(logs) != 2 || logs[0].Duration != 2 || logs[1].Duration != 3 {
+			t.Fatalf("FindByUser returned %d logs, want the 2 started in the range", len(logs))
+		}
+
+		if err := store.Delete(context.Background(), logs[0].ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if logs, _ := store.FindByTask(context.Background(), taskID); len(logs) != 3 {
+			t.Fatalf("FindByTask after Delete returned %d logs, want 3", len(logs))
+		}
+		if err := store.DeleteByTask(context.Background(), taskID); err != nil {
+			t.Fatalf("DeleteByTask: %v", err)
+		}
+		if logs, _ := store.FindByTask(context.Background(), taskID); len(logs) != 0 {
+			t.Fatalf("FindByTask after DeleteByTask returned %d logs", len(logs))
+		}
+	})
+}
+
+func activityIDs(activities []*models.Activity) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, activity := range activities {
+		ids = append(ids, activity.ID)
+	}
+	return ids
+}
+
+// TestAttachmentStore runs the AttachmentStore conformance suite.
+func TestAttachmentStore(t *testing.T, newStore func(t *testing.T) repository.AttachmentStore) {
+	t.Run("FindByTaskOldestFirst", func(t *testing.T) {
+		store := newStore(t)
+		task := primitive.NewObjectID()
+		for _, name := range []string{"first.png", "second.log"} {
+			if err := store.Create(context.Background(), &models.Attachment{TaskID: task, Filename: name, BlobKey: "k/" + name}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+		if err := store.Create(context.Background(), &models.Attachment{TaskID: primitive.NewObjectID(), Filename: "other"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		attachments, err := store.FindByTask(context.Background(), task)
+		if err != nil {
+			t.Fatalf("FindByTask: %v", err)
+		}
+		if len(attachments) != 2 || attachments[0].Filename != "first.png" || attachments[1].Filename != "second.log" {
+			t.Fatalf("FindByTask = %d attachments, want [first.png second.log]", len(attachments))
+		}
+		if attachments[0].BlobKey != "k/first.png" {
+			t.Fatalf("BlobKey = %q, want k/first.png", attachments[0].BlobKey)
+		}
+	})
+
+	t.Run("MarkDeletedHidesUntilDelete", func(t *testing.T) {
+		store := newStore(t)
+		task := primitive.NewObjectID()
+		one := &models.Attachment{TaskID: task, Filename: "one"}
+		two := &models.Attachment{TaskID: task, Filename: "two"}
+		kept := &models.Attachment{TaskID: primitive.NewObjectID(), Filename: "kept"}
+		for _, attachment := range []*models.Attachment{one, two, kept} {
+			if err := store.Create(context.Background(), attachment); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		now := time.Now()
+		if err := store.MarkDeleted(context.Background(), one.ID, now.Add(-time.Minute)); err != nil {
+			t.Fatalf("MarkDeleted: %v", err)
+		}
+		if err := store.MarkDeletedByTask(context.Background(), task, now); err != nil {
+			t.Fatalf("MarkDeletedByTask: %v", err)
+		}
+		if found, err := store.FindByID(context.Background(), one.ID); err != nil || found != nil {
+			t.Fatalf("FindByID of deleted attachment = %+v, %v; want nil", found, err)
+		}
+		if found, err := store.FindByTask(context.Background(), task); err != nil || len(found) != 0 {
+			t.Fatalf("FindByTask after MarkDeletedByTask = %d attachments, %v; want none", len(found), err)
+		}
+
+		pending, err := store.FindDeleted(context.Background(), 10)
+		if err != nil {
+			t.Fatalf("FindDeleted: %v", err)
+		}
+		if len(pending) != 2 || pending[0].ID != one.ID || pending[1].ID != two.ID {
+			t.Fatalf("FindDeleted = %d attachments, want [one two]", len(pending))
+		}
+		if limited, err := store.FindDeleted(context.Background(), 1); err != nil || len(limited) != 1 {
+			t.Fatalf("FindDeleted(1) = %d attachments, %v; want 1", len(limited), err)
+		}
+
+		for _, attachment := range pending {
+			if err := store.Delete(context.Background(), attachment.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}
+		if pending, err := store.FindDeleted(context.Background(), 10); err != nil || len(pending) != 0 {
+			t.Fatalf("FindDeleted after Delete = %d attachments, %v; want none", len(pending), err)
+		}
+		if found, err := store.FindByID(context.Background(), kept.ID); err != nil || found == nil {
+			t.Fatalf("FindByID of kept attachment = %v, %v", found, err)
+		}
+	})
+}
+
+// TestNotificationStore runs the NotificationStore conformance suite.
+func TestNotificationStore(t *testing.T, newStore func(t *testing.T) repository.NotificationStore) {
+	t.Run("FindByUserNewestFirst", func(t *testing.T) {
+		store := newStore(t)
+		user := primitive.NewObjectID()
+		for _, message := range []string{"first", "second", "third"} {
+			if err := store.Create(context.Background(), &models.Notification{UserID: user, Type: models.NotificationOverdue, Message: message}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+		if err := store.Create(context.Background(), &models.Notification{UserID: primitive.NewObjectID(), Message: "other"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		notifications, err := store.FindByUser(context.Background(), user, false, nil, 2)
+		if err != nil {
+			t.Fatalf("FindByUser: %v", err)
+		}
+		if len(notifications) != 2 || notifications[0].Message != "third" || notifications[1].Message != "second" {
+			t.Fatalf("FindByUser = %d notifications, want [third second]", len(notifications))
+		}
+		rest, err := store.FindByUser(context.Background(), user, false, &notifications[1].ID, 2)
+		if err != nil {
+			t.Fatalf("FindByUser after cursor: %v", err)
+		}
+		if len(rest) != 1 || rest[0].Message != "first" {
+			t.Fatalf("FindByUser after cursor = %d notifications, want [first]", len(rest))
+		}
+	})
+
+	t.Run("MarkRead", func(t *testing.T) {
+		store := newStore(t)
+		user, other := primitive.NewObjectID(), primitive.NewObjectID()
+		var ids []primitive.ObjectID
+		for i := 0; i < 3; i++ {
+			notification := &models.Notification{UserID: user, Type: models.NotificationAssigned}
+			if err := store.Create(context.Background(), notification); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			ids = append(ids, notification.ID)
+		}
+		if err := store.Create(context.Background(), &models.Notification{UserID: other}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if n, err := store.CountUnread(context.Background(), user); err != nil || n != 3 {
+			t.Fatalf("CountUnread = %d, %v; want 3", n, err)
+		}
+
+		readAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		if err := store.MarkRead(context.Background(), user, ids[0], readAt); err != nil {
+			t.Fatalf("MarkRead: %v", err)
+		}
+		if err := store.MarkRead(context.Background(), user, ids[0], time.Now()); err != nil {
+			t.Fatalf("MarkRead again: %v", err)
+		}
+		if err := store.MarkRead(context.Background(), other, ids[1], time.Now()); !errors.Is(err, repository.ErrNotificationNotFound) {
+			t.Fatalf("MarkRead of another user's notification = %v, want ErrNotificationNotFound", err)
+		}
+		unread, err := store.FindByUser(context.Background(), user, true, nil, 10)
+		if err != nil || len(unread) != 2 {
+			t.Fatalf("FindByUser unread = %d notifications, %v; want 2", len(unread), err)
+		}
+		all, err := store.FindByUser(context.Background(), user, false, nil, 10)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("FindByUser = %d notifications, %v; want 3", len(all), err)
+		}
+		read := all[2]
+		if read.ID != ids[0] || !read.Read || read.ReadAt == nil || !read.ReadAt.Equal(readAt) {
+			t.Fatalf("read notification = %+v, want read at %v", read, readAt)
+		}
+
+		if n, err := store.MarkAllRead(context.Background(), user, time.Now()); err != nil || n != 2 {
+			t.Fatalf("MarkAllRead = %d, %v; want 2", n, err)
+		}
+		if n, err := store.CountUnread(context.Background(), user); err != nil || n != 0 {
+			t.Fatalf("CountUnread after MarkAllRead = %d, %v; want 0", n, err)
+		}
+		if n, err := store.CountUnread(context.Background(), other); err != nil || n != 1 {
+			t.Fatalf("CountUnread of other user = %d, %v; want 1", n, err)
+		}
+	})
+
+	t.Run("CreateRejectsDuplicateKey", func(t *testing.T) {
+		store := newStore(t)
+		user := primitive.NewObjectID()
+		if err := store.Create(context.Background(), &models.Notification{UserID: user, Key: "event"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		err := store.Create(context.Background(), &models.Notification{UserID: user, Key: "event"})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("Create with a taken key = %v, want a duplicate key error", err)
+		}
+		// Notifications without a key never collide.
+		for i := 0; i < 2; i++ {
+			if err := store.Create(context.Background(), &models.Notification{UserID: user}); err != nil {
+				t.Fatalf("Create without a key: %v", err)
+			}
+		}
+		if notifications, err := store.FindByUser(context.Background(), user, false, nil, 10); err != nil || len(notifications) != 3 {
+			t.Fatalf("FindByUser = %d notifications, %v; want 3", len(notifications), err)
+		}
+	})
+}
+
+// TestLeaseStore runs the LeaseStore conformance suite.
+func TestLeaseStore(t *testing.T, newStore func(t *testing.T) repository.LeaseStore) {
+	t.Run("OneHolderAtATime", func(t *testing.T) {
+		store := newStore(t)
+		lease, err := store.Acquire(context.Background(), "job", "a", time.Minute)
+		if err != nil || lease == nil || lease.Holder != "a" {
+			t.Fatalf("Acquire by a = %+v, %v", lease, err)
+		}
+		if lease, err := store.Acquire(context.Background(), "job", "b", time.Minute); err != nil || lease != nil {
+			t.Fatalf("Acquire by b while a holds it = %+v, %v; want nil", lease, err)
+		}
+		if lease, err := store.Acquire(context.Background(), "other", "b", time.Minute); err != nil || lease == nil {
+			t.Fatalf("Acquire of another lease = %+v, %v", lease, err)
+		}
+		if lease, err := store.Acquire(context.Background(), "job", "a", time.Minute); err != nil || lease == nil {
+			t.Fatalf("renewal by a = %+v, %v", lease, err)
+		}
+		if err := store.Advance(context.Background(), "job", "b", time.Now()); !errors.Is(err, repository.ErrLeaseLost) {
+			t.Fatalf("Advance by b = %v, want ErrLeaseLost", err)
+		}
+	})
+
+	t.Run("ExpiredLeaseKeepsCursor", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Acquire(context.Background(), "job", "a", 50*time.Millisecond); err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		cursor := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		if err := store.Advance(context.Background(), "job", "a", cursor); err != nil {
+			t.Fatalf("Advance: %v", err)
+		}
+		time.Sleep(60 * time.Millisecond)
+
+		if err := store.Advance(context.Background(), "job", "a", time.Now()); !errors.Is(err, repository.ErrLeaseLost) {
+			t.Fatalf("Advance after expiry = %v, want ErrLeaseLost", err)
+		}
+		lease, err := store.Acquire(context.Background(), "job", "b", time.Minute)
+		if err != nil || lease == nil {
+			t.Fatalf("Acquire of expired lease = %+v, %v", lease, err)
+		}
+		if lease.Holder != "b" || lease.Cursor == nil || !lease.Cursor.Equal(cursor) {
+			t.Fatalf("lease = %+v, want held by b with cursor %v", lease, cursor)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Release(context.Background(), "job", "a"); err != nil {
+			t.Fatalf("Release of a missing lease = %v, want nil", err)
+		}
+		if _, err := store.Acquire(context.Background(), "job", "a", time.Minute); err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		cursor := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		if err := store.Advance(context.Background(), "job", "a", cursor); err != nil {
+			t.Fatalf("Advance: %v", err)
+		}
+
+		if err := store.Release(context.Background(), "job", "b"); err != nil {
+			t.Fatalf("Release by b = %v, want nil", err)
+		}
+		if lease, err := store.Acquire(context.Background(), "job", "b", time.Minute); err != nil || lease != nil {
+			t.Fatalf("Acquire by b after b released = %+v, %v; want nil", lease, err)
+		}
+
+		if err := store.Release(context.Background(), "job", "a"); err != nil {
+			t.Fatalf("Release by a: %v", err)
+		}
+		if err := store.Advance(context.Background(), "job", "a", time.Now()); !errors.Is(err, repository.ErrLeaseLost) {
+			t.Fatalf("Advance after Release = %v, want ErrLeaseLost", err)
+		}
+		lease, err := store.Acquire(context.Background(), "job", "b", time.Minute)
+		if err != nil || lease == nil {
+			t.Fatalf("Acquire by b after a released = %+v, %v", lease, err)
+		}
+		if lease.Holder != "b" || lease.Cursor == nil || !lease.Cursor.Equal(cursor) {
+			t.Fatalf("lease = %+v, want held by b with cursor %v", lease, cursor)
+		}
+	})
+}
+
+func mustCreateTask(t *testing.T, store repository.TaskStore, task *models.Task) {
+	t.Helper()
+	if err := store.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// Keep created_at strictly increasing at MongoDB's millisecond precision
+	// so ordering assertions are deterministic.
+	time.Sleep(2 * time.Millisecond)
+}
+
+func mustFindTask(t *testing.T, store repository.TaskStore, id primitive.ObjectID) *models.Task {
+	t.Helper()
+	task, err := store.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	return task
+}
+
+func assertTitles(t *testing.T, tasks []*models.Task, want []string) {
+	t.Helper()
+	if len(tasks) != len(want) {
+		t.Fatalf("got %d tasks %v, want %v", len(tasks), titles(tasks), want)
+	}
+	for i, task := range tasks {
+		if task.Title != want[i] {
+			t.Fatalf("got tasks %v, want %v", titles(tasks), want)
+		}
+	}
+}
+
+func titles(tasks []*models.Task) []string {
+	out := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		out = append(out, task.Title)
+	}
+	return out
+}
@@ -2,10 +2,15 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/shrey258/task_management/internal/database"
 	"github.com/shrey258/task_management/internal/models"
+	"github.com/shrey258/task_management/internal/taskquery"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,12 +27,87 @@ func NewTaskRepository() *TaskRepository {
 	}
 }
 
+// EnsureIndexes creates the indexes task lookups rely on. It is safe to call
+// on every start.
+func (r *TaskRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "previous_keys", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "blocked_by", Value: 1}}},
+		{Keys: bson.D{{Key: "recurrence.series_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "priority_rank", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "deleted_with", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "custom_fields.$**", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "status", Value: 1}, {Key: "rank", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}, {Key: "tags", Value: "text"}},
+			Options: options.Index().SetName("task_text").SetWeights(bson.M{
+				"title":       models.SearchWeightTitle,
+				"tags":        models.SearchWeightTags,
+				"description": models.SearchWeightDescription,
+			}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tasks stored before priority sorting have no rank yet.
+	for _, priority := range []models.TaskPriority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh} {
+		_, err := r.collection.UpdateMany(ctx,
+			bson.M{"priority": priority, "priority_rank": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"priority_rank": models.PriorityRank(priority)}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Tasks stored before workflows have no status category. They map onto
+	// the default workflow; "pending", which older clients sent, is its
+	// todo status.
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"status": "pending", "status_category": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": models.StatusTodo}},
+	)
+	if err != nil {
+		return err
+	}
+	for _, status := range []models.TaskStatus{models.StatusInProgress, models.StatusCompleted} {
+		_, err := r.collection.UpdateMany(ctx,
+			bson.M{"status": status, "status_category": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"status_category": models.DefaultStatusCategory(status)}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"status_category": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status_category": models.CategoryTodo}},
+	)
+	return err
+}
+
 func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
 	if task.Status == "" {
 		task.Status = models.StatusTodo
 	}
+	if task.StatusCategory == "" {
+		task.StatusCategory = models.DefaultStatusCategory(task.Status)
+	}
+	task.Version = 1
+	task.PriorityRank = models.PriorityRank(task.Priority)
+	task.ChecklistProgress = models.CountChecklist(task.Checklist)
 
 	result, err := r.collection.InsertOne(ctx, task)
 	if err != nil {
@@ -49,31 +129,142 @@ func (r *TaskRepository) Update(ctx context.Context, id primitive.ObjectID, upda
 	}
 	if update.Priority != nil {
 		updateDoc["priority"] = *update.Priority
+		updateDoc["priority_rank"] = models.PriorityRank(*update.Priority)
 	}
 	if update.Status != nil {
 		updateDoc["status"] = *update.Status
 	}
+	if update.StatusCategory != nil {
+		updateDoc["status_category"] = *update.StatusCategory
+	}
+	if update.Resolution != nil {
+		updateDoc["resolution"] = *update.Resolution
+	}
+	if update.Rank != nil {
+		updateDoc["rank"] = *update.Rank
+	}
+	if update.Estimate != nil {
+		updateDoc["estimate"] = *update.Estimate
+	}
 	if update.DueDate != nil {
 		updateDoc["due_date"] = *update.DueDate
 	}
-	if update.AssignedTo != nil {
-		updateDoc["assigned_to"] = *update.AssignedTo
-	}
 	if update.Tags != nil {
 		updateDoc["tags"] = *update.Tags
 	}
+	if update.SharedWith != nil {
+		updateDoc["shared_with"] = *update.SharedWith
+	}
+	if update.PreviousKeys != nil {
+		updateDoc["previous_keys"] = *update.PreviousKeys
+	}
 
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": updateDoc},
-	)
-	return err
+	if update.BlockedBy != nil {
+		updateDoc["blocked_by"] = *update.BlockedBy
+	}
+
+	changes := bson.M{"$set": updateDoc, "$inc": bson.M{"version": 1}}
+	if update.ParentID != nil {
+		if update.ParentID.IsZero() {
+			changes["$unset"] = bson.M{"parent_id": ""}
+		} else {
+			updateDoc["parent_id"] = *update.ParentID
+		}
+	}
+	unset := func(field string) {
+		fields, _ := changes["$unset"].(bson.M)
+		if fields == nil {
+			fields = bson.M{}
+			changes["$unset"] = fields
+		}
+		fields[field] = ""
+	}
+	if update.ProjectID != nil {
+		if update.ProjectID.IsZero() {
+			unset("project_id")
+		} else {
+			updateDoc["project_id"] = *update.ProjectID
+		}
+	}
+	if update.Key != nil {
+		if *update.Key == "" {
+			unset("key")
+		} else {
+			updateDoc["key"] = *update.Key
+		}
+	}
+	if update.AssignedTo != nil {
+		if update.AssignedTo.IsZero() {
+			unset("assigned_to")
+		} else {
+			updateDoc["assigned_to"] = *update.AssignedTo
+		}
+	}
+	if update.Recurrence != nil {
+		if update.Recurrence.Rule == "" {
+			unset("recurrence")
+		} else {
+			updateDoc["recurrence"] = *update.Recurrence
+		}
+	}
+	for fieldID, value := range update.CustomFields {
+		if value == nil {
+			unset(models.CustomFieldPath(fieldID))
+		} else {
+			updateDoc[models.CustomFieldPath(fieldID)] = value
+		}
+	}
+
+	filter := bson.M{"_id": id}
+	if update.ExpectedVersion != nil {
+		// Tasks stored before versioning have no version field and count
+		// as version 0.
+		if *update.ExpectedVersion == 0 {
+			filter["version"] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter["version"] = *update.ExpectedVersion
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, changes)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 && update.ExpectedVersion != nil {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVersionConflict
+		}
+	}
+	return nil
 }
 
 func (r *TaskRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Task, error) {
 	var task models.Task
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindByKey looks a task up by its current project key or any key it had
+// before being moved between projects.
+func (r *TaskRepository) FindByKey(ctx context.Context, key string) (*models.Task, error) {
+	var task models.Task
+	err := r.collection.FindOne(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"key": key},
+			bson.M{"previous_keys": key},
+		},
+		"deleted_at": nil,
+	}).Decode(&task)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -84,22 +275,467 @@ func (r *TaskRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 }
 
 func (r *TaskRepository) Find(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	filterDoc := taskFilterDoc(filter)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filterDoc, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*models.Task
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// FindPage uses keyset pagination: each page continues after the sort value
+// and ID of the previous page's last task, so its cost does not grow with
+// the page number and concurrent inserts do not shift later pages.
+func (r *TaskRepository) FindPage(ctx context.Context, filter models.TaskFilter, page models.TaskPage) (*models.TaskPageResult, error) {
+	filterDoc := taskFilterDoc(filter)
+	result := &models.TaskPageResult{}
+
+	if page.CountTotal {
+		total, err := r.collection.CountDocuments(ctx, filterDoc)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	key := page.Sort.SortKey()
+	direction, after := 1, "$gt"
+	if page.Descending {
+		direction, after = -1, "$lt"
+	}
+	if page.After != nil {
+		filterDoc = bson.M{"$and": bson.A{filterDoc, afterCursorDoc(key, after, page.After)}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: key, Value: direction}, {Key: "_id", Value: direction}})
+	if page.Limit > 0 {
+		opts.SetLimit(int64(page.Limit) + 1)
+	}
+	if len(page.Fields) > 0 {
+		projection := bson.M{key: 1, string(page.Sort): 1}
+		for _, field := range page.Fields {
+			if field != "id" {
+				projection[field] = 1
+			}
+		}
+		opts.SetProjection(projection)
+	}
+
+	cursor, err := r.collection.Find(ctx, filterDoc, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &result.Tasks); err != nil {
+		return nil, err
+	}
+
+	trimTaskPage(result, page)
+	return result, nil
+}
+
+// afterCursorDoc matches the tasks that sort after cursor, where after is
+// $gt or $lt. Custom fields may be missing, and MongoDB sorts missing values
+// before all others; comparison operators never match them, so they are
+// handled separately.
+func afterCursorDoc(key, after string, cursor *models.TaskCursor) bson.M {
+	if cursor.Value == nil {
+		sameValue := bson.M{key: nil, "_id": bson.M{after: cursor.ID}}
+		if after == "$lt" {
+			return sameValue
+		}
+		return bson.M{"$or": bson.A{sameValue, bson.M{key: bson.M{"$ne": nil}}}}
+	}
+
+	conditions := bson.A{
+		bson.M{key: bson.M{after: cursor.Value}},
+		bson.M{key: cursor.Value, "_id": bson.M{after: cursor.ID}},
+	}
+	if _, custom := models.TaskSortField(key).CustomFieldID(); custom && after == "$lt" {
+		conditions = append(conditions, bson.M{key: nil})
+	}
+	return bson.M{"$or": conditions}
+}
+
+// trimTaskPage drops the extra task fetched to detect a following page and
+// sets Next accordingly.
+func trimTaskPage(result *models.TaskPageResult, page models.TaskPage) {
+	if page.Limit <= 0 || len(result.Tasks) <= page.Limit {
+		return
+	}
+	result.Tasks = result.Tasks[:page.Limit]
+	last := result.Tasks[page.Limit-1]
+	result.Next = &models.TaskCursor{Value: last.SortValue(page.Sort), ID: last.ID}
+}
+
+// Search uses the text index for words and phrases. Every word is passed to
+// $text as a phrase so that all of them are required, as in the Go matcher
+// the in-memory store uses. Prefixes cannot use the text index and are
+// matched with regular expressions; a search of prefixes alone is ranked by
+// the Go matcher over the most recently updated matches.
+func (r *TaskRepository) Search(ctx context.Context, text models.TextQuery, filter models.TaskFilter, limit int) ([]*models.TaskSearchResult, error) {
+	filterDoc := taskFilterDoc(filter)
+
+	var terms []string
+	for _, word := range text.Words {
+		terms = append(terms, `"`+word+`"`)
+	}
+	for _, phrase := range text.Phrases {
+		terms = append(terms, `"`+phrase+`"`)
+	}
+	for _, word := range text.Excluded {
+		terms = append(terms, "-"+word)
+	}
+	useText := len(text.Words)+len(text.Phrases) > 0
+	if useText {
+		filterDoc["$text"] = bson.M{"$search": strings.Join(terms, " ")}
+	}
+
+	var conditions bson.A
+	for _, prefix := range text.Prefixes {
+		pattern := primitive.Regex{Pattern: `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(prefix), Options: "i"}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"title": pattern},
+			bson.M{"description": pattern},
+			bson.M{"tags": pattern},
+		}})
+	}
+	if !useText {
+		for _, word := range text.Excluded {
+			pattern := primitive.Regex{Pattern: `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(word) + `(e?s)?($|[^\p{L}\p{N}])`, Options: "i"}
+			conditions = append(conditions, bson.M{
+				"title":       bson.M{"$not": pattern},
+				"description": bson.M{"$not": pattern},
+				"tags":        bson.M{"$not": pattern},
+			})
+		}
+	}
+	if len(conditions) > 0 {
+		filterDoc["$and"] = conditions
+	}
+
+	opts := options.Find().SetLimit(int64(limit))
+	if useText {
+		opts.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+		opts.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "updated_at", Value: -1}})
+	} else {
+		opts.SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	}
+
+	cursor, err := r.collection.Find(ctx, filterDoc, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	matcher := taskquery.NewTextMatcher(text)
+	var results []*models.TaskSearchResult
+	for cursor.Next(ctx) {
+		var task models.Task
+		if err := cursor.Decode(&task); err != nil {
+			return nil, err
+		}
+		result := &models.TaskSearchResult{Task: &task}
+		if useText {
+			var scored struct {
+				Score float64 `bson:"score"`
+			}
+			if err := cursor.Decode(&scored); err != nil {
+				return nil, err
+			}
+			result.Score = scored.Score
+		} else {
+			result.Score, _ = matcher.Score(&task)
+		}
+		results = append(results, result)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	if !useText {
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	}
+	return results, nil
+}
+
+// Delete moves a task to the trash. Tasks already there are left as they
+// are.
+func (r *TaskRepository) Delete(ctx context.Context, id primitive.ObjectID, deletion models.TaskDeletion) error {
+	now := time.Now()
+	set := bson.M{"deleted_at": now, "deleted_by": deletion.By, "updated_at": now}
+	if deletion.With != nil {
+		set["deleted_with"] = *deletion.With
+	}
+
+	filter := bson.M{"_id": id, "deleted_at": nil}
+	if deletion.ExpectedVersion != nil {
+		if *deletion.ExpectedVersion == 0 {
+			filter["version"] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter["version"] = *deletion.ExpectedVersion
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 && deletion.ExpectedVersion != nil {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVersionConflict
+		}
+	}
+	return nil
+}
+
+func (r *TaskRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"deleted_at": "", "deleted_by": "", "deleted_with": ""},
+			"$inc":   bson.M{"version": 1},
+		},
+	)
+	return err
+}
+
+func (r *TaskRepository) Purge(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// AddChecklistItem pushes the item, refusing it in the same update if the
+// checklist is already full.
+func (r *TaskRepository) AddChecklistItem(ctx context.Context, taskID primitive.ObjectID, item *models.ChecklistItem, position int) error {
+	push := bson.M{"$each": bson.A{item}}
+	if position >= 0 {
+		push["$position"] = position
+	}
+	inc := bson.M{"version": 1, "checklist_progress.total": 1}
+	if item.Done {
+		inc["checklist_progress.done"] = 1
+	}
+	filter := bson.M{
+		"_id":        taskID,
+		"deleted_at": nil,
+		fmt.Sprintf("checklist.%d", models.MaxChecklistItems-1): bson.M{"$exists": false},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$push": bson.M{"checklist": push},
+		"$inc":  inc,
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": taskID, "deleted_at": nil})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrChecklistFull
+		}
+	}
+	return nil
+}
+
+// UpdateChecklistItem only matches an item that is not yet in the state
+// being set, so the done count moves with it. An item already in that state
+// gets the rest of the update.
+func (r *TaskRepository) UpdateChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID, update *models.ChecklistItemUpdate) error {
+	if update.Text == nil && update.Done == nil {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": taskID, "deleted_at": nil, "checklist.id": itemID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrChecklistItemNotFound
+		}
+		return nil
+	}
+
+	match := bson.M{"id": itemID}
+	set := bson.M{"updated_at": time.Now()}
+	inc := bson.M{"version": 1}
+	changes := bson.M{"$set": set, "$inc": inc}
+	if update.Text != nil {
+		set["checklist.$.text"] = *update.Text
+	}
+	if update.Done != nil {
+		match["done"] = !*update.Done
+		set["checklist.$.done"] = *update.Done
+		if *update.Done {
+			set["checklist.$.done_by"] = update.By
+			set["checklist.$.done_at"] = update.At
+			inc["checklist_progress.done"] = 1
+		} else {
+			changes["$unset"] = bson.M{"checklist.$.done_by": "", "checklist.$.done_at": ""}
+			inc["checklist_progress.done"] = -1
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": taskID, "deleted_at": nil, "checklist": bson.M{"$elemMatch": match}},
+		changes,
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	if update.Done != nil {
+		return r.UpdateChecklistItem(ctx, taskID, itemID, &models.ChecklistItemUpdate{Text: update.Text})
+	}
+	return ErrChecklistItemNotFound
+}
+
+// MoveChecklistItem rebuilds the checklist with an update pipeline, which
+// takes the item out and puts it back in at position in one write.
+func (r *TaskRepository) MoveChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID, position int) error {
+	rest := bson.M{"$filter": bson.M{"input": "$checklist", "cond": bson.M{"$ne": bson.A{"$$this.id", itemID}}}}
+	item := bson.M{"$filter": bson.M{"input": "$checklist", "cond": bson.M{"$eq": bson.A{"$$this.id", itemID}}}}
+	if position < 0 {
+		position = models.MaxChecklistItems
+	}
+	var head interface{} = bson.A{}
+	if position > 0 {
+		head = bson.M{"$slice": bson.A{"$$rest", position}}
+	}
+	tail := bson.M{"$slice": bson.A{"$$rest", position, models.MaxChecklistItems}}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": taskID, "deleted_at": nil, "checklist.id": itemID},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"checklist": bson.M{"$let": bson.M{
+				"vars": bson.M{"rest": rest, "item": item},
+				"in":   bson.M{"$concatArrays": bson.A{head, "$$item", tail}},
+			}},
+			"updated_at": time.Now(),
+			"version":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrChecklistItemNotFound
+	}
+	return nil
+}
+
+// DeleteChecklistItem pulls the item, matching it as done or not so the
+// done count can move with it.
+func (r *TaskRepository) DeleteChecklistItem(ctx context.Context, taskID, itemID primitive.ObjectID) error {
+	for _, done := range []bool{true, false} {
+		inc := bson.M{"version": 1, "checklist_progress.total": -1}
+		if done {
+			inc["checklist_progress.done"] = -1
+		}
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": taskID, "deleted_at": nil, "checklist": bson.M{"$elemMatch": bson.M{"id": itemID, "done": done}}},
+			bson.M{
+				"$pull": bson.M{"checklist": bson.M{"id": itemID}},
+				"$inc":  inc,
+				"$set":  bson.M{"updated_at": time.Now()},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount > 0 {
+			return nil
+		}
+	}
+	return ErrChecklistItemNotFound
+}
+
+// WithTransaction runs fn in a MongoDB transaction, retrying it on transient
+// errors as the driver recommends. Transactions need a replica set or a
+// sharded cluster; standalone servers report ErrTransactionsUnsupported.
+func (r *TaskRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := r.collection.Database().RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return ErrTransactionsUnsupported
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+// taskFilterDoc translates a TaskFilter into a MongoDB query document.
+// MemoryTaskRepository mirrors these semantics in matchesTaskFilter.
+func taskFilterDoc(filter models.TaskFilter) bson.M {
 	filterDoc := bson.M{}
 
-	if filter.Status != nil {
-		filterDoc["status"] = *filter.Status
+	if len(filter.IDs) > 0 {
+		filterDoc["_id"] = bson.M{"$in": filter.IDs}
 	}
-	if filter.Priority != nil {
-		filterDoc["priority"] = *filter.Priority
+	if filter.Trashed {
+		deleted := bson.M{"$ne": nil}
+		if filter.DeletedBefore != nil {
+			deleted["$lt"] = *filter.DeletedBefore
+		}
+		filterDoc["deleted_at"] = deleted
+		if filter.DeletedWith != nil {
+			filterDoc["deleted_with"] = *filter.DeletedWith
+		}
+	} else {
+		filterDoc["deleted_at"] = nil
+	}
+	if cond := inCondition(filter.Status, filter.ExcludeStatus); cond != nil {
+		filterDoc["status"] = cond
+	}
+	if cond := inCondition(filter.Category, filter.ExcludeCategory); cond != nil {
+		filterDoc["status_category"] = cond
+	}
+	if cond := inCondition(filter.Priority, filter.ExcludePriority); cond != nil {
+		filterDoc["priority"] = cond
 	}
 	if filter.AssignedTo != nil {
 		filterDoc["assigned_to"] = *filter.AssignedTo
 	}
+	if filter.Unassigned {
+		// Tasks unassigned before the field was unset on clearing hold
+		// an empty ID.
+		filterDoc["assigned_to"] = bson.M{"$in": bson.A{nil, primitive.NilObjectID}}
+	}
 	if filter.CreatedBy != nil {
 		filterDoc["created_by"] = *filter.CreatedBy
 	}
-	if len(filter.Tags) > 0 {
-		filterDoc["tags"] = bson.M{"$in": filter.Tags}
+	if cond := inCondition(filter.Tags, filter.ExcludeTags); cond != nil {
+		filterDoc["tags"] = cond
 	}
 	if filter.DueBefore != nil {
 		filterDoc["due_date"] = bson.M{"$lt": *filter.DueBefore}
@@ -111,23 +747,61 @@ func (r *TaskRepository) Find(ctx context.Context, filter models.TaskFilter) ([]
 			filterDoc["due_date"] = bson.M{"$gt": *filter.DueAfter}
 		}
 	}
-
-	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
-	cursor, err := r.collection.Find(ctx, filterDoc, opts)
-	if err != nil {
-		return nil, err
+	if filter.ProjectID != nil {
+		filterDoc["project_id"] = *filter.ProjectID
 	}
-	defer cursor.Close(ctx)
-
-	var tasks []*models.Task
-	if err = cursor.All(ctx, &tasks); err != nil {
-		return nil, err
+	if len(filter.ParentIDs) > 0 {
+		filterDoc["parent_id"] = bson.M{"$in": filter.ParentIDs}
+	}
+	if filter.BlockedBy != nil {
+		filterDoc["blocked_by"] = *filter.BlockedBy
+	}
+	if filter.SeriesID != nil {
+		filterDoc["recurrence.series_id"] = *filter.SeriesID
+	}
+	for _, match := range filter.CustomFieldMatches {
+		cond := bson.M{}
+		if len(match.Include) > 0 {
+			cond["$in"] = match.Include
+		}
+		if len(match.Exclude) > 0 {
+			cond["$nin"] = match.Exclude
+		}
+		for op, bound := range match.Bounds {
+			cond[op] = bound
+		}
+		if len(cond) > 0 {
+			filterDoc[models.CustomFieldPath(match.FieldID)] = cond
+		}
+	}
+	if filter.VisibleTo != nil {
+		visibility := bson.A{
+			bson.M{"created_by": *filter.VisibleTo},
+			bson.M{"assigned_to": *filter.VisibleTo},
+			bson.M{"shared_with.user_id": *filter.VisibleTo},
+		}
+		if len(filter.VisibleProjects) > 0 {
+			visibility = append(visibility, bson.M{"project_id": bson.M{"$in": filter.VisibleProjects}})
+		}
+		filterDoc["$or"] = visibility
 	}
 
-	return tasks, nil
+	return filterDoc
 }
 
-func (r *TaskRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+// inCondition builds the $in/$nin condition for a field that must match one
+// of include (when not empty) and none of exclude. It returns nil when both
+// are empty.
+func inCondition[T any](include, exclude []T) bson.M {
+	cond := bson.M{}
+	if len(include) > 0 {
+		cond["$in"] = include
+	}
+	if len(exclude) > 0 {
+		cond["$nin"] = exclude
+	}
+	if len(cond) == 0 {
+		return nil
+	}
+	return cond
 }
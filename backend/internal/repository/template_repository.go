@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TaskTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTaskTemplateRepository() *TaskTemplateRepository {
+	return &TaskTemplateRepository{
+		collection: database.GetDB().Collection("task_templates"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to list a user's own templates and
+// the templates shared in their projects.
+func (r *TaskTemplateRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "shared", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+func (r *TaskTemplateRepository) Create(ctx context.Context, template *models.TaskTemplate) error {
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, template)
+	if err != nil {
+		return err
+	}
+
+	template.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *TaskTemplateRepository) Replace(ctx context.Context, template *models.TaskTemplate) error {
+	template.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": template.ID}, template)
+	return err
+}
+
+func (r *TaskTemplateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *TaskTemplateRepository) FindVisible(ctx context.Context, userID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*models.TaskTemplate, error) {
+	filter := bson.M{"owner_id": userID}
+	if len(projectIDs) > 0 {
+		filter = bson.M{"$or": bson.A{
+			bson.M{"owner_id": userID},
+			bson.M{"shared": true, "project_id": bson.M{"$in": projectIDs}},
+		}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.TaskTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (r *TaskTemplateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
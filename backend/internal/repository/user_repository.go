@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/shrey258/task_management/internal/database"
@@ -34,6 +35,23 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+func (r *UserRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.UserUpdate) error {
+	updateDoc := bson.M{"updated_at": time.Now()}
+
+	if update.Name != nil {
+		updateDoc["name"] = *update.Name
+	}
+	if update.Timezone != nil {
+		updateDoc["timezone"] = *update.Timezone
+	}
+	if update.MutedNotifications != nil {
+		updateDoc["muted_notifications"] = *update.MutedNotifications
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateDoc})
+	return err
+}
+
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
@@ -57,3 +75,18 @@ func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 	}
 	return &user, nil
 }
+
+func (r *UserRepository) FindByName(ctx context.Context, name string) ([]*models.User, error) {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"}
+	cursor, err := r.collection.Find(ctx, bson.M{"name": pattern})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SavedViewRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSavedViewRepository() *SavedViewRepository {
+	return &SavedViewRepository{
+		collection: database.GetDB().Collection("saved_views"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to list a user's own views and the
+// views shared in their projects.
+func (r *SavedViewRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "shared", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+func (r *SavedViewRepository) Create(ctx context.Context, view *models.SavedView) error {
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, view)
+	if err != nil {
+		return err
+	}
+
+	view.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *SavedViewRepository) Update(ctx context.Context, id primitive.ObjectID, update *models.SavedViewUpdate) error {
+	updateDoc := bson.M{"updated_at": time.Now()}
+
+	if update.Name != nil {
+		updateDoc["name"] = *update.Name
+	}
+	if update.Shared != nil {
+		updateDoc["shared"] = *update.Shared
+	}
+	if update.Filter != nil {
+		updateDoc["filter"] = *update.Filter
+	}
+	if update.Sort != nil {
+		updateDoc["sort"] = *update.Sort
+	}
+	if update.GroupBy != nil {
+		updateDoc["group_by"] = *update.GroupBy
+	}
+
+	changes := bson.M{"$set": updateDoc}
+	if update.ProjectID != nil {
+		if update.ProjectID.IsZero() {
+			changes["$unset"] = bson.M{"project_id": ""}
+		} else {
+			updateDoc["project_id"] = *update.ProjectID
+		}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, changes)
+	return err
+}
+
+func (r *SavedViewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.SavedView, error) {
+	var view models.SavedView
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&view)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (r *SavedViewRepository) FindVisible(ctx context.Context, userID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*models.SavedView, error) {
+	filter := bson.M{"owner_id": userID}
+	if len(projectIDs) > 0 {
+		filter = bson.M{"$or": bson.A{
+			bson.M{"owner_id": userID},
+			bson.M{"shared": true, "project_id": bson.M{"$in": projectIDs}},
+		}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var views []*models.SavedView
+	if err = cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+func (r *SavedViewRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
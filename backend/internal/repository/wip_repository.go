@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WIPViolationRepository stores WIP limit violations in an insert-only
+// collection.
+type WIPViolationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWIPViolationRepository() *WIPViolationRepository {
+	return &WIPViolationRepository{
+		collection: database.GetDB().Collection("wip_violations"),
+	}
+}
+
+// EnsureIndexes creates the index used to report a project's violations.
+func (r *WIPViolationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "at", Value: -1}},
+	})
+	return err
+}
+
+func (r *WIPViolationRepository) Append(ctx context.Context, violation *models.WIPViolation) error {
+	if violation.At.IsZero() {
+		violation.At = time.Now()
+	}
+	if violation.ID.IsZero() {
+		violation.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, violation)
+	return err
+}
+
+func (r *WIPViolationRepository) FindByProject(ctx context.Context, projectID primitive.ObjectID, since time.Time, limit int) ([]*models.WIPViolation, error) {
+	filter := bson.M{"project_id": projectID, "at": bson.M{"$gte": since}}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var violations []*models.WIPViolation
+	if err = cursor.All(ctx, &violations); err != nil {
+		return nil, err
+	}
+	return violations, nil
+}
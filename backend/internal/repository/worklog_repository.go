@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shrey258/task_management/internal/database"
+	"github.com/shrey258/task_management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WorkLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWorkLogRepository() *WorkLogRepository {
+	return &WorkLogRepository{
+		collection: database.GetDB().Collection("worklogs"),
+	}
+}
+
+// EnsureIndexes creates the indexes used to list work logs by task and by
+// user, and the unique index that allows a user one running timer.
+func (r *WorkLogRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "started_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("running_timer").SetUnique(true).SetPartialFilterExpression(bson.M{"running": true}),
+		},
+	})
+	return err
+}
+
+func (r *WorkLogRepository) Create(ctx context.Context, log *models.WorkLog) error {
+	log.CreatedAt = time.Now()
+	if log.ID.IsZero() {
+		log.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, log)
+	return err
+}
+
+func (r *WorkLogRepository) Stop(ctx context.Context, id primitive.ObjectID, end time.Time, duration int64, note string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "running": true},
+		bson.M{"$set": bson.M{"running": false, "ended_at": end, "duration": duration, "note": note}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTimerNotRunning
+	}
+	return nil
+}
+
+func (r *WorkLogRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.WorkLog, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *WorkLogRepository) FindRunning(ctx context.Context, userID primitive.ObjectID) (*models.WorkLog, error) {
+	return r.findOne(ctx, bson.M{"user_id": userID, "running": true})
+}
+
+func (r *WorkLogRepository) findOne(ctx context.Context, filter bson.M) (*models.WorkLog, error) {
+	var log models.WorkLog
+	err := r.collection.FindOne(ctx, filter).Decode(&log)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &log, nil
+}
+
+func (r *WorkLogRepository) FindByTask(ctx context.Context, taskID primitive.ObjectID) ([]*models.WorkLog, error) {
+	return r.find(ctx, bson.M{"task_id": taskID})
+}
+
+func (r *WorkLogRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*models.WorkLog, error) {
+	return r.find(ctx, bson.M{"user_id": userID, "started_at": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *WorkLogRepository) find(ctx context.Context, filter bson.M) ([]*models.WorkLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var logs []*models.WorkLog
+	if err = cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (r *WorkLogRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *WorkLogRepository) DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
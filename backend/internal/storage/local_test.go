@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	testBlobStore(t, func(t *testing.T) BlobStore {
+		store, err := NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewLocalStore: %v", err)
+		}
+		return store
+	})
+}
+
+func TestLocalStoreLayout(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "blobs")
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "tasks/a/b", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "tasks", "a", "b"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("blob file = %q, %v; want hello", data, err)
+	}
+
+	// A failed upload leaves neither the blob nor its temporary file.
+	if err := store.Put(ctx, "tasks/a/c", strings.NewReader("hello world"), 5, ""); err == nil {
+		t.Fatal("Put of more than size bytes succeeded")
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "tasks", "a"))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "b" {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Fatalf("directory holds %v, want only b", names)
+	}
+}
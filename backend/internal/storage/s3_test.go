@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 stands in for an S3-compatible service. It checks the Signature
+// Version 4 of every request and keeps objects in memory by path.
+type fakeS3 struct {
+	accessKey, secretKey, region string
+
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		accessKey:    "AKIDEXAMPLE",
+		secretKey:    "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:       "eu-west-1",
+		objects:      make(map[string][]byte),
+		contentTypes: make(map[string]string),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if code, message := f.verify(r); code != "" {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>"+code+"</Code><Message>"+message+"</Message></Error>")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = data
+		f.contentTypes[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the request's signature from the AWS Signature Version
+// 4 specification and returns an S3 error code if it does not match.
+func (f *fakeS3) verify(r *http.Request) (string, string) {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return "AccessDenied", "missing signature"
+	}
+	fields := make(map[string]string)
+	for _, part := range strings.Split(auth, ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		fields[name] = value
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || time.Since(signedAt).Abs() > 15*time.Minute {
+		return "RequestTimeTooSkewed", "bad X-Amz-Date"
+	}
+	scope := amzDate[:8] + "/" + f.region + "/s3/aws4_request"
+	if fields["Credential"] != f.accessKey+"/"+scope {
+		return "InvalidAccessKeyId", "bad credential " + fields["Credential"]
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	wantHash := emptyPayloadHash
+	if r.Method == http.MethodPut {
+		wantHash = "UNSIGNED-PAYLOAD"
+	}
+	if payloadHash != wantHash {
+		return "XAmzContentSHA256Mismatch", "unexpected payload hash " + payloadHash
+	}
+
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signed) {
+		return "SignatureDoesNotMatch", "signed headers out of order"
+	}
+	var headers strings.Builder
+	for _, name := range signed {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonical := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery, headers.String(), fields["SignedHeaders"], payloadHash}, "\n")
+	digest := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	key := mac([]byte("AWS4"+f.secretKey), amzDate[:8])
+	key = mac(key, f.region)
+	key = mac(key, "s3")
+	key = mac(key, "aws4_request")
+	if want := hex.EncodeToString(mac(key, stringToSign)); !hmac.Equal([]byte(fields["Signature"]), []byte(want)) {
+		return "SignatureDoesNotMatch", "signature mismatch"
+	}
+	return "", ""
+}
+
+func newTestS3Store(t *testing.T, fake *fakeS3, endpoint string) *S3Store {
+	t.Helper()
+	store, err := NewS3Store(S3Config{
+		Endpoint:        endpoint,
+		Region:          fake.region,
+		Bucket:          "attachments",
+		AccessKeyID:     fake.accessKey,
+		SecretAccessKey: fake.secretKey,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	return store
+}
+
+func TestS3Store(t *testing.T) {
+	testBlobStore(t, func(t *testing.T) BlobStore {
+		fake := newFakeS3()
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		return newTestS3Store(t, fake, server.URL)
+	})
+}
+
+func TestS3StoreAddressesObjectsPathStyle(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store := newTestS3Store(t, fake, server.URL+"/storage/")
+
+	if err := store.Put(context.Background(), "tasks/a/b.pdf", strings.NewReader("%PDF"), 4, "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	const path = "/storage/attachments/tasks/a/b.pdf"
+	if string(fake.objects[path]) != "%PDF" || fake.contentTypes[path] != "application/pdf" {
+		t.Fatalf("objects = %v, content types = %v; want %s stored as application/pdf", fake.objects, fake.contentTypes, path)
+	}
+}
+
+func TestS3StoreReportsErrors(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	wrongSecret := newFakeS3()
+	wrongSecret.secretKey = "not-the-secret"
+	store := newTestS3Store(t, wrongSecret, server.URL)
+	err := store.Put(context.Background(), "k", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("Put with the wrong secret = %v, want SignatureDoesNotMatch", err)
+	}
+	if _, err := store.Get(context.Background(), "k"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get with the wrong secret = %v, want a signature error", err)
+	}
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	store = newTestS3Store(t, fake, broken.URL)
+	if err := store.Delete(context.Background(), "k"); err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Fatalf("Delete against a failing server = %v, want status 500", err)
+	}
+}
+
+func TestNewS3StoreValidatesConfig(t *testing.T) {
+	valid := S3Config{Endpoint: "https://s3.example.com", Bucket: "b", AccessKeyID: "k", SecretAccessKey: "s"}
+	tests := []struct {
+		name   string
+		modify func(*S3Config)
+	}{
+		{name: "no endpoint", modify: func(c *S3Config) { c.Endpoint = "" }},
+		{name: "no bucket", modify: func(c *S3Config) { c.Bucket = "" }},
+		{name: "no access key", modify: func(c *S3Config) { c.AccessKeyID = "" }},
+		{name: "no secret", modify: func(c *S3Config) { c.SecretAccessKey = "" }},
+		{name: "no scheme", modify: func(c *S3Config) { c.Endpoint = "s3.example.com" }},
+		{name: "ftp", modify: func(c *S3Config) { c.Endpoint = "ftp://s3.example.com" }},
+	}
+	if _, err := NewS3Store(valid); err != nil {
+		t.Fatalf("NewS3Store(valid) = %v", err)
+	}
+	for _, tc := range tests {
+		config := valid
+		tc.modify(&config)
+		if _, err := NewS3Store(config); err == nil {
+			t.Errorf("%s: NewS3Store succeeded, want an error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package storage keeps the binary content of attachments. A BlobStore maps
+// keys such as "tasks/<task id>/<attachment id>" to blobs; metadata about
+// them lives in MongoDB with the rest of the data.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned by BlobStore.Get for keys that hold no blob.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores blobs by key. Put reads exactly size bytes from r and
+// replaces any blob already stored under key. Delete succeeds for keys that
+// hold no blob, so deletions can be retried.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// keyPattern allows slash-separated segments of letters, digits, dots,
+// dashes and underscores.
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+
+func validateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
+
+// FromEnv builds the blob store named by BLOB_STORE. "local", the default,
+// keeps blobs in BLOB_DIR (./data/blobs unless set). "s3" uses an
+// S3-compatible service configured by S3_ENDPOINT, S3_REGION, S3_BUCKET,
+// S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY.
+func FromEnv() (BlobStore, error) {
+	switch kind := os.Getenv("BLOB_STORE"); kind {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q, want local or s3", kind)
+	}
+}
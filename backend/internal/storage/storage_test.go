@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testBlobStore checks the behaviour every BlobStore must share. newStore
+// must return an empty store for every call.
+func testBlobStore(t *testing.T, newStore func(t *testing.T) BlobStore) {
+	ctx := context.Background()
+	read := func(t *testing.T, store BlobStore, key string) string {
+		t.Helper()
+		blob, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		defer blob.Close()
+		data, err := io.ReadAll(blob)
+		if err != nil {
+			t.Fatalf("read %q: %v", key, err)
+		}
+		return string(data)
+	}
+
+	t.Run("PutGetDelete", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Put(ctx, "tasks/a/b", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		if got := read(t, store, "tasks/a/b"); got != "hello" {
+			t.Fatalf("Get = %q, want hello", got)
+		}
+		if err := store.Delete(ctx, "tasks/a/b"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := store.Get(ctx, "tasks/a/b"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("PutReplaces", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Put(ctx, "k", strings.NewReader("first"), 5, ""); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		if err := store.Put(ctx, "k", strings.NewReader("second"), 6, ""); err != nil {
+			t.Fatalf("second Put: %v", err)
+		}
+		if got := read(t, store, "k"); got != "second" {
+			t.Fatalf("Get = %q, want second", got)
+		}
+	})
+
+	t.Run("PutShortReaderFails", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Put(ctx, "k", strings.NewReader("hel"), 5, ""); err == nil {
+			t.Fatal("Put of 3 bytes with size 5 succeeded")
+		}
+		if _, err := store.Get(ctx, "k"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get after failed Put = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("MissingKeys", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Get(ctx, "nothing/here"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get = %v, want ErrNotFound", err)
+		}
+		if err := store.Delete(ctx, "nothing/here"); err != nil {
+			t.Fatalf("Delete = %v, want nil", err)
+		}
+	})
+
+	t.Run("InvalidKeys", func(t *testing.T) {
+		store := newStore(t)
+		for _, key := range []string{"", "/abs", "a//b", "a/", "../up", "a/../b", "a/./b", "sp ace", `back\slash`} {
+			if err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); err == nil {
+				t.Errorf("Put(%q) succeeded, want an error", key)
+			}
+			if _, err := store.Get(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(%q) = %v, want an invalid key error", key, err)
+			}
+			if err := store.Delete(ctx, key); err == nil {
+				t.Errorf("Delete(%q) succeeded, want an error", key)
+			}
+		}
+	})
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"tasks/6540f1a2b3c4d5e6f7a8b9c0/6540f1a2b3c4d5e6f7a8b9c1", true},
+		{"a.b-c_d", true},
+		{"..a/b..", true},
+		{"", false},
+		{"..", false},
+		{"a/../b", false},
+		{"a/.", false},
+		{"/a", false},
+		{"a/", false},
+		{"a//b", false},
+		{"a b", false},
+		{"ä", false},
+	}
+	for _, tc := range tests {
+		if err := validateKey(tc.key); (err == nil) != tc.valid {
+			t.Errorf("validateKey(%q) = %v, want valid %v", tc.key, err, tc.valid)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("BLOB_STORE", "")
+		t.Setenv("BLOB_DIR", dir)
+		store, err := FromEnv()
+		if err != nil {
+			t.Fatalf("FromEnv: %v", err)
+		}
+		if local, ok := store.(*LocalStore); !ok || local.root != dir {
+			t.Fatalf("FromEnv = %#v, want a LocalStore in %s", store, dir)
+		}
+	})
+
+	t.Run("s3", func(t *testing.T) {
+		t.Setenv("BLOB_STORE", "s3")
+		t.Setenv("S3_ENDPOINT", "http://localhost:9000")
+		t.Setenv("S3_REGION", "")
+		t.Setenv("S3_BUCKET", "attachments")
+		t.Setenv("S3_ACCESS_KEY_ID", "key")
+		t.Setenv("S3_SECRET_ACCESS_KEY", "secret")
+		store, err := FromEnv()
+		if err != nil {
+			t.Fatalf("FromEnv: %v", err)
+		}
+		if s3, ok := store.(*S3Store); !ok || s3.region != "us-east-1" || s3.bucket != "attachments" {
+			t.Fatalf("FromEnv = %#v, want an S3Store for attachments in us-east-1", store)
+		}
+	})
+
+	t.Run("s3 without credentials", func(t *testing.T) {
+		t.Setenv("BLOB_STORE", "s3")
+		t.Setenv("S3_ENDPOINT", "http://localhost:9000")
+		t.Setenv("S3_BUCKET", "attachments")
+		t.Setenv("S3_ACCESS_KEY_ID", "")
+		t.Setenv("S3_SECRET_ACCESS_KEY", "")
+		if _, err := FromEnv(); err == nil {
+			t.Fatal("FromEnv succeeded without credentials")
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Setenv("BLOB_STORE", "ftp")
+		if _, err := FromEnv(); err == nil {
+			t.Fatal("FromEnv succeeded for an unknown store")
+		}
+	})
+}
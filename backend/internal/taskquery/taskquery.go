@@ -0,0 +1,262 @@
+// Package taskquery parses the compact filter syntax accepted by the q=
+// parameter of task listings, for example
+//
+//	status:todo,in_progress priority:high tag:"needs review" due:<2026-11-01 -tag:blocked
+//
+// A query is a list of terms separated by spaces. Each term is field:value;
+// a leading "-" excludes matching tasks instead. Values may be quoted to
+// include spaces, and a comma-separated list matches any of its values. Due
+// terms may start with one of the comparisons <, <=, > or >=.
+//
+// The package only checks the syntax and the field names; interpreting the
+// values is left to the caller.
+package taskquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FieldStatus    = "status"
+	FieldCategory  = "category"
+	FieldPriority  = "priority"
+	FieldTag       = "tag"
+	FieldDue       = "due"
+	FieldAssignee  = "assignee"
+	FieldCreator   = "creator"
+	FieldProject   = "project"
+	FieldParent    = "parent"
+	FieldBlockedBy = "blocked_by"
+	FieldSeries    = "series"
+	// FieldCustom is the field of every custom field term; the term's
+	// Custom holds the key of the custom field.
+	FieldCustom = "cf"
+)
+
+// customPrefix starts the name of a custom field.
+const customPrefix = FieldCustom + "."
+
+type fieldRules struct {
+	negatable bool
+	compared  bool
+	multiple  bool
+}
+
+var fields = map[string]fieldRules{
+	FieldStatus:    {negatable: true, multiple: true},
+	FieldCategory:  {negatable: true, multiple: true},
+	FieldPriority:  {negatable: true, multiple: true},
+	FieldTag:       {negatable: true, multiple: true},
+	FieldDue:       {compared: true},
+	FieldAssignee:  {},
+	FieldCreator:   {},
+	FieldProject:   {},
+	FieldParent:    {multiple: true},
+	FieldBlockedBy: {},
+	FieldSeries:    {},
+	FieldCustom:    {negatable: true, compared: true, multiple: true},
+}
+
+// aliases maps alternative spellings to field names.
+var aliases = map[string]string{
+	"tags":        FieldTag,
+	"assigned_to": FieldAssignee,
+	"created_by":  FieldCreator,
+	"project_id":  FieldProject,
+	"parent_id":   FieldParent,
+	"series_id":   FieldSeries,
+}
+
+// Term is one field:value term of a query. Op is the comparison of a due or
+// custom field term, or empty for an exact match. Pos is the term's byte
+// offset in the query.
+type Term struct {
+	Field   string
+	Custom  string
+	Op      string
+	Values  []string
+	Negated bool
+	Pos     int
+}
+
+// Error describes a problem with a query and where it was found.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+// Parse splits a query into terms.
+func Parse(query string) ([]Term, error) {
+	p := &parser{query: query}
+	var terms []Term
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.query) {
+			return terms, nil
+		}
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+}
+
+// NewTerm builds a term outside of a query, applying the same rules as
+// Parse. It lets callers treat plain query parameters as terms. field may
+// name a custom field as cf.<key>.
+func NewTerm(field, op string, values []string, negated bool) (Term, error) {
+	custom, isCustom := customKey(field)
+	if isCustom {
+		if custom == "" {
+			return Term{}, &Error{Msg: "missing custom field key"}
+		}
+		field = FieldCustom
+	}
+	rules, ok := fields[field]
+	switch {
+	case !ok, field == FieldCustom && !isCustom:
+		return Term{}, &Error{Msg: fmt.Sprintf("unknown field %q", field)}
+	case negated && !rules.negatable:
+		return Term{}, &Error{Msg: fmt.Sprintf("%s cannot be negated", field)}
+	case op != "" && !rules.compared:
+		return Term{}, &Error{Msg: fmt.Sprintf("%s cannot be compared", field)}
+	case len(values) == 0:
+		return Term{}, &Error{Msg: fmt.Sprintf("missing value for %s", field)}
+	case len(values) > 1 && !rules.multiple:
+		return Term{}, &Error{Msg: fmt.Sprintf("%s takes a single value", field)}
+	}
+	return Term{Field: field, Custom: custom, Op: op, Values: values, Negated: negated}, nil
+}
+
+// customKey returns the key of a custom field name such as cf.story_points.
+func customKey(name string) (string, bool) {
+	if !strings.HasPrefix(name, customPrefix) {
+		return "", false
+	}
+	return name[len(customPrefix):], true
+}
+
+type parser struct {
+	query string
+	pos   int
+}
+
+func (p *parser) term() (Term, error) {
+	term := Term{Pos: p.pos}
+	if p.peek() == '-' {
+		term.Negated = true
+		p.pos++
+	}
+
+	start := p.pos
+	for p.pos < len(p.query) && isFieldChar(p.query[p.pos]) {
+		p.pos++
+	}
+	name := strings.ToLower(p.query[start:p.pos])
+	if name == "" || p.peek() != ':' {
+		return term, &Error{Pos: term.Pos, Msg: "expected field:value"}
+	}
+	p.pos++
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	if key, ok := customKey(name); ok {
+		if key == "" {
+			return term, &Error{Pos: start, Msg: "missing custom field key"}
+		}
+		term.Custom, name = key, FieldCustom
+	}
+	rules, ok := fields[name]
+	if name == FieldCustom && term.Custom == "" {
+		ok = false
+	}
+	if !ok {
+		return term, &Error{Pos: start, Msg: fmt.Sprintf("unknown field %q", name)}
+	}
+	term.Field = name
+	if term.Custom != "" {
+		name = customPrefix + term.Custom
+	}
+
+	if term.Negated && !rules.negatable {
+		return term, &Error{Pos: term.Pos, Msg: fmt.Sprintf("%s cannot be negated", name)}
+	}
+	if rules.compared {
+		for _, op := range []string{"<=", ">=", "<", ">"} {
+			if strings.HasPrefix(p.query[p.pos:], op) {
+				term.Op = op
+				p.pos += len(op)
+				break
+			}
+		}
+	}
+
+	for {
+		valuePos := p.pos
+		value, err := p.value()
+		if err != nil {
+			return term, err
+		}
+		if value == "" {
+			return term, &Error{Pos: valuePos, Msg: fmt.Sprintf("missing value for %s", name)}
+		}
+		term.Values = append(term.Values, value)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(term.Values) > 1 && !rules.multiple {
+		return term, &Error{Pos: term.Pos, Msg: fmt.Sprintf("%s takes a single value", name)}
+	}
+	if p.pos < len(p.query) && !isSpace(p.query[p.pos]) {
+		return term, &Error{Pos: p.pos, Msg: "unexpected character"}
+	}
+	return term, nil
+}
+
+// value reads a bare or double-quoted value. Bare values end at a space or a
+// comma.
+func (p *parser) value() (string, error) {
+	if p.peek() == '"' {
+		start := p.pos
+		end := strings.IndexByte(p.query[p.pos+1:], '"')
+		if end < 0 {
+			return "", &Error{Pos: start, Msg: "unterminated quote"}
+		}
+		p.pos += end + 2
+		return p.query[start+1 : start+1+end], nil
+	}
+	start := p.pos
+	for p.pos < len(p.query) && !isSpace(p.query[p.pos]) && p.query[p.pos] != ',' && p.query[p.pos] != '"' {
+		p.pos++
+	}
+	return p.query[start:p.pos], nil
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.query) {
+		return p.query[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.query) && isSpace(p.query[p.pos]) {
+		p.pos++
+	}
+}
+
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
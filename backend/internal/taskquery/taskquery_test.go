@@ -0,0 +1,190 @@
+package taskquery
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []Term
+	}{
+		{query: "", want: nil},
+		{query: "   ", want: nil},
+		{
+			query: "status:todo",
+			want:  []Term{{Field: FieldStatus, Values: []string{"todo"}}},
+		},
+		{
+			query: "status:todo,in_progress priority:high",
+			want: []Term{
+				{Field: FieldStatus, Values: []string{"todo", "in_progress"}},
+				{Field: FieldPriority, Values: []string{"high"}, Pos: 24},
+			},
+		},
+		{
+			query: `tag:"needs review",urgent`,
+			want:  []Term{{Field: FieldTag, Values: []string{"needs review", "urgent"}}},
+		},
+		{
+			query: `tag:"a,b"`,
+			want:  []Term{{Field: FieldTag, Values: []string{"a,b"}}},
+		},
+		{
+			query: "-tag:blocked",
+			want:  []Term{{Field: FieldTag, Values: []string{"blocked"}, Negated: true}},
+		},
+		{
+			query: "STATUS:done",
+			want:  []Term{{Field: FieldStatus, Values: []string{"done"}}},
+		},
+		{
+			query: "due:2026-11-01",
+			want:  []Term{{Field: FieldDue, Values: []string{"2026-11-01"}}},
+		},
+		{
+			query: "due:<2026-11-01 due:>=2026-10-01",
+			want: []Term{
+				{Field: FieldDue, Op: "<", Values: []string{"2026-11-01"}},
+				{Field: FieldDue, Op: ">=", Values: []string{"2026-10-01"}, Pos: 16},
+			},
+		},
+		{
+			query: "due:<=2026-11-01T12:00:00Z",
+			want:  []Term{{Field: FieldDue, Op: "<=", Values: []string{"2026-11-01T12:00:00Z"}}},
+		},
+		{
+			// Only fields that can be compared take an operator; elsewhere
+			// it is part of the value, which the caller rejects.
+			query: "priority:<high",
+			want:  []Term{{Field: FieldPriority, Values: []string{"<high"}}},
+		},
+		{
+			query: "tags:x assigned_to:me created_by:me project_id:p parent_id:q series_id:s",
+			want: []Term{
+				{Field: FieldTag, Values: []string{"x"}},
+				{Field: FieldAssignee, Values: []string{"me"}, Pos: 7},
+				{Field: FieldCreator, Values: []string{"me"}, Pos: 22},
+				{Field: FieldProject, Values: []string{"p"}, Pos: 36},
+				{Field: FieldParent, Values: []string{"q"}, Pos: 49},
+				{Field: FieldSeries, Values: []string{"s"}, Pos: 61},
+			},
+		},
+		{
+			query: "cf.story_points:>3",
+			want:  []Term{{Field: FieldCustom, Custom: "story_points", Op: ">", Values: []string{"3"}}},
+		},
+		{
+			query: `-cf.team:"core platform",web`,
+			want:  []Term{{Field: FieldCustom, Custom: "team", Values: []string{"core platform", "web"}, Negated: true}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := Parse(tc.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tc.query, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{query: "todo", pos: 0, msg: "expected field:value"},
+		{query: "status:todo :x", pos: 12, msg: "expected field:value"},
+		{query: "colour:red", pos: 0, msg: `unknown field "colour"`},
+		{query: "status:todo -colour:red", pos: 13, msg: `unknown field "colour"`},
+		{query: "cf:3", pos: 0, msg: `unknown field "cf"`},
+		{query: "cf.:3", pos: 0, msg: "missing custom field key"},
+		{query: "-due:2026-11-01", pos: 0, msg: "due cannot be negated"},
+		{query: "-assignee:me", pos: 0, msg: "assignee cannot be negated"},
+		{query: "status:", pos: 7, msg: "missing value for status"},
+		{query: "status:todo,", pos: 12, msg: "missing value for status"},
+		{query: "due:<", pos: 5, msg: "missing value for due"},
+		{query: "cf.points:>", pos: 11, msg: "missing value for cf.points"},
+		{query: "assignee:a,b", pos: 0, msg: "assignee takes a single value"},
+		{query: `tag:"open`, pos: 4, msg: "unterminated quote"},
+		{query: `tag:"a"b`, pos: 7, msg: "unexpected character"},
+		{query: `tag:a"b"`, pos: 5, msg: "unexpected character"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			terms, err := Parse(tc.query)
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse(%q) = %+v, %v; want an *Error", tc.query, terms, err)
+			}
+			if perr.Pos != tc.pos || perr.Msg != tc.msg {
+				t.Errorf("Parse(%q) error = %q at %d, want %q at %d", tc.query, perr.Msg, perr.Pos, tc.msg, tc.pos)
+			}
+		})
+	}
+}
+
+func TestNewTerm(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		op      string
+		values  []string
+		negated bool
+		want    Term
+		wantErr string
+	}{
+		{
+			name:   "plain field",
+			field:  FieldPriority,
+			values: []string{"high", "low"},
+			want:   Term{Field: FieldPriority, Values: []string{"high", "low"}},
+		},
+		{
+			name:   "custom field",
+			field:  "cf.points",
+			op:     ">=",
+			values: []string{"3"},
+			want:   Term{Field: FieldCustom, Custom: "points", Op: ">=", Values: []string{"3"}},
+		},
+		{name: "unknown field", field: "colour", values: []string{"red"}, wantErr: `unknown field "colour"`},
+		{name: "bare custom field", field: FieldCustom, values: []string{"3"}, wantErr: `unknown field "cf"`},
+		{name: "missing custom key", field: "cf.", values: []string{"3"}, wantErr: "missing custom field key"},
+		{name: "negated", field: FieldDue, values: []string{"2026-11-01"}, negated: true, wantErr: "due cannot be negated"},
+		{name: "compared", field: FieldStatus, op: "<", values: []string{"todo"}, wantErr: "status cannot be compared"},
+		{name: "no values", field: FieldTag, wantErr: "missing value for tag"},
+		{name: "several values", field: FieldCreator, values: []string{"a", "b"}, wantErr: "creator takes a single value"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewTerm(tc.field, tc.op, tc.values, tc.negated)
+			if tc.wantErr != "" {
+				var perr *Error
+				if !errors.As(err, &perr) || perr.Msg != tc.wantErr {
+					t.Fatalf("NewTerm = %+v, %v; want error %q", got, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTerm: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("NewTerm = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestErrorReportsOneBasedPosition(t *testing.T) {
+	err := &Error{Pos: 4, Msg: "unterminated quote"}
+	if got, want := err.Error(), "unterminated quote at position 5"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
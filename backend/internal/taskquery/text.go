@@ -0,0 +1,251 @@
+package taskquery
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shrey258/task_management/internal/models"
+)
+
+// ParseText parses a full-text search such as
+//
+//	login "session timeout" deploy* -flaky
+//
+// Bare words must all appear, quoted phrases must appear as written, a
+// trailing "*" matches any word starting with what precedes it, and a
+// leading "-" excludes tasks containing the word.
+func ParseText(query string) (models.TextQuery, error) {
+	var text models.TextQuery
+	p := &parser{query: query}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.query) {
+			break
+		}
+		start := p.pos
+
+		if p.peek() == '"' {
+			phrase, err := p.value()
+			if err != nil {
+				return text, err
+			}
+			if words := textWords(phrase); len(words) > 0 {
+				text.Phrases = append(text.Phrases, strings.Join(words, " "))
+			}
+			continue
+		}
+
+		for p.pos < len(p.query) && !isSpace(p.query[p.pos]) {
+			p.pos++
+		}
+		word := strings.ToLower(p.query[start:p.pos])
+		switch {
+		case strings.HasPrefix(word, "-"):
+			word = word[1:]
+			if strings.ContainsAny(word, `*"`) || len(textWords(word)) != 1 {
+				return text, &Error{Pos: start, Msg: "only single words can be excluded"}
+			}
+			text.Excluded = append(text.Excluded, word)
+		case strings.HasSuffix(word, "*"):
+			word = strings.TrimSuffix(word, "*")
+			if strings.ContainsAny(word, `*"`) || len(textWords(word)) != 1 {
+				return text, &Error{Pos: start, Msg: "a prefix must be a single word followed by *"}
+			}
+			text.Prefixes = append(text.Prefixes, word)
+		case strings.ContainsAny(word, `*"`):
+			return text, &Error{Pos: start, Msg: "unexpected character"}
+		default:
+			// Punctuation inside a word splits it, as the text index does.
+			words := textWords(word)
+			if len(words) == 1 {
+				text.Words = append(text.Words, words[0])
+			} else if len(words) > 1 {
+				text.Phrases = append(text.Phrases, strings.Join(words, " "))
+			}
+		}
+	}
+
+	if len(text.Words)+len(text.Phrases)+len(text.Prefixes) == 0 {
+		return text, &Error{Pos: 0, Msg: "search needs at least one word"}
+	}
+	return text, nil
+}
+
+// TextMatcher evaluates a TextQuery against tasks in Go. It backs the
+// in-memory store and highlights matches for either store. Words match
+// whole words, also in their plural forms, rather than using the stemming
+// of MongoDB's text index.
+type TextMatcher struct {
+	required []*regexp.Regexp
+	excluded []*regexp.Regexp
+}
+
+func NewTextMatcher(text models.TextQuery) *TextMatcher {
+	m := &TextMatcher{}
+	for _, word := range text.Words {
+		m.required = append(m.required, wordPattern(word))
+	}
+	for _, phrase := range text.Phrases {
+		words := strings.Fields(phrase)
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		m.required = append(m.required, regexp.MustCompile(`(?i)`+strings.Join(words, `[^\p{L}\p{N}]+`)))
+	}
+	for _, prefix := range text.Prefixes {
+		m.required = append(m.required, regexp.MustCompile(`(?i)`+regexp.QuoteMeta(prefix)+`[\p{L}\p{N}]*`))
+	}
+	for _, word := range text.Excluded {
+		m.excluded = append(m.excluded, wordPattern(word))
+	}
+	return m
+}
+
+// Score reports whether the task matches and how relevant it is: the number
+// of matches in each field, weighted by the field's search weight.
+func (m *TextMatcher) Score(task *models.Task) (float64, bool) {
+	for _, pattern := range m.excluded {
+		if len(wordMatches(pattern, task.Title)) > 0 || len(wordMatches(pattern, task.Description)) > 0 {
+			return 0, false
+		}
+		for _, tag := range task.Tags {
+			if len(wordMatches(pattern, tag)) > 0 {
+				return 0, false
+			}
+		}
+	}
+
+	var score float64
+	for _, pattern := range m.required {
+		hits := models.SearchWeightTitle*len(wordMatches(pattern, task.Title)) +
+			models.SearchWeightDescription*len(wordMatches(pattern, task.Description))
+		for _, tag := range task.Tags {
+			hits += models.SearchWeightTags * len(wordMatches(pattern, tag))
+		}
+		if hits == 0 {
+			return 0, false
+		}
+		score += float64(hits)
+	}
+	return score, true
+}
+
+// Matches reports whether text contains any of the query's words, phrases
+// or prefixes.
+func (m *TextMatcher) Matches(text string) bool {
+	return len(m.spans(text)) > 0
+}
+
+// Highlight returns text as HTML with every match wrapped in <mark>. Text
+// longer than maxLen bytes is cut to a window around the first match, with
+// an ellipsis marking each cut. It reports false if nothing matched.
+func (m *TextMatcher) Highlight(text string, maxLen int) (string, bool) {
+	spans := m.spans(text)
+	if len(spans) == 0 {
+		return html.EscapeString(truncate(text, maxLen)), false
+	}
+
+	start, end := 0, len(text)
+	if len(text) > maxLen {
+		start = spans[0][0] - maxLen/4
+		if start < 0 {
+			start = 0
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		end = start + maxLen
+		if end > len(text) {
+			end = len(text)
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, span := range spans {
+		if span[1] <= start || span[0] >= end {
+			continue
+		}
+		from, to := max(span[0], pos), min(span[1], end)
+		b.WriteString(html.EscapeString(text[pos:from]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[from:to]))
+		b.WriteString("</mark>")
+		pos = to
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// spans returns the merged byte ranges of text matched by the query, in
+// order.
+func (m *TextMatcher) spans(text string) [][2]int {
+	var spans [][2]int
+	for _, pattern := range m.required {
+		spans = append(spans, wordMatches(pattern, text)...)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var merged [][2]int
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], span[1])
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// wordPattern matches a word and its plural forms.
+func wordPattern(word string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)` + regexp.QuoteMeta(word) + `(?:e?s)?`)
+}
+
+// wordMatches returns the matches of pattern in text that start and end on
+// word boundaries.
+func wordMatches(pattern *regexp.Regexp, text string) [][2]int {
+	var matches [][2]int
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+		after, _ := utf8.DecodeRuneInString(text[loc[1]:])
+		if loc[0] > 0 && isWordRune(before) || loc[1] < len(text) && isWordRune(after) {
+			continue
+		}
+		matches = append(matches, [2]int{loc[0], loc[1]})
+	}
+	return matches
+}
+
+// textWords splits s into lower-case words the way the matcher sees them.
+func textWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !isWordRune(r) })
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+func truncate(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	end := maxLen
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end] + "…"
+}
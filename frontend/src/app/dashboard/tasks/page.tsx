@@ -89,7 +89,7 @@ export default function TasksPage() {
           const priorityOrder = { high: 0, medium: 1, low: 2 };
           return priorityOrder[a.priority as keyof typeof priorityOrder] - priorityOrder[b.priority as keyof typeof priorityOrder];
         case 'status':
-          const statusOrder = { in_progress: 0, pending: 1, completed: 2 };
+          const statusOrder = { in_progress: 0, todo: 1, completed: 2 };
           return statusOrder[a.status as keyof typeof statusOrder] - statusOrder[b.status as keyof typeof statusOrder];
         default:
           return 0;
@@ -4,7 +4,7 @@ import { useState } from 'react';
 import { Task } from '@/types';
 
 type TaskPriority = 'low' | 'medium' | 'high';
-type TaskStatus = 'pending' | 'in_progress' | 'completed';
+type TaskStatus = 'todo' | 'in_progress' | 'completed';
 
 interface TaskFormProps {
   onSubmit: (taskData: Partial<Task>) => Promise<void>;
@@ -17,7 +17,7 @@ export default function TaskForm({ onSubmit, onCancel, loading = false, initialD
   const [title, setTitle] = useState(initialData?.title || '');
   const [description, setDescription] = useState(initialData?.description || '');
   const [priority, setPriority] = useState<TaskPriority>((initialData?.priority as TaskPriority) || 'medium');
-  const [status, setStatus] = useState<TaskStatus>((initialData?.status as TaskStatus) || 'pending');
+  const [status, setStatus] = useState<TaskStatus>((initialData?.status as TaskStatus) || 'todo');
   const [dueDate, setDueDate] = useState(initialData?.dueDate ? new Date(initialData.dueDate).toISOString().split('T')[0] : '');
   const [tags, setTags] = useState<string[]>(initialData?.tags || []);
   const [newTag, setNewTag] = useState('');
@@ -104,7 +104,7 @@ export default function TaskForm({ onSubmit, onCancel, loading = false, initialD
           onChange={(e) => setStatus(e.target.value as TaskStatus)}
           className="mt-1 block w-full rounded-md border-gray-300 shadow-sm focus:border-blue-500 focus:ring-blue-500 text-gray-900"
         >
-          <option value="pending">Pending</option>
+          <option value="todo">To do</option>
           <option value="in_progress">In Progress</option>
           <option value="completed">Completed</option>
         </select>
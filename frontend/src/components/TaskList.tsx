@@ -117,7 +117,7 @@ export default function TaskList({ tasks, onUpdate, onDelete }: TaskListProps) {
                       task.status
                     )}`}
                   >
-                    <option value="pending">Pending</option>
+                    <option value="todo">To do</option>
                     <option value="in_progress">In Progress</option>
                     <option value="completed">Completed</option>
                   </select>
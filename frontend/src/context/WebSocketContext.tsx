@@ -21,13 +21,15 @@ interface WebSocketProviderProps {
   onTaskUpdate?: (task: Task) => void;
   onTaskCreate?: (task: Task) => void;
   onTaskDelete?: (taskId: string) => void;
+  onTaskMove?: (move: any) => void;
 }
 
 export function WebSocketProvider({ 
   children,
   onTaskUpdate,
   onTaskCreate,
-  onTaskDelete
+  onTaskDelete,
+  onTaskMove
 }: WebSocketProviderProps) {
   const { token, isAuthenticated } = useAuth();
   const ws = useRef<WebSocket | null>(null);
@@ -101,8 +103,18 @@ export function WebSocketProvider({
             case 'task_created':
               onTaskCreate?.(message.payload);
               break;
+            case 'task_restored':
+              onTaskCreate?.(message.payload);
+              break;
             case 'task_deleted':
-              onTaskDelete?.(message.payload);
+              onTaskDelete?.(message.payload.id);
+              break;
+            case 'task_moved':
+              onTaskMove?.(message.payload);
+              break;
+            case 'tasks_bulk':
+              message.payload.updated.forEach((task: any) => onTaskUpdate?.(task));
+              message.payload.deleted.forEach((deleted: any) => onTaskDelete?.(deleted.id));
               break;
             default:
               console.log('WebSocket: Unknown message type:', message.type);